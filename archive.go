@@ -0,0 +1,132 @@
+package testutil
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// archiveEntry is a file or directory in an archive.
+type archiveEntry struct {
+	mode    os.FileMode
+	content string
+}
+
+// CompareArchives compares two zip, tar or gzipped tar archives and
+// returns a string detailing which entries are missing or unexpected
+// and, for entries in both, how their mode or content differ. It is
+// useful for testing code which generates exports or bundles.
+// Modification times are not compared since they rarely stay the
+// same between runs.
+func CompareArchives(got []byte, want []byte) string {
+	gotEntries, err := readArchive(got)
+	if err != nil {
+		return fmt.Sprintf("could not read got archive: %v", err)
+	}
+	wantEntries, err := readArchive(want)
+	if err != nil {
+		return fmt.Sprintf("could not read want archive: %v", err)
+	}
+	diffs := []string{}
+	for _, name := range sortedEntryNames(wantEntries) {
+		if _, ok := gotEntries[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("missing entry %q", name))
+		}
+	}
+	for _, name := range sortedEntryNames(gotEntries) {
+		if _, ok := wantEntries[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("unexpected entry %q", name))
+		}
+	}
+	for _, name := range sortedEntryNames(wantEntries) {
+		g, ok := gotEntries[name]
+		if !ok {
+			continue
+		}
+		w := wantEntries[name]
+		if g.mode != w.mode {
+			diffs = append(diffs, fmt.Sprintf("entry %q got mode %v, want %v", name, g.mode, w.mode))
+		}
+		if diff := CompareStrings(g.content, w.content); diff != "" {
+			diffs = append(diffs, fmt.Sprintf("entry %q content is not expected, %s", name, diff))
+		}
+	}
+	if len(diffs) > 0 {
+		return "archives differ:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+// readArchive reads every entry from a zip, tar or gzipped tar
+// archive, the format is detected from the data.
+func readArchive(b []byte) (map[string]archiveEntry, error) {
+	if bytes.HasPrefix(b, []byte("PK\x03\x04")) || bytes.HasPrefix(b, []byte("PK\x05\x06")) {
+		return readZip(b)
+	}
+	var r io.Reader = bytes.NewReader(b)
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return readTar(r)
+}
+
+func readZip(b []byte) (map[string]archiveEntry, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]archiveEntry{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("opening %q: %v", f.Name, err)
+		}
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %q: %v", f.Name, err)
+		}
+		entries[f.Name] = archiveEntry{mode: f.Mode(), content: string(content)}
+	}
+	return entries, nil
+}
+
+func readTar(r io.Reader) (map[string]archiveEntry, error) {
+	tr := tar.NewReader(r)
+	entries := map[string]archiveEntry{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("reading %q: %v", hdr.Name, err)
+		}
+		entries[hdr.Name] = archiveEntry{mode: hdr.FileInfo().Mode(), content: string(content)}
+	}
+}
+
+func sortedEntryNames(entries map[string]archiveEntry) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,127 @@
+package testutil_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+type testFile struct {
+	name    string
+	mode    int64
+	content string
+}
+
+func makeZip(files []testFile) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		hdr := &zip.FileHeader{Name: f.name, Method: zip.Deflate}
+		hdr.SetMode(0644)
+		if f.mode != 0 {
+			hdr.SetMode(0755)
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			panic(err)
+		}
+		w.Write([]byte(f.content))
+	}
+	if err := zw.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func makeTarGz(files []testFile) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		mode := f.mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: mode, Size: int64(len(f.content)), Typeflag: tar.TypeReg}); err != nil {
+			panic(err)
+		}
+		tw.Write([]byte(f.content))
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+// TestCompareArchives tests that the expected diff is generated when
+// comparing archives.
+func TestCompareArchives(t *testing.T) {
+	want := []testFile{
+		{name: "README", content: "hello"},
+		{name: "bin/run", mode: 0755, content: "#!/bin/sh"},
+		{name: "data.csv", content: "a,b\n1,2\n"},
+	}
+	changed := []testFile{
+		{name: "README", content: "hello"},
+		{name: "bin/run", content: "#!/bin/sh"},
+		{name: "data.csv", content: "a,b\n1,3\n"},
+		{name: "extra.txt", content: ""},
+	}
+	wantDiff := `archives differ:
+unexpected entry "extra.txt"
+entry "bin/run" got mode -rw-r--r--, want -rwxr-xr-x
+entry "data.csv" content is not expected, strings differ at index 6 (line 2, column 3), from that index on:
+##### got string #####
+3
+
+##### want string #####
+2
+`
+	tests := []struct {
+		name     string
+		got      []byte
+		want     []byte
+		wantDiff string
+	}{
+		{
+			name:     "zip archives equal",
+			got:      makeZip(want),
+			want:     makeZip(want),
+			wantDiff: "",
+		},
+		{
+			name:     "tar.gz archives equal",
+			got:      makeTarGz(want),
+			want:     makeTarGz(want),
+			wantDiff: "",
+		},
+		{
+			name:     "zip archives differ",
+			got:      makeZip(changed),
+			want:     makeZip(want),
+			wantDiff: wantDiff,
+		},
+		{
+			name:     "tar.gz archives differ",
+			got:      makeTarGz(changed),
+			want:     makeTarGz(want),
+			wantDiff: wantDiff,
+		},
+		{
+			name:     "missing entry",
+			got:      makeZip(want[:1]),
+			want:     makeZip(want[:2]),
+			wantDiff: "archives differ:\nmissing entry \"bin/run\"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.CompareArchives(test.got, test.want), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package testutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// BasicAuth is the credentials a request should send with HTTP basic
+// authentication.
+type BasicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// basicAuthDiffs adds a diff if got doesn't send the credentials in
+// want. The password got is never included in the diff so failing
+// tests don't leak secrets into logs.
+func basicAuthDiffs(diffs *Diffs, got *http.Request, want *BasicAuth) {
+	if want == nil {
+		return
+	}
+	username, password, ok := got.BasicAuth()
+	if !ok {
+		diffs.add("basic auth", DiffMissing, nil, want.Username, fmt.Sprintf("missing basic auth credentials, want username %q", want.Username))
+		return
+	}
+	if username != want.Username {
+		diffs.add("basic auth username", DiffChanged, username, want.Username, fmt.Sprintf("basic auth username: got %q, want %q", username, want.Username))
+	}
+	if password != want.Password {
+		diffs.add("basic auth password", DiffChanged, nil, nil, fmt.Sprintf("basic auth password for username %q does not match", username))
+	}
+}
+
+// bearerTokenDiffs adds a diff if the bearer token got sends isn't a
+// JWT with the claims in want. The signature is only verified when
+// key is set.
+func bearerTokenDiffs(diffs *Diffs, got *http.Request, want map[string]interface{}, key interface{}) {
+	if want == nil && key == nil {
+		return
+	}
+	auth := got.Header.Get("Authorization")
+	if len(auth) < len("Bearer ") || !strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		diffs.add("bearer token", DiffMissing, nil, nil, "missing bearer token in Authorization header")
+		return
+	}
+	claims, err := parseJWT(strings.TrimSpace(auth[len("Bearer "):]), key)
+	if err != nil {
+		diffs.add("bearer token", DiffChanged, nil, nil, fmt.Sprintf("bearer token is not valid: %v", err))
+		return
+	}
+	// Round trip the wanted claims through JSON so they can be
+	// compared like CompareJSON does.
+	decoded, err := decodeJSON(compactJSON(want))
+	if err != nil {
+		diffs.add("bearer token", DiffChanged, nil, nil, fmt.Sprintf("could not encode wanted claims: %v", err))
+		return
+	}
+	wantClaims, _ := decoded.(map[string]interface{})
+	for _, name := range sortedKeys(wantClaims) {
+		path := "$." + name
+		g, ok := claims[name]
+		if !ok {
+			diffs.add("bearer token "+path, DiffMissing, nil, want[name], fmt.Sprintf("bearer token is missing claim %q", name))
+			continue
+		}
+		for _, diff := range compareJSONValues(path, g, wantClaims[name], options{}) {
+			diffs.add("bearer token "+path, DiffChanged, g, want[name], "bearer token claim "+diff)
+		}
+	}
+}
+
+// parseJWT returns the claims in a JWT, verifying its signature with
+// key if key is not nil.
+func parseJWT(token string, key interface{}) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("got %d parts separated by \".\", want 3", len(parts))
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("could not decode header: %v", err)
+	}
+	if key != nil {
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("could not decode signature: %v", err)
+		}
+		if err := verifyJWT(header.Alg, parts[0]+"."+parts[1], sig, key); err != nil {
+			return nil, err
+		}
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("could not decode claims: %v", err)
+	}
+	claims, err := decodeJSON(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode claims: %v", err)
+	}
+	m, ok := claims.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("claims are not a JSON object")
+	}
+	return m, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func verifyJWT(alg string, signed string, sig []byte, key interface{}) error {
+	hashes := map[string]crypto.Hash{"256": crypto.SHA256, "384": crypto.SHA384, "512": crypto.SHA512}
+	if len(alg) != 5 || hashes[alg[2:]] == 0 {
+		return fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	hash := hashes[alg[2:]]
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+	switch key := key.(type) {
+	case []byte:
+		if alg[:2] != "HS" {
+			break
+		}
+		mac := hmac.New(hash.New, key)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return fmt.Errorf("signature does not match")
+		}
+		return nil
+	case *rsa.PublicKey:
+		if alg[:2] != "RS" {
+			break
+		}
+		if err := rsa.VerifyPKCS1v15(key, hash, digest, sig); err != nil {
+			return fmt.Errorf("signature does not match: %v", err)
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" || len(sig)%2 != 0 {
+			break
+		}
+		r, s := new(big.Int).SetBytes(sig[:len(sig)/2]), new(big.Int).SetBytes(sig[len(sig)/2:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return fmt.Errorf("signature does not match")
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot verify a %s signature with a %T key", alg, key)
+}
@@ -0,0 +1,116 @@
+package testutil_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCheckHTTPRequestBasicAuth tests that basic auth credentials are
+// checked without revealing the password which was sent.
+func TestCheckHTTPRequestBasicAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     *testutil.BasicAuth
+		wantDiff string
+	}{
+		{
+			name:     "credentials match",
+			username: "bob",
+			password: "hunter2",
+			want:     &testutil.BasicAuth{Username: "bob", Password: "hunter2"},
+			wantDiff: "",
+		},
+		{
+			name:     "credentials differ",
+			username: "alice",
+			password: "s3cret",
+			want:     &testutil.BasicAuth{Username: "bob", Password: "hunter2"},
+			wantDiff: `request does not match what is expected:
+basic auth username: got "alice", want "bob"
+basic auth password for username "alice" does not match`,
+		},
+		{
+			name:     "no credentials",
+			want:     &testutil.BasicAuth{Username: "bob", Password: "hunter2"},
+			wantDiff: "request does not match what is expected:\nmissing basic auth credentials, want username \"bob\"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotReq := testutil.MustNewHTTPRequest("GET", "http://hello.com", strings.NewReader(""))
+			if test.username != "" {
+				gotReq.SetBasicAuth(test.username, test.password)
+			}
+			diff := testutil.CheckHTTPRequest(gotReq, testutil.HTTPRequest{Method: "GET", URL: "http://hello.com", BasicAuth: test.want})
+			if got, want := diff, test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCheckHTTPRequestBearerClaims tests that the claims in a JWT
+// bearer token are checked.
+func TestCheckHTTPRequestBearerClaims(t *testing.T) {
+	secret := []byte("secret")
+	sign := func(claims string, key []byte) string {
+		signed := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signed))
+		return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	}
+	token := sign(`{"sub": "bob", "admin": true, "exp": 1700000000, "scope": ["read"]}`, secret)
+	tests := []struct {
+		name     string
+		auth     string
+		claims   map[string]interface{}
+		key      interface{}
+		wantDiff string
+	}{
+		{
+			name:     "claims match",
+			auth:     "Bearer " + token,
+			claims:   map[string]interface{}{"sub": "bob", "exp": 1700000000, "scope": []string{"read"}},
+			key:      secret,
+			wantDiff: "",
+		},
+		{
+			name:   "claims differ",
+			auth:   "Bearer " + token,
+			claims: map[string]interface{}{"sub": "alice", "admin": true, "tenant": "acme"},
+			wantDiff: `request does not match what is expected:
+bearer token claim $.sub: got "bob", want "alice"
+bearer token is missing claim "tenant"`,
+		},
+		{
+			name:     "bad signature",
+			auth:     "Bearer " + sign(`{"sub": "bob"}`, []byte("wrong")),
+			claims:   map[string]interface{}{"sub": "bob"},
+			key:      secret,
+			wantDiff: "request does not match what is expected:\nbearer token is not valid: signature does not match",
+		},
+		{
+			name:     "not a bearer token",
+			auth:     "Basic Ym9iOmh1bnRlcjI=",
+			claims:   map[string]interface{}{"sub": "bob"},
+			wantDiff: "request does not match what is expected:\nmissing bearer token in Authorization header",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotReq := testutil.MustNewHTTPRequest("GET", "http://hello.com", strings.NewReader(""))
+			gotReq.Header.Set("Authorization", test.auth)
+			diff := testutil.CheckHTTPRequest(gotReq, testutil.HTTPRequest{Method: "GET", URL: "http://hello.com", BearerClaims: test.claims, BearerKey: test.key})
+			if got, want := diff, test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
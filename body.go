@@ -0,0 +1,218 @@
+package testutil
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// BodyComparator compares a got body against a wanted one and returns
+// a string detailing how they differ or "" if they don't.
+// CompareStrings, CompareJSON, CompareXML and CompareForm are all
+// BodyComparators.
+type BodyComparator func(got string, want string, opts ...Option) string
+
+var (
+	bodyComparatorsMu sync.RWMutex
+	// bodyComparators maps media types to the comparator used for
+	// bodies of that type. Keys starting with "+" match structured
+	// syntax suffixes so "+json" covers "application/problem+json".
+	bodyComparators = map[string]BodyComparator{
+		"application/json": CompareJSON,
+		"+json":            CompareJSON,
+		"application/xml":  CompareXML,
+		"text/xml":         CompareXML,
+		"+xml":             CompareXML,
+		"text/plain":       CompareStrings,
+
+		"application/x-www-form-urlencoded": CompareForm,
+	}
+)
+
+// RegisterBodyComparator makes CheckHTTPRequest and CheckHTTPResponse
+// compare bodies whose Content-Type has mediaType with c. mediaType
+// can also be a structured syntax suffix like "+cbor". Registering a
+// comparator for a media type which already has one replaces it.
+// Packages for formats which need dependencies, like prototest for
+// protobuf, register their comparators when they are imported.
+func RegisterBodyComparator(mediaType string, c BodyComparator) {
+	bodyComparatorsMu.Lock()
+	defer bodyComparatorsMu.Unlock()
+	bodyComparators[strings.ToLower(mediaType)] = c
+}
+
+// JSONBody makes CheckHTTPRequest and CheckHTTPResponse compare
+// bodies as JSON, like CompareJSON does, even if the Content-Type
+// header doesn't say they are JSON.
+func JSONBody() Option {
+	return func(o *options) {
+		o.jsonBody = true
+	}
+}
+
+// bodyDiffs adds a diff if the body of a request or response doesn't
+// match m or, when m is nil, want. The body is restored so it can be
+// read again and bodies with a gzip or deflate Content-Encoding are
+// decompressed before being compared.
+func bodyDiffs(diffs *Diffs, header http.Header, body *io.ReadCloser, want string, m Matcher, opts []Option) {
+	got, err := readAndRestore(body)
+	if err != nil {
+		diffs.add("body", DiffChanged, nil, want, fmt.Sprintf("could not read body: %v", err))
+		return
+	}
+	got, encoding, err := decodeBody(header, got)
+	if err != nil {
+		diffs.add("body", DiffChanged, got, want, fmt.Sprintf("could not decompress %s body: %v", encoding, err))
+		return
+	}
+	name := "body"
+	if encoding != "" {
+		name = fmt.Sprintf("body after %s decompression", encoding)
+	}
+	if m != nil {
+		if diff := matchField(name, m, got); diff != "" {
+			diffs.add("body", DiffChanged, got, nil, diff)
+		}
+	} else if diff := compareBody(header, got, want, opts); diff != "" {
+		diffs.add("body", DiffChanged, got, want, name+" is not expected, "+diff)
+	}
+}
+
+// decodeBody undoes a gzip or deflate Content-Encoding and returns
+// the decoded body along with the encoding it undid. Other bodies are
+// returned as is.
+func decodeBody(header http.Header, body string) (string, string, error) {
+	encoding := strings.ToLower(strings.TrimSpace(header.Get("Content-Encoding")))
+	var r io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(strings.NewReader(body))
+		if err != nil {
+			return body, encoding, err
+		}
+		r = zr
+	case "deflate":
+		// deflate is meant to be zlib wrapped but some servers
+		// send raw deflate data.
+		if zr, err := zlib.NewReader(strings.NewReader(body)); err == nil {
+			r = zr
+		} else {
+			r = flate.NewReader(strings.NewReader(body))
+		}
+	default:
+		return body, "", nil
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return body, encoding, err
+	}
+	return string(b), encoding, nil
+}
+
+// compareBody compares a request or response body with the
+// comparator registered for the Content-Type in header, falling back
+// to CompareStrings, unless the options say what the body is. The
+// Normalize normalizers are applied first whatever the comparator and
+// two empty bodies are always equal, a 204 has no JSON to parse.
+func compareBody(header http.Header, got string, want string, opts []Option) string {
+	o := newOptions(opts)
+	if len(o.normalizers) > 0 {
+		for _, n := range o.normalizers {
+			got, want = n(got), n(want)
+		}
+		opts = append(opts[:len(opts):len(opts)], func(o *options) { o.normalizers = nil })
+	}
+	if got == "" && want == "" {
+		return ""
+	}
+	if o.jsonBody {
+		return CompareJSON(got, want, opts...)
+	}
+	ct := header.Get("Content-Type")
+	opts = append(opts[:len(opts):len(opts)], contentType(ct))
+	if o.bodyComparator != nil {
+		return o.bodyComparator(got, want, opts...)
+	}
+	return bodyComparatorFor(ct)(got, want, opts...)
+}
+
+// CompareBodyWith makes CheckHTTPRequest and CheckHTTPResponse compare
+// bodies with c whatever their Content-Type.
+func CompareBodyWith(c BodyComparator) Option {
+	return func(o *options) {
+		o.bodyComparator = c
+	}
+}
+
+// contentType passes the Content-Type of a body on to the comparator
+// for it.
+func contentType(ct string) Option {
+	return func(o *options) {
+		o.contentType = ct
+	}
+}
+
+// BodyContentType returns the Content-Type of the body a
+// BodyComparator was called to compare, from the opts it was passed,
+// or "" if it wasn't called to compare a body.
+func BodyContentType(opts ...Option) string {
+	return newOptions(opts).contentType
+}
+
+func bodyComparatorFor(contentType string) BodyComparator {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return CompareStrings
+	}
+	bodyComparatorsMu.RLock()
+	defer bodyComparatorsMu.RUnlock()
+	if c, ok := bodyComparators[mediaType]; ok {
+		return c
+	}
+	if i := strings.LastIndex(mediaType, "+"); i >= 0 {
+		if c, ok := bodyComparators[mediaType[i:]]; ok {
+			return c
+		}
+	}
+	return CompareStrings
+}
+
+// framingDiffs adds a diff if the Content-Length or Transfer-Encoding
+// of a request or response differ from the ones wanted, if any.
+func framingDiffs(diffs *Diffs, gotLength int64, gotEncoding []string, wantLength int64, wantEncoding []string) {
+	if wantLength != 0 && gotLength != wantLength {
+		diffs.add("content length", DiffChanged, gotLength, wantLength, fmt.Sprintf("got content length %d, want %d", gotLength, wantLength))
+	}
+	if wantEncoding != nil && strings.Join(gotEncoding, ", ") != strings.Join(wantEncoding, ", ") {
+		diffs.add("transfer encoding", DiffChanged, gotEncoding, wantEncoding, fmt.Sprintf("got transfer encoding %q, want %q", gotEncoding, wantEncoding))
+	}
+}
+
+// bodyDigestDiffs streams body, adding a diff if its length or
+// SHA-256 digest differ from the ones wanted, if any.
+func bodyDigestDiffs(diffs *Diffs, body io.Reader, wantLength int64, wantSHA256 string) {
+	h := sha256.New()
+	var n int64
+	if body != nil {
+		var err error
+		if n, err = io.Copy(h, body); err != nil {
+			diffs.add("body", DiffChanged, nil, nil, fmt.Sprintf("could not read body: %v", err))
+			return
+		}
+	}
+	if wantLength != 0 && n != wantLength {
+		diffs.add("body length", DiffChanged, n, wantLength, fmt.Sprintf("got body length %d, want %d", n, wantLength))
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); wantSHA256 != "" && got != strings.ToLower(wantSHA256) {
+		diffs.add("body SHA-256", DiffChanged, got, wantSHA256, fmt.Sprintf("got body SHA-256 %s, want %s", got, wantSHA256))
+	}
+}
@@ -0,0 +1,223 @@
+package testutil_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestBodyComparators tests that bodies are compared with the
+// comparator registered for their content type.
+func TestBodyComparators(t *testing.T) {
+	testutil.RegisterBodyComparator("application/x-Lines", func(got string, want string, opts ...testutil.Option) string {
+		return testutil.CompareLinesUnordered(got, want)
+	})
+	tests := []struct {
+		name        string
+		contentType string
+		gotBody     string
+		wantBody    string
+		opts        []testutil.Option
+		wantDiff    string
+	}{
+		{
+			name:        "json suffix",
+			contentType: "application/problem+json",
+			gotBody:     `{"title": "oops", "status": 400}`,
+			wantBody:    `{"status": 400, "title": "oops"}`,
+			wantDiff:    "",
+		},
+		{
+			name:        "xml",
+			contentType: "text/xml; charset=utf-8",
+			gotBody:     `<a><b>1</b></a>`,
+			wantBody:    `<a><b>2</b></a>`,
+			wantDiff: `response does not match what is expected:
+body is not expected, XML does not match:
+/a/b/text(): got "1", want "2"`,
+		},
+		{
+			name:        "registered comparator",
+			contentType: "application/x-lines",
+			gotBody:     "b\na",
+			wantBody:    "a\nb",
+			wantDiff:    "",
+		},
+		{
+			name:        "empty json bodies",
+			contentType: "application/json",
+			gotBody:     "",
+			wantBody:    "",
+			wantDiff:    "",
+		},
+		{
+			name:        "json with placeholders",
+			contentType: "application/json",
+			gotBody:     `{"id": "0b9d7e5c-2a1f-4c3e-9d8b-7f6a5e4d3c2b", "count": 3}`,
+			wantBody:    `{"id": "{{UUID}}", "count": "{{NUMBER}}"}`,
+			opts:        []testutil.Option{testutil.UsePlaceholders()},
+			wantDiff:    "",
+		},
+		{
+			name:        "json placeholders which don't match",
+			contentType: "application/json",
+			gotBody:     `{"id": "not-a-uuid"}`,
+			wantBody:    `{"id": "{{UUID}}"}`,
+			opts:        []testutil.Option{testutil.UsePlaceholders()},
+			wantDiff: `response does not match what is expected:
+body is not expected, JSON does not match:
+$.id: got "not-a-uuid", want "{{UUID}}"`,
+		},
+		{
+			name:        "json is normalized first",
+			contentType: "application/json",
+			gotBody:     `{"at": "2024-05-01T10:00:00Z"}`,
+			wantBody:    `{"at": "2023-01-01T00:00:00Z"}`,
+			opts:        []testutil.Option{testutil.Normalize(testutil.RedactPattern(`\d{4}-\d{2}-\d{2}T[\d:]+Z`, "TIME"))},
+			wantDiff:    "",
+		},
+		{
+			name:        "unknown content type",
+			contentType: "application/octet-stream",
+			gotBody:     "ab",
+			wantBody:    "ac",
+			wantDiff: `response does not match what is expected:
+body is not expected, strings differ at index 1, from that index on:
+##### got string #####
+b
+##### want string #####
+c`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {test.contentType}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.gotBody)),
+			}
+			diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: test.wantBody}, test.opts...)
+			if got, want := diff, test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCheckHTTPResponseCompressedBody tests that compressed bodies are
+// decompressed before being compared.
+func TestCheckHTTPResponseCompressedBody(t *testing.T) {
+	var gzipped, deflated bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write([]byte("hello there"))
+	gw.Close()
+	zw := zlib.NewWriter(&deflated)
+	zw.Write([]byte("hello there"))
+	zw.Close()
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		wantBody string
+		wantDiff string
+	}{
+		{
+			name:     "gzip",
+			encoding: "gzip",
+			body:     gzipped.Bytes(),
+			wantBody: "hello there",
+			wantDiff: "",
+		},
+		{
+			name:     "deflate",
+			encoding: "deflate",
+			body:     deflated.Bytes(),
+			wantBody: "hello where",
+			wantDiff: `response does not match what is expected:
+body after deflate decompression is not expected, strings differ at index 6, from that index on:
+##### got string #####
+there
+##### want string #####
+where`,
+		},
+		{
+			name:     "not actually compressed",
+			encoding: "gzip",
+			body:     []byte("hello there"),
+			wantBody: "hello there",
+			wantDiff: `response does not match what is expected:
+could not decompress gzip body: gzip: invalid header`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Encoding": {test.encoding}},
+				Body:       ioutil.NopCloser(bytes.NewReader(test.body)),
+			}
+			diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: test.wantBody})
+			if got, want := diff, test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCheckHTTPFraming tests that requests without a body can be
+// checked and that the Content-Length and Transfer-Encoding are
+// checked when wanted.
+func TestCheckHTTPFraming(t *testing.T) {
+	req := &http.Request{Method: "GET", URL: &url.URL{Scheme: "http", Host: "hello.com"}}
+	if diff := testutil.CheckHTTPRequest(req, testutil.HTTPRequest{Method: "GET", URL: "http://hello.com"}); diff != "" {
+		t.Error(diff)
+	}
+	req = testutil.MustNewHTTPRequest("POST", "http://hello.com", strings.NewReader("hello"))
+	wantDiff := `request does not match what is expected:
+got content length 5, want 6
+got transfer encoding [], want ["chunked"]`
+	if got, want := testutil.CheckHTTPRequest(req, testutil.HTTPRequest{Method: "POST", URL: "http://hello.com", Body: "hello", ContentLength: 6, TransferEncoding: []string{"chunked"}}), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+	resp := &http.Response{StatusCode: 200, ContentLength: -1, TransferEncoding: []string{"chunked"}, Body: ioutil.NopCloser(strings.NewReader("hello"))}
+	if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: "hello", ContentLength: -1, TransferEncoding: []string{"chunked"}}); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// TestCheckHTTPResponseBodyDigest tests that large bodies can be
+// checked by their length and digest.
+func TestCheckHTTPResponseBodyDigest(t *testing.T) {
+	newResp := func() *http.Response {
+		return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(io.LimitReader(zeros{}, 1<<20))}
+	}
+	want := testutil.HTTPResponse{StatusCode: 200, BodyLength: 1 << 20, BodySHA256: "30E14955EBF1352266DC2FF8067E68104607E750ABB9D3B36582B8AF909FCB58"}
+	if diff := testutil.CheckHTTPResponse(newResp(), want); diff != "" {
+		t.Error(diff)
+	}
+	want = testutil.HTTPResponse{StatusCode: 200, BodyLength: 1 << 21, BodySHA256: "abc"}
+	wantDiff := `response does not match what is expected:
+got body length 1048576, want 2097152
+got body SHA-256 30e14955ebf1352266dc2ff8067e68104607e750abb9d3b36582b8af909fcb58, want abc`
+	if got, want := testutil.CheckHTTPResponse(newResp(), want), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+}
+
+// zeros is an endless reader of zero bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,100 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// RequestBuilder builds an HTTPRequest expectation one piece at a
+// time which reads better than a struct literal in a long table of
+// expected requests. Start one with ExpectRequest and finish it with
+// Build.
+type RequestBuilder struct {
+	req HTTPRequest
+}
+
+// ExpectRequest starts building an HTTPRequest expectation.
+func ExpectRequest() *RequestBuilder {
+	return &RequestBuilder{}
+}
+
+// Method sets the method and URL of the expected request.
+func (b *RequestBuilder) Method(method string, url string) *RequestBuilder {
+	b.req.Method, b.req.URL = method, url
+	return b
+}
+
+// GET expects a GET request to url.
+func (b *RequestBuilder) GET(url string) *RequestBuilder {
+	return b.Method(http.MethodGet, url)
+}
+
+// POST expects a POST request to url.
+func (b *RequestBuilder) POST(url string) *RequestBuilder {
+	return b.Method(http.MethodPost, url)
+}
+
+// PUT expects a PUT request to url.
+func (b *RequestBuilder) PUT(url string) *RequestBuilder {
+	return b.Method(http.MethodPut, url)
+}
+
+// PATCH expects a PATCH request to url.
+func (b *RequestBuilder) PATCH(url string) *RequestBuilder {
+	return b.Method(http.MethodPatch, url)
+}
+
+// DELETE expects a DELETE request to url.
+func (b *RequestBuilder) DELETE(url string) *RequestBuilder {
+	return b.Method(http.MethodDelete, url)
+}
+
+// WithHeader expects the header name to have values.
+func (b *RequestBuilder) WithHeader(name string, values ...string) *RequestBuilder {
+	if b.req.Header == nil {
+		b.req.Header = http.Header{}
+	}
+	b.req.Header[http.CanonicalHeaderKey(name)] = values
+	return b
+}
+
+// WithCookie expects a cookie called name with value.
+func (b *RequestBuilder) WithCookie(name string, value string) *RequestBuilder {
+	if b.req.Cookies == nil {
+		b.req.Cookies = map[string]string{}
+	}
+	b.req.Cookies[name] = value
+	return b
+}
+
+// WithBasicAuth expects basic auth credentials.
+func (b *RequestBuilder) WithBasicAuth(username string, password string) *RequestBuilder {
+	b.req.BasicAuth = &BasicAuth{Username: username, Password: password}
+	return b
+}
+
+// WithBody expects the body to be body.
+func (b *RequestBuilder) WithBody(body string) *RequestBuilder {
+	b.req.Body = body
+	return b
+}
+
+// WithJSONBody expects the body to be v encoded as JSON. The body is
+// compared like CompareJSON does whatever the Content-Type of the
+// request. It panic's if v cannot be encoded.
+func (b *RequestBuilder) WithJSONBody(v interface{}) *RequestBuilder {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	b.req.Body = string(body)
+	b.req.BodyMatcher = MatcherFunc(func(got interface{}) string {
+		return CompareJSON(string(toBytes(got)), string(body))
+	})
+	return b
+}
+
+// Build returns the expected request.
+func (b *RequestBuilder) Build() HTTPRequest {
+	return b.req
+}
@@ -0,0 +1,42 @@
+package testutil_test
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestRequestBuilder tests that the builder produces the expected
+// HTTPRequest and that it can be checked.
+func TestRequestBuilder(t *testing.T) {
+	want := testutil.ExpectRequest().
+		PUT("http://hello.com/users/1").
+		WithHeader("x-id", "1", "2").
+		WithCookie("session", "abc").
+		WithBasicAuth("bob", "hunter2").
+		WithBody("hi").
+		Build()
+	wantReq := testutil.HTTPRequest{
+		Method:    "PUT",
+		URL:       "http://hello.com/users/1",
+		Header:    http.Header{"X-Id": {"1", "2"}},
+		Cookies:   map[string]string{"session": "abc"},
+		BasicAuth: &testutil.BasicAuth{Username: "bob", Password: "hunter2"},
+		Body:      "hi",
+	}
+	if !reflect.DeepEqual(want, wantReq) {
+		t.Errorf("got request %+v, want %+v", want, wantReq)
+	}
+
+	want = testutil.ExpectRequest().POST("http://hello.com/users").WithJSONBody(map[string]interface{}{"name": "bob", "age": 30}).Build()
+	got := testutil.MustNewHTTPRequest("POST", "http://hello.com/users", strings.NewReader(`{"age": 30.0, "name": "bobby"}`))
+	wantDiff := `request does not match what is expected:
+body did not match: JSON does not match:
+$.name: got "bobby", want "bob"`
+	if got, want := testutil.CheckHTTPRequest(got, want), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// hexContextRows is the number of 16 byte rows shown either side of
+// the row where two byte slices first differ.
+const hexContextRows = 2
+
+// CompareBytes compares two byte slices and returns a string detailing
+// where they differ or "" if they don't. The difference is shown as an
+// xxd style hex and ASCII dump of both slices around the first
+// differing offset, which is far more readable than a string diff for
+// binary data like protocol frames. Rows containing bytes which differ
+// are marked with ">".
+func CompareBytes(got []byte, want []byte) string {
+	if bytes.Equal(got, want) {
+		return ""
+	}
+	offset := 0
+	for offset < len(got) && offset < len(want) && got[offset] == want[offset] {
+		offset++
+	}
+	msg := fmt.Sprintf("bytes differ at offset %d (0x%x)", offset, offset)
+	if offset == len(got) || offset == len(want) {
+		msg = fmt.Sprintf("got %d bytes, want %d, the first %d match", len(got), len(want), offset)
+	}
+	row := offset / 16
+	return fmt.Sprintf("%s:\n##### got bytes #####\n%s\n##### want bytes #####\n%s", msg, hexDump(got, want, row), hexDump(want, got, row))
+}
+
+// hexDump dumps the rows of b around row, marking rows which differ
+// from other.
+func hexDump(b []byte, other []byte, row int) string {
+	if len(b) == 0 {
+		return "(no bytes)"
+	}
+	first, last := row-hexContextRows, row+hexContextRows
+	if first < 0 {
+		first = 0
+	}
+	if max := (len(b) - 1) / 16; last > max {
+		last = max
+	}
+	lines := []string{}
+	for r := first; r <= last; r++ {
+		start, end := r*16, r*16+16
+		if end > len(b) {
+			end = len(b)
+		}
+		otherEnd := end
+		if otherEnd > len(other) {
+			otherEnd = len(other)
+		}
+		marker := " "
+		if start > otherEnd || !bytes.Equal(b[start:end], other[start:otherEnd]) || (end-start < 16 && len(other) > end) {
+			marker = ">"
+		}
+		var hex, ascii strings.Builder
+		for i := 0; i < 16; i++ {
+			if i > 0 && i%2 == 0 {
+				hex.WriteByte(' ')
+			}
+			if start+i >= end {
+				hex.WriteString("  ")
+				continue
+			}
+			c := b[start+i]
+			fmt.Fprintf(&hex, "%02x", c)
+			if c >= 0x20 && c < 0x7f {
+				ascii.WriteByte(c)
+			} else {
+				ascii.WriteByte('.')
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s %08x: %s  %s", marker, start, hex.String(), ascii.String()))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,72 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareBytes tests that the expected hex dump diff is generated
+// when comparing byte slices.
+func TestCompareBytes(t *testing.T) {
+	frame := []byte("\x01\x00\x10HELLO, PROTOCOL!\x00\x00\x00\x2a\xff\xfe trailing bytes..")
+	changed := append([]byte(nil), frame...)
+	changed[22] = 0x2b
+	tests := []struct {
+		name     string
+		got      []byte
+		want     []byte
+		wantDiff string
+	}{
+		{
+			name:     "equal",
+			got:      frame,
+			want:     frame,
+			wantDiff: "",
+		},
+		{
+			name: "byte differs",
+			got:  changed,
+			want: frame,
+			wantDiff: `bytes differ at offset 22 (0x16):
+##### got bytes #####
+  00000000: 0100 1048 454c 4c4f 2c20 5052 4f54 4f43  ...HELLO, PROTOC
+> 00000010: 4f4c 2100 0000 2bff fe20 7472 6169 6c69  OL!...+.. traili
+  00000020: 6e67 2062 7974 6573 2e2e                 ng bytes..
+##### want bytes #####
+  00000000: 0100 1048 454c 4c4f 2c20 5052 4f54 4f43  ...HELLO, PROTOC
+> 00000010: 4f4c 2100 0000 2aff fe20 7472 6169 6c69  OL!...*.. traili
+  00000020: 6e67 2062 7974 6573 2e2e                 ng bytes..`,
+		},
+		{
+			name: "got is shorter",
+			got:  frame[:20],
+			want: frame,
+			wantDiff: `got 20 bytes, want 42, the first 20 match:
+##### got bytes #####
+  00000000: 0100 1048 454c 4c4f 2c20 5052 4f54 4f43  ...HELLO, PROTOC
+> 00000010: 4f4c 2100                                OL!.
+##### want bytes #####
+  00000000: 0100 1048 454c 4c4f 2c20 5052 4f54 4f43  ...HELLO, PROTOC
+> 00000010: 4f4c 2100 0000 2aff fe20 7472 6169 6c69  OL!...*.. traili
+> 00000020: 6e67 2062 7974 6573 2e2e                 ng bytes..`,
+		},
+		{
+			name: "got is empty",
+			got:  nil,
+			want: []byte("ab"),
+			wantDiff: `got 0 bytes, want 2, the first 0 match:
+##### got bytes #####
+(no bytes)
+##### want bytes #####
+> 00000000: 6162                                     ab`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareBytes(test.got, test.want), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// cassetteFormats holds the converters to and from JSON for the
+// cassette formats registered with RegisterCassetteFormat, keyed by
+// file extension.
+var (
+	cassetteFormatsMu sync.RWMutex
+	cassetteFormats   = map[string]cassetteFormat{}
+)
+
+type cassetteFormat struct {
+	fromJSON func([]byte) ([]byte, error)
+	toJSON   func([]byte) ([]byte, error)
+}
+
+// RegisterCassetteFormat makes WriteCassette and ReadCassette use
+// another format for cassette files whose name ends in ext, like
+// ".yaml". Cassettes are marshalled to JSON first and fromJSON
+// converts that JSON to the format, toJSON converts back when reading.
+// Packages for formats which need dependencies, like yamltest for
+// YAML, register their formats when they are imported.
+func RegisterCassetteFormat(ext string, fromJSON func([]byte) ([]byte, error), toJSON func([]byte) ([]byte, error)) {
+	cassetteFormatsMu.Lock()
+	defer cassetteFormatsMu.Unlock()
+	cassetteFormats[strings.ToLower(ext)] = cassetteFormat{fromJSON: fromJSON, toJSON: toJSON}
+}
+
+func lookupCassetteFormat(path string) (cassetteFormat, bool) {
+	cassetteFormatsMu.RLock()
+	defer cassetteFormatsMu.RUnlock()
+	f, ok := cassetteFormats[strings.ToLower(filepath.Ext(path))]
+	return f, ok
+}
+
+// WriteCassette writes a cassette to the file at path, as JSON unless
+// a format was registered for the extension of path with
+// RegisterCassetteFormat. Importing yamltest registers YAML for
+// ".yaml" and ".yml" files. Cassettes use the same keys as HTTPRequest
+// and HTTPResponse do in JSON. The scrubbers are applied to every
+// interaction before it is written so secrets, like Authorization
+// headers and API keys, don't end up in files which get committed.
+func WriteCassette(path string, c Cassette, scrubbers ...Scrubber) error {
+	c.Interactions = scrubInteractions(c.Interactions, scrubbers)
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling cassette: %v", err)
+	}
+	if f, ok := lookupCassetteFormat(path); ok {
+		if b, err = f.fromJSON(b); err != nil {
+			return fmt.Errorf("marshalling cassette: %v", err)
+		}
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing cassette: %v", err)
+	}
+	return nil
+}
+
+// ReadCassette reads a cassette previously written by WriteCassette,
+// or by hand.
+func ReadCassette(path string) (Cassette, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Cassette{}, fmt.Errorf("reading cassette: %v", err)
+	}
+	if f, ok := lookupCassetteFormat(path); ok {
+		if b, err = f.toJSON(b); err != nil {
+			return Cassette{}, fmt.Errorf("unmarshalling cassette: %v", err)
+		}
+	}
+	var c Cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Cassette{}, fmt.Errorf("unmarshalling cassette: %v", err)
+	}
+	return c, nil
+}
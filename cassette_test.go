@@ -0,0 +1,84 @@
+package testutil_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCassetteFormats tests that cassettes are written as JSON by
+// default and in the registered format for other extensions, and read
+// back.
+func TestCassetteFormats(t *testing.T) {
+	testutil.RegisterCassetteFormat(".B64", func(b []byte) ([]byte, error) {
+		return []byte(base64.StdEncoding.EncodeToString(b)), nil
+	}, func(b []byte) ([]byte, error) {
+		return base64.StdEncoding.DecodeString(string(b))
+	})
+	c := testutil.Cassette{
+		Upstream: "https://api.example.com",
+		Interactions: []testutil.Interaction{{
+			Request:  testutil.HTTPRequest{Method: "GET", URL: "/users/1"},
+			Response: testutil.HTTPResponse{StatusCode: 200, Body: "hi"},
+		}},
+	}
+	wantJSON := `{
+  "upstream": "https://api.example.com",
+  "interactions": [
+    {
+      "request": {
+        "method": "GET",
+        "url": "/users/1",
+        "header": null,
+        "body": ""
+      },
+      "response": {
+        "status_code": 200,
+        "header": null,
+        "body": "hi"
+      }
+    }
+  ]
+}`
+	tests := []struct {
+		name     string
+		file     string
+		wantFile string
+	}{
+		{
+			name:     "json",
+			file:     "cassette.json",
+			wantFile: wantJSON,
+		},
+		{
+			name:     "registered format",
+			file:     "cassette.b64",
+			wantFile: base64.StdEncoding.EncodeToString([]byte(wantJSON)),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), test.file)
+			if err := testutil.WriteCassette(path, c); err != nil {
+				t.Fatal(err)
+			}
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := testutil.CompareStrings(string(b), test.wantFile); diff != "" {
+				t.Error(diff)
+			}
+			got, err := testutil.ReadCassette(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := testutil.CompareValues(got, c); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
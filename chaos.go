@@ -0,0 +1,92 @@
+package testutil
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ChaosConfig configures the chaos injected by Chaos. Each rate is the
+// probability, between 0 and 1, of that kind of chaos happening to a
+// request.
+type ChaosConfig struct {
+	// Seed makes the chaos deterministic, the same seed and
+	// sequence of requests always gets the same chaos.
+	Seed int64
+	// ErrorRate is how often to respond with a random 5xx status
+	// instead of calling the handler.
+	ErrorRate float64
+	// LatencyRate is how often to wait for Latency before calling
+	// the handler.
+	LatencyRate float64
+	Latency     time.Duration
+	// DropRate is how often to drop the connection instead of
+	// responding.
+	DropRate float64
+	// CorruptRate is how often to corrupt a byte of the response
+	// body.
+	CorruptRate float64
+}
+
+var chaosStatusCodes = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// Chaos wraps a handler and injects chaos into its responses so the
+// resilience of clients and gateways can be tested in-process.
+// Dropping a connection is done by panicking with
+// http.ErrAbortHandler so it only works when served by a real server
+// like httptest.Server.
+func Chaos(h http.Handler, cfg ChaosConfig) http.Handler {
+	var mu sync.Mutex
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every decision is made up front, under the lock, so the
+		// chaos a request gets only depends on the seed and how
+		// many requests came before it.
+		mu.Lock()
+		drop := rng.Float64() < cfg.DropRate
+		fail := rng.Float64() < cfg.ErrorRate
+		status := chaosStatusCodes[rng.Intn(len(chaosStatusCodes))]
+		slow := rng.Float64() < cfg.LatencyRate
+		corrupt := rng.Float64() < cfg.CorruptRate
+		corruptSeed := rng.Int63()
+		mu.Unlock()
+		if slow {
+			time.Sleep(cfg.Latency)
+		}
+		if drop {
+			panic(http.ErrAbortHandler)
+		}
+		if fail {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		if corrupt {
+			w = &corruptingWriter{ResponseWriter: w, rng: rand.New(rand.NewSource(corruptSeed))}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// corruptingWriter flips the bits of one byte in the first non-empty
+// write of the response body.
+type corruptingWriter struct {
+	http.ResponseWriter
+	rng       *rand.Rand
+	corrupted bool
+}
+
+func (c *corruptingWriter) Write(b []byte) (int, error) {
+	if c.corrupted || len(b) == 0 {
+		return c.ResponseWriter.Write(b)
+	}
+	c.corrupted = true
+	corrupt := append([]byte(nil), b...)
+	corrupt[c.rng.Intn(len(corrupt))] ^= 0xff
+	return c.ResponseWriter.Write(corrupt)
+}
@@ -0,0 +1,63 @@
+package testutil_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lag13/testutil"
+)
+
+// TestChaos tests that the chaos middleware injects the configured
+// chaos deterministically.
+func TestChaos(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello buddy"))
+	})
+	statusCodes := func(h http.Handler) []int {
+		codes := []int{}
+		for i := 0; i < 20; i++ {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			codes = append(codes, rec.Code)
+		}
+		return codes
+	}
+	cfg := testutil.ChaosConfig{Seed: 42, ErrorRate: 0.5}
+	first, second := statusCodes(testutil.Chaos(ok, cfg)), statusCodes(testutil.Chaos(ok, cfg))
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("same seed gave different chaos: %v and %v", first, second)
+	}
+	failures := 0
+	for _, code := range first {
+		if code >= 500 {
+			failures++
+		} else if code != 200 {
+			t.Errorf("got unexpected status code %d", code)
+		}
+	}
+	if failures == 0 || failures == len(first) {
+		t.Errorf("got %d failures out of %d requests with an error rate of 0.5", failures, len(first))
+	}
+
+	rec := httptest.NewRecorder()
+	testutil.Chaos(ok, testutil.ChaosConfig{CorruptRate: 1}).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if body := rec.Body.String(); body == "hello buddy" || len(body) != len("hello buddy") {
+		t.Errorf("body was not corrupted in place, got %q", body)
+	}
+
+	start := time.Now()
+	rec = httptest.NewRecorder()
+	testutil.Chaos(ok, testutil.ChaosConfig{LatencyRate: 1, Latency: 50 * time.Millisecond}).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("request took %v, want at least 50ms of injected latency", elapsed)
+	}
+
+	server := httptest.NewServer(testutil.Chaos(ok, testutil.ChaosConfig{DropRate: 1}))
+	defer server.Close()
+	if _, err := http.Get(server.URL); err == nil {
+		t.Error("got no error from a dropped connection")
+	}
+}
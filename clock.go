@@ -0,0 +1,177 @@
+package testutil
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time. Code which depends on a Clock instead of
+// calling the time package directly can be tested with a FakeClock.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Sleeper pauses the current goroutine.
+type Sleeper interface {
+	Sleep(d time.Duration)
+}
+
+// Ticker delivers ticks at intervals, like a time.Ticker.
+type Ticker interface {
+	Chan() <-chan time.Time
+	Stop()
+}
+
+// RealClock is the Clock and Sleeper to use in production, it just
+// calls the time package.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// After returns time.After(d).
+func (RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// NewTicker returns a Ticker backed by a time.Ticker.
+func (RealClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+// Sleep calls time.Sleep(d).
+func (RealClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+type realTicker struct {
+	t *time.Ticker
+}
+
+func (r realTicker) Chan() <-chan time.Time {
+	return r.t.C
+}
+
+func (r realTicker) Stop() {
+	r.t.Stop()
+}
+
+// FakeClock is a Clock and Sleeper whose time only moves when the test
+// says so. Timers, tickers and sleeps fire as Advance moves time past
+// them.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter is anything waiting on the fake clock: a call to After or
+// Sleep or a ticker.
+type fakeWaiter struct {
+	at     time.Time
+	period time.Duration
+	c      chan time.Time
+}
+
+// NewFakeClock returns a FakeClock set to start.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the fake clock's current time.
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After returns a channel which receives the time once the clock has
+// been advanced by at least d.
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	return f.addWaiter(d, 0).c
+}
+
+// NewTicker returns a Ticker which ticks every time the clock is
+// advanced past another multiple of d. Like a time.Ticker, ticks are
+// dropped if the previous one hasn't been received.
+func (f *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return &fakeTicker{clock: f, w: f.addWaiter(d, d)}
+}
+
+// Sleep blocks until the clock has been advanced by at least d.
+func (f *FakeClock) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// Advance moves the clock forward by d firing anything which was
+// waiting for a time up to and including the new time.
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	remaining := f.waiters[:0]
+	for _, w := range f.waiters {
+		for !w.at.After(f.now) {
+			select {
+			case w.c <- w.at:
+			default:
+			}
+			if w.period == 0 {
+				break
+			}
+			w.at = w.at.Add(w.period)
+		}
+		if w.at.After(f.now) {
+			remaining = append(remaining, w)
+		}
+	}
+	f.waiters = remaining
+}
+
+// Waiters returns how many timers, tickers and sleeps are waiting on
+// the clock. Tests can poll it to know when the code under test has
+// started waiting before calling Advance.
+func (f *FakeClock) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+func (f *FakeClock) addWaiter(d time.Duration, period time.Duration) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &fakeWaiter{at: f.now.Add(d), period: period, c: make(chan time.Time, 1)}
+	if d <= 0 && period == 0 {
+		w.c <- f.now
+		return w
+	}
+	f.waiters = append(f.waiters, w)
+	return w
+}
+
+type fakeTicker struct {
+	clock *FakeClock
+	w     *fakeWaiter
+}
+
+func (t *fakeTicker) Chan() <-chan time.Time {
+	return t.w.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	for i, w := range t.clock.waiters {
+		if w == t.w {
+			t.clock.waiters = append(t.clock.waiters[:i], t.clock.waiters[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package testutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lag13/testutil"
+)
+
+var (
+	_ testutil.Clock   = testutil.RealClock{}
+	_ testutil.Sleeper = testutil.RealClock{}
+	_ testutil.Clock   = &testutil.FakeClock{}
+	_ testutil.Sleeper = &testutil.FakeClock{}
+)
+
+// TestFakeClock tests that the fake clock only moves when advanced
+// and fires timers, tickers and sleeps when it does.
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	clock := testutil.NewFakeClock(start)
+	after := clock.After(time.Minute)
+	ticker := clock.NewTicker(20 * time.Second)
+	slept := make(chan struct{})
+	go func() {
+		clock.Sleep(30 * time.Second)
+		close(slept)
+	}()
+	for clock.Waiters() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	clock.Advance(25 * time.Second)
+	if got, want := clock.Now(), start.Add(25*time.Second); !got.Equal(want) {
+		t.Errorf("got time %v, want %v", got, want)
+	}
+	if got, want := <-ticker.Chan(), start.Add(20*time.Second); !got.Equal(want) {
+		t.Errorf("got tick at %v, want %v", got, want)
+	}
+	select {
+	case <-after:
+		t.Error("After fired before its duration elapsed")
+	case <-slept:
+		t.Error("Sleep returned before its duration elapsed")
+	default:
+	}
+
+	clock.Advance(35 * time.Second)
+	if got, want := <-after, start.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("got After time %v, want %v", got, want)
+	}
+	<-slept
+	if got, want := <-ticker.Chan(), start.Add(40*time.Second); !got.Equal(want) {
+		t.Errorf("got tick at %v, want %v, later ticks should be dropped while the channel is full", got, want)
+	}
+	ticker.Stop()
+	if got, want := clock.Waiters(), 0; got != want {
+		t.Errorf("got %d waiters after everything fired, want %d", got, want)
+	}
+}
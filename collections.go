@@ -0,0 +1,264 @@
+package testutil
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// collectionContext is how many elements either side of a violation
+// are shown.
+const collectionContext = 2
+
+// AssertSortedBy checks that s is sorted according to less and
+// returns a string describing the first pair of elements which are
+// out of order, along with their neighbours, or "" if s is sorted.
+func AssertSortedBy[T any](s []T, less func(a, b T) bool) string {
+	for i := 1; i < len(s); i++ {
+		if less(s[i], s[i-1]) {
+			return fmt.Sprintf("elements at index %d and %d are out of order: %v comes before %v\n%s", i-1, i, s[i-1], s[i], sliceContext(s, i-1, i))
+		}
+	}
+	return ""
+}
+
+// AssertUnique checks that no element of s appears more than once and
+// returns a string describing the first duplicate or "" if there are
+// none.
+func AssertUnique[T comparable](s []T) string {
+	seen := map[T]int{}
+	for i, e := range s {
+		if j, ok := seen[e]; ok {
+			return fmt.Sprintf("element %v at index %d is a duplicate of the one at index %d\n%s", e, i, j, sliceContext(s, j, i))
+		}
+		seen[e] = i
+	}
+	return ""
+}
+
+// AssertSubset checks that every element of sub is also in super and
+// returns a string describing the first one which isn't or "" if sub
+// is a subset.
+func AssertSubset[T comparable](sub []T, super []T) string {
+	in := map[T]bool{}
+	for _, e := range super {
+		in[e] = true
+	}
+	for i, e := range sub {
+		if !in[e] {
+			return fmt.Sprintf("element %v at index %d is not in the superset %v\n%s", e, i, super, sliceContext(sub, i, i))
+		}
+	}
+	return ""
+}
+
+// AssertPermutation checks that got contains exactly the same
+// elements as want, the same number of times, in any order and
+// returns a string describing which elements are missing or extra or
+// "" if got is a permutation of want.
+func AssertPermutation[T comparable](got []T, want []T) string {
+	counts := map[T]int{}
+	for _, e := range want {
+		counts[e]++
+	}
+	extra := []T{}
+	for _, e := range got {
+		if counts[e] == 0 {
+			extra = append(extra, e)
+			continue
+		}
+		counts[e]--
+	}
+	missing := []T{}
+	for _, e := range want {
+		if counts[e] > 0 {
+			missing = append(missing, e)
+			counts[e]--
+		}
+	}
+	diffs := []string{}
+	if len(missing) > 0 {
+		diffs = append(diffs, fmt.Sprintf("missing elements: %v", missing))
+	}
+	if len(extra) > 0 {
+		diffs = append(diffs, fmt.Sprintf("extra elements: %v", extra))
+	}
+	if len(diffs) > 0 {
+		return "got is not a permutation of want:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+// sliceContext renders the elements of s around indexes i through j
+// with the elements in that range marked.
+func sliceContext[T any](s []T, i int, j int) string {
+	start, end := i-collectionContext, j+collectionContext+1
+	if start < 0 {
+		start = 0
+	}
+	if end > len(s) {
+		end = len(s)
+	}
+	lines := []string{}
+	for k := start; k < end; k++ {
+		marker := " "
+		if k == i || k == j {
+			marker = ">"
+		}
+		lines = append(lines, fmt.Sprintf("%s [%d] %v", marker, k, s[k]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// IgnoreOrder makes CompareSlices treat slices as equal if they
+// contain the same elements the same number of times in any order.
+func IgnoreOrder() Option {
+	return func(o *options) {
+		o.ignoreOrder = true
+	}
+}
+
+// CompareSlices compares two slices element by element and returns a
+// string listing the indexes which differ and the elements which are
+// extra or missing or "" if the slices are equal. Pass IgnoreOrder to
+// compare them regardless of order, in which case missing elements
+// which look like a typo of an extra element get a "did you mean"
+// suggestion.
+func CompareSlices[T comparable](got []T, want []T, opts ...Option) string {
+	return CompareSlicesFunc(got, want, func(a, b T) bool { return a == b }, opts...)
+}
+
+// CompareSlicesFunc is like CompareSlices but uses eq to decide if two
+// elements are equal so it works with elements which are not
+// comparable.
+func CompareSlicesFunc[T any](got []T, want []T, eq func(a, b T) bool, opts ...Option) string {
+	o := newOptions(opts)
+	diffs := []string{}
+	if o.ignoreOrder {
+		matched := make([]bool, len(want))
+		extra := []T{}
+		for _, g := range got {
+			found := false
+			for j, w := range want {
+				if !matched[j] && eq(g, w) {
+					matched[j], found = true, true
+					break
+				}
+			}
+			if !found {
+				extra = append(extra, g)
+			}
+		}
+		missing := []T{}
+		for j, w := range want {
+			if !matched[j] {
+				missing = append(missing, w)
+			}
+		}
+		if len(missing) > 0 {
+			diffs = append(diffs, fmt.Sprintf("missing elements: %v", missing))
+		}
+		if len(extra) > 0 {
+			diffs = append(diffs, fmt.Sprintf("extra elements: %v", extra))
+		}
+		diffs = append(diffs, suggestions(missing, extra)...)
+	} else {
+		for i := 0; i < len(got) || i < len(want); i++ {
+			switch {
+			case i >= len(want):
+				diffs = append(diffs, fmt.Sprintf("index %d: extra element %v", i, got[i]))
+			case i >= len(got):
+				diffs = append(diffs, fmt.Sprintf("index %d: missing element %v", i, want[i]))
+			case !eq(got[i], want[i]):
+				diffs = append(diffs, fmt.Sprintf("index %d: got %v, want %v", i, got[i], want[i]))
+			}
+		}
+	}
+	if len(diffs) > 0 {
+		return "slices differ:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+// CompareMaps compares two maps and returns a string listing the keys
+// which are missing or unexpected and the keys whose values differ or
+// "" if the maps are equal. Values are compared with reflect.DeepEqual
+// and keys are listed in sorted order.
+func CompareMaps[K comparable, V any](got map[K]V, want map[K]V) string {
+	keys := []K{}
+	for k := range want {
+		keys = append(keys, k)
+	}
+	for k := range got {
+		if _, ok := want[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+	diffs := []string{}
+	for _, k := range keys {
+		g, inGot := got[k]
+		w, inWant := want[k]
+		switch {
+		case !inGot:
+			diffs = append(diffs, fmt.Sprintf("missing key %v", k))
+		case !inWant:
+			diffs = append(diffs, fmt.Sprintf("unexpected key %v with value %v", k, g))
+		case !reflect.DeepEqual(g, w):
+			diffs = append(diffs, fmt.Sprintf("key %v: got %v, want %v", k, g, w))
+		}
+	}
+	if len(diffs) > 0 {
+		return "maps differ:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+// maximumMatching pairs up as many got and wanted elements as possible
+// where matches(i, j) reports whether got[i] can be paired with
+// want[j]. Pairing each wanted element with the first got element it
+// matches isn't enough when some match more than others, a loose want
+// could take the only got element a stricter one matches. It returns
+// the index each got element is paired with and the index each wanted
+// element is paired with, -1 for those which aren't.
+func maximumMatching(nGot int, nWant int, matches func(i, j int) bool) ([]int, []int) {
+	ok := make([][]bool, nGot)
+	for i := range ok {
+		ok[i] = make([]bool, nWant)
+		for j := range ok[i] {
+			ok[i][j] = matches(i, j)
+		}
+	}
+	matchedGot, matchedWant := make([]int, nGot), make([]int, nWant)
+	for i := range matchedGot {
+		matchedGot[i] = -1
+	}
+	// augment looks for a got element for want[j], taking it from
+	// another wanted element if that one can be paired differently.
+	var augment func(j int, visited []bool) bool
+	augment = func(j int, visited []bool) bool {
+		for i := 0; i < nGot; i++ {
+			if ok[i][j] && !visited[i] {
+				visited[i] = true
+				if matchedGot[i] < 0 || augment(matchedGot[i], visited) {
+					matchedGot[i] = j
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for j := 0; j < nWant; j++ {
+		augment(j, make([]bool, nGot))
+	}
+	for j := range matchedWant {
+		matchedWant[j] = -1
+	}
+	for i, j := range matchedGot {
+		if j >= 0 {
+			matchedWant[j] = i
+		}
+	}
+	return matchedGot, matchedWant
+}
@@ -0,0 +1,141 @@
+package testutil_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCollectionCheckers tests that the expected diff is generated by
+// the collection checkers.
+func TestCollectionCheckers(t *testing.T) {
+	byValue := func(a, b int) bool { return a < b }
+	tests := []struct {
+		name     string
+		diff     string
+		wantDiff string
+	}{
+		{
+			name:     "sorted",
+			diff:     testutil.AssertSortedBy([]int{1, 2, 2, 5}, byValue),
+			wantDiff: "",
+		},
+		{
+			name: "not sorted",
+			diff: testutil.AssertSortedBy([]int{1, 2, 3, 4, 9, 5, 6, 7, 8}, byValue),
+			wantDiff: `elements at index 4 and 5 are out of order: 9 comes before 5
+  [2] 3
+  [3] 4
+> [4] 9
+> [5] 5
+  [6] 6
+  [7] 7`,
+		},
+		{
+			name:     "unique",
+			diff:     testutil.AssertUnique([]string{"a", "b", "c"}),
+			wantDiff: "",
+		},
+		{
+			name: "not unique",
+			diff: testutil.AssertUnique([]string{"a", "b", "c", "b"}),
+			wantDiff: `element b at index 3 is a duplicate of the one at index 1
+  [0] a
+> [1] b
+  [2] c
+> [3] b`,
+		},
+		{
+			name:     "subset",
+			diff:     testutil.AssertSubset([]int{3, 1}, []int{1, 2, 3}),
+			wantDiff: "",
+		},
+		{
+			name: "not subset",
+			diff: testutil.AssertSubset([]int{3, 4}, []int{1, 2, 3}),
+			wantDiff: `element 4 at index 1 is not in the superset [1 2 3]
+  [0] 3
+> [1] 4`,
+		},
+		{
+			name:     "permutation",
+			diff:     testutil.AssertPermutation([]int{3, 1, 2, 1}, []int{1, 1, 2, 3}),
+			wantDiff: "",
+		},
+		{
+			name: "not permutation",
+			diff: testutil.AssertPermutation([]int{3, 1, 2, 4}, []int{1, 1, 2, 3}),
+			wantDiff: `got is not a permutation of want:
+missing elements: [1]
+extra elements: [4]`,
+		},
+		{
+			name:     "slices equal",
+			diff:     testutil.CompareSlices([]string{"a", "b"}, []string{"a", "b"}),
+			wantDiff: "",
+		},
+		{
+			name: "slices differ",
+			diff: testutil.CompareSlices([]string{"a", "x", "c", "d"}, []string{"a", "b", "c"}),
+			wantDiff: `slices differ:
+index 1: got x, want b
+index 3: extra element d`,
+		},
+		{
+			name: "got slice is shorter",
+			diff: testutil.CompareSlices([]int{1}, []int{1, 2, 3}),
+			wantDiff: `slices differ:
+index 1: missing element 2
+index 2: missing element 3`,
+		},
+		{
+			name:     "slices equal ignoring order",
+			diff:     testutil.CompareSlices([]int{3, 1, 2}, []int{1, 2, 3}, testutil.IgnoreOrder()),
+			wantDiff: "",
+		},
+		{
+			name: "slices differ ignoring order",
+			diff: testutil.CompareSlices([]int{3, 1, 1}, []int{1, 2, 3}, testutil.IgnoreOrder()),
+			wantDiff: `slices differ:
+missing elements: [2]
+extra elements: [1]`,
+		},
+		{
+			name: "slices compared with a func",
+			diff: testutil.CompareSlicesFunc([][]int{{1}, {2, 3}}, [][]int{{1}, {2}}, func(a, b []int) bool {
+				return fmt.Sprint(a) == fmt.Sprint(b)
+			}),
+			wantDiff: `slices differ:
+index 1: got [2 3], want [2]`,
+		},
+		{
+			name: "did you mean",
+			diff: testutil.CompareSlices([]string{"GET /users", "POST /order"}, []string{"GET /users", "POST /orders"}, testutil.IgnoreOrder()),
+			wantDiff: `slices differ:
+missing elements: [POST /orders]
+extra elements: [POST /order]
+missing POST /orders, did you mean POST /order?`,
+		},
+		{
+			name:     "maps equal",
+			diff:     testutil.CompareMaps(map[string][]int{"a": {1}}, map[string][]int{"a": {1}}),
+			wantDiff: "",
+		},
+		{
+			name: "maps differ",
+			diff: testutil.CompareMaps(map[string]int{"a": 1, "b": 5, "d": 4}, map[string]int{"a": 1, "b": 2, "c": 3}),
+			wantDiff: `maps differ:
+key b: got 5, want 2
+missing key c
+unexpected key d with value 4`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := test.diff, test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
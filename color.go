@@ -0,0 +1,81 @@
+package testutil
+
+import (
+	"os"
+	"strings"
+)
+
+// ColorEnv is the environment variable which turns on colored diffs
+// for every comparison. Set it to "always" to always color diffs or
+// "auto" to color them only when stdout is a terminal and the CI
+// environment variable is unset. NO_COLOR turns coloring off.
+const ColorEnv = "TESTUTIL_COLOR"
+
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+	ansiReset = "\x1b[0m"
+)
+
+// Color makes CompareStrings color the parts of a diff which differ
+// using ANSI escape codes regardless of ColorEnv.
+func Color() Option {
+	return func(o *options) {
+		o.color = true
+	}
+}
+
+// palette styles the parts of a diff. Its zero value leaves text
+// untouched.
+type palette struct {
+	enabled bool
+}
+
+func newPalette(o options) palette {
+	return palette{enabled: o.color || colorFromEnv()}
+}
+
+func colorFromEnv() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	switch os.Getenv(ColorEnv) {
+	case "always":
+		return true
+	case "auto":
+		return os.Getenv("CI") == "" && isTerminal(os.Stdout)
+	}
+	return false
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (p palette) paint(color string, s string) string {
+	if !p.enabled || s == "" {
+		return s
+	}
+	// Color each line separately so a diff stays colored when it is
+	// indented or interleaved with other output.
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = color + l + ansiReset
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// removed styles text which is only in got, or only in want for a
+// unified diff.
+func (p palette) removed(s string) string { return p.paint(ansiRed, s) }
+
+// added styles text which is only in want, or only in got for a
+// unified diff.
+func (p palette) added(s string) string { return p.paint(ansiGreen, s) }
+
+// hunk styles the headers of a unified diff.
+func (p palette) hunk(s string) string { return p.paint(ansiCyan, s) }
@@ -0,0 +1,53 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareStringsColor tests that the parts of a diff which differ
+// are colored when asked for.
+func TestCompareStringsColor(t *testing.T) {
+	t.Setenv(testutil.ColorEnv, "")
+	tests := []struct {
+		name     string
+		opts     []testutil.Option
+		env      string
+		wantDiff string
+	}{
+		{
+			name:     "plain by default",
+			wantDiff: "strings differ at index 2, from that index on:\n##### got string #####\nc\n##### want string #####\nx\ny",
+		},
+		{
+			name:     "color option",
+			opts:     []testutil.Option{testutil.Color()},
+			wantDiff: "strings differ at index 2, from that index on:\n##### got string #####\n\x1b[31mc\x1b[0m\n##### want string #####\n\x1b[32mx\x1b[0m\n\x1b[32my\x1b[0m",
+		},
+		{
+			name:     "color env",
+			env:      "always",
+			wantDiff: "strings differ at index 2, from that index on:\n##### got string #####\n\x1b[31mc\x1b[0m\n##### want string #####\n\x1b[32mx\x1b[0m\n\x1b[32my\x1b[0m",
+		},
+		{
+			name:     "auto is plain in CI",
+			env:      "auto",
+			wantDiff: "strings differ at index 2, from that index on:\n##### got string #####\nc\n##### want string #####\nx\ny",
+		},
+		{
+			name:     "unified diff",
+			opts:     []testutil.Option{testutil.Color(), testutil.UnifiedDiff()},
+			wantDiff: "--- want\n+++ got\n\x1b[36m@@ -1,2 +1 @@\x1b[0m\n\x1b[31m-abx\x1b[0m\n\x1b[31m-y\x1b[0m\n\x1b[32m+abc\x1b[0m",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(testutil.ColorEnv, test.env)
+			t.Setenv("CI", "true")
+			if got, want := testutil.CompareStrings("abc", "abx\ny", test.opts...), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%q\n### WANT ###\n%q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package testutil
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// CompareINI parses two INI documents and returns a string detailing
+// the keys where they differ or "" if they don't. Keys before the
+// first section belong to the root, comments start with ";" or "#"
+// and values may be quoted. Formatting and key order don't matter.
+func CompareINI(got string, want string) string {
+	gotDoc, err := parseINI(got)
+	if err != nil {
+		return fmt.Sprintf("could not parse got INI: %v", err)
+	}
+	wantDoc, err := parseINI(want)
+	if err != nil {
+		return fmt.Sprintf("could not parse want INI: %v", err)
+	}
+	if diffs := compareTrees("", gotDoc, wantDoc); len(diffs) > 0 {
+		return "INI does not match:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+func parseINI(doc string) (map[string]interface{}, error) {
+	root := map[string]interface{}{}
+	section := root
+	scanner := bufio.NewScanner(strings.NewReader(doc))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == ';' || line[0] == '#':
+			continue
+		case line[0] == '[':
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: unterminated section header %q", n, line)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			s, ok := root[name].(map[string]interface{})
+			if !ok {
+				s = map[string]interface{}{}
+				root[name] = s
+			}
+			section = s
+		default:
+			i := strings.IndexAny(line, "=:")
+			if i == -1 {
+				return nil, fmt.Errorf("line %d: expected key=value, got %q", n, line)
+			}
+			value := strings.TrimSpace(line[i+1:])
+			if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+				value = value[1 : len(value)-1]
+			}
+			section[strings.TrimSpace(line[:i])] = value
+		}
+	}
+	return root, scanner.Err()
+}
@@ -0,0 +1,58 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareINI tests that the expected diff is generated when
+// comparing INI documents.
+func TestCompareINI(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		want     string
+		wantDiff string
+	}{
+		{
+			name: "equal apart from formatting and order",
+			got: `; generated
+name = app
+[db]
+user: "root"
+host=localhost
+`,
+			want: `name=app
+
+[db]
+host = localhost
+user = root
+`,
+			wantDiff: "",
+		},
+		{
+			name: "values differ",
+			got: `[db]
+host = localhost
+port = 5432
+`,
+			want: `[db]
+host = db.internal
+[cache]
+size = 10
+`,
+			wantDiff: `INI does not match:
+(root): missing key "cache"
+db: unexpected key "port"
+db.host: got "localhost", want "db.internal"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.CompareINI(test.got, test.want), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
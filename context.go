@@ -0,0 +1,84 @@
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CheckContextValue checks that ctx holds want under key. It is meant
+// to be called from inside a handler under test to make sure that
+// middleware injected the values the handler relies on.
+func CheckContextValue(ctx context.Context, key interface{}, want interface{}) string {
+	got := ctx.Value(key)
+	if got == nil {
+		return fmt.Sprintf("context has no value for key %v, want %#v", key, want)
+	}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Sprintf("context value for key %v is %#v, want %#v", key, got, want)
+	}
+	return ""
+}
+
+// CheckContextDeadline checks that ctx has a deadline and that the
+// time remaining until it is between min and max. It is meant to be
+// called from inside a handler under test to make sure a timeout was
+// propagated.
+func CheckContextDeadline(ctx context.Context, min time.Duration, max time.Duration) string {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return fmt.Sprintf("context has no deadline, want one between %v and %v from now", min, max)
+	}
+	if remaining := time.Until(deadline); remaining < min || remaining > max {
+		return fmt.Sprintf("context deadline is %v from now, want between %v and %v", remaining, min, max)
+	}
+	return ""
+}
+
+// CheckMiddlewareContext serves req with middleware wrapped around a
+// handler which captures the request's context and then checks that
+// the context holds the values in want, keyed by context key. Values
+// are compared like CompareValues does, taking the same options. It
+// verifies that middleware such as authentication or tracing injected
+// what later handlers rely on without writing a handler to do it.
+func CheckMiddlewareContext(middleware func(http.Handler) http.Handler, req *http.Request, want map[interface{}]interface{}, opts ...Option) string {
+	var ctx context.Context
+	rec := httptest.NewRecorder()
+	middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	})).ServeHTTP(rec, req)
+	if ctx == nil {
+		return fmt.Sprintf("middleware did not call the next handler, it responded with status %d", rec.Code)
+	}
+	keys := make([]interface{}, 0, len(want))
+	for key := range want {
+		keys = append(keys, key)
+	}
+	// Keys of distinct types often print the same, such as 0 or {}, so
+	// ties are broken by type to keep the diff stable.
+	sort.Slice(keys, func(i, j int) bool {
+		ki, kj := fmt.Sprint(keys[i]), fmt.Sprint(keys[j])
+		if ki != kj {
+			return ki < kj
+		}
+		return fmt.Sprintf("%T", keys[i]) < fmt.Sprintf("%T", keys[j])
+	})
+	diffs := []string{}
+	for _, key := range keys {
+		got := ctx.Value(key)
+		if got == nil {
+			diffs = append(diffs, fmt.Sprintf("context has no value for key %v, want %#v", key, want[key]))
+		} else if ds := DiffValues(got, want[key], opts...); len(ds) > 0 {
+			diffs = append(diffs, fmt.Sprintf("context value for key %v differs:\n%s", key, indent(ds.String(), "  ")))
+		}
+	}
+	if len(diffs) > 0 {
+		return "middleware context does not match what is expected:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
@@ -0,0 +1,148 @@
+package testutil_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lag13/testutil"
+)
+
+type ctxKey string
+
+type (
+	keyA int
+	keyB int
+)
+
+// TestCheckContextValue tests that the expected diff is generated
+// when checking values stored in a context.
+func TestCheckContextValue(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey("user"), "bob")
+	tests := []struct {
+		name     string
+		key      interface{}
+		want     interface{}
+		wantDiff string
+	}{
+		{
+			name:     "value present",
+			key:      ctxKey("user"),
+			want:     "bob",
+			wantDiff: "",
+		},
+		{
+			name:     "value differs",
+			key:      ctxKey("user"),
+			want:     "alice",
+			wantDiff: `context value for key user is "bob", want "alice"`,
+		},
+		{
+			name:     "value missing",
+			key:      ctxKey("trace"),
+			want:     "abc",
+			wantDiff: `context has no value for key trace, want "abc"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CheckContextValue(ctx, test.key, test.want), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCheckContextDeadline tests that deadlines are checked against
+// the expected range.
+func TestCheckContextDeadline(t *testing.T) {
+	if diff := testutil.CheckContextDeadline(context.Background(), time.Second, 2*time.Second); diff != "context has no deadline, want one between 1s and 2s from now" {
+		t.Errorf("got wrong diff for context without a deadline: %s", diff)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if diff := testutil.CheckContextDeadline(ctx, 4*time.Second, 5*time.Second); diff != "" {
+		t.Errorf("got unexpected diff: %s", diff)
+	}
+	if diff := testutil.CheckContextDeadline(ctx, time.Second, 2*time.Second); !strings.HasPrefix(diff, "context deadline is ") {
+		t.Errorf("got wrong diff for deadline out of range: %s", diff)
+	}
+}
+
+// TestCheckMiddlewareContext tests that the values middleware puts in
+// the request context are checked.
+func TestCheckMiddlewareContext(t *testing.T) {
+	type user struct {
+		Name  string
+		Roles []string
+	}
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			ctx := context.WithValue(r.Context(), ctxKey("user"), user{Name: "bob", Roles: []string{"reader"}})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+	newReq := func(authorization string) *http.Request {
+		r := httptest.NewRequest("GET", "/", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		return r
+	}
+	tests := []struct {
+		name     string
+		req      *http.Request
+		want     map[interface{}]interface{}
+		wantDiff string
+	}{
+		{
+			name:     "values match",
+			req:      newReq("Bearer abc"),
+			want:     map[interface{}]interface{}{ctxKey("user"): user{Name: "bob", Roles: []string{"reader"}}},
+			wantDiff: "",
+		},
+		{
+			name: "values differ",
+			req:  newReq("Bearer abc"),
+			want: map[interface{}]interface{}{
+				ctxKey("user"):  user{Name: "bob", Roles: []string{"admin"}},
+				ctxKey("trace"): "abc",
+			},
+			wantDiff: `middleware context does not match what is expected:
+context has no value for key trace, want "abc"
+context value for key user differs:
+  user.Roles[0]: got "reader", want "admin"`,
+		},
+		{
+			name:     "next handler not called",
+			req:      newReq(""),
+			want:     map[interface{}]interface{}{ctxKey("user"): user{Name: "bob"}},
+			wantDiff: "middleware did not call the next handler, it responded with status 401",
+		},
+		{
+			name: "keys which print the same are ordered by type",
+			req:  newReq("Bearer abc"),
+			want: map[interface{}]interface{}{
+				keyB(0): "b",
+				keyA(0): "a",
+			},
+			wantDiff: `middleware context does not match what is expected:
+context has no value for key 0, want "a"
+context has no value for key 0, want "b"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CheckMiddlewareContext(auth, test.req, test.want), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Interaction is a single request sent to an API along with the
+// response that API gave back.
+type Interaction struct {
+	Description string       `json:"description,omitempty"`
+	Request     HTTPRequest  `json:"request"`
+	Response    HTTPResponse `json:"response"`
+}
+
+// Contract is the set of interactions a consumer of an API relies on.
+// A consumer's tests record them and the provider's tests replay them
+// to make sure the provider still behaves the way the consumer
+// expects.
+type Contract struct {
+	Consumer     string        `json:"consumer"`
+	Provider     string        `json:"provider"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// RecordingTransport is a http.RoundTripper which records every
+// request it sends along with the response it got back. Point the
+// client of the code under test at a mock API using this transport
+// and the recorded interactions can be exported as a Contract.
+type RecordingTransport struct {
+	// Transport is used to actually send requests. If nil
+	// http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// RoundTrip sends the request and records it along with the response.
+func (t *RecordingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	req, err := FromHTTPRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := transport.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	recorded, err := FromHTTPResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	// The date a response was sent will never be the same when the
+	// interaction is replayed and the length is already covered by
+	// checking the body.
+	recorded.Header.Del("Date")
+	recorded.Header.Del("Content-Length")
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.interactions = append(t.interactions, Interaction{Request: req, Response: recorded})
+	return resp, nil
+}
+
+// Interactions returns the interactions recorded so far.
+func (t *RecordingTransport) Interactions() []Interaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Interaction(nil), t.interactions...)
+}
+
+// Requests returns the requests recorded so far.
+func (t *RecordingTransport) Requests() []HTTPRequest {
+	reqs := []HTTPRequest{}
+	for _, i := range t.Interactions() {
+		reqs = append(reqs, i.Request)
+	}
+	return reqs
+}
+
+// Contract returns the recorded interactions as a Contract between
+// the consumer and provider.
+func (t *RecordingTransport) Contract(consumer string, provider string) Contract {
+	return Contract{
+		Consumer:     consumer,
+		Provider:     provider,
+		Interactions: t.Interactions(),
+	}
+}
+
+// WriteContract writes a contract as JSON to the file at path. The
+// scrubbers are applied to every interaction before it is written so
+// secrets don't end up in files which get committed.
+func WriteContract(path string, c Contract, scrubbers ...Scrubber) error {
+	c.Interactions = scrubInteractions(c.Interactions, scrubbers)
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling contract: %v", err)
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("writing contract: %v", err)
+	}
+	return nil
+}
+
+// ReadContract reads a contract previously written by WriteContract.
+func ReadContract(path string) (Contract, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Contract{}, fmt.Errorf("reading contract: %v", err)
+	}
+	var c Contract
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Contract{}, fmt.Errorf("unmarshalling contract: %v", err)
+	}
+	return c, nil
+}
+
+// VerifyContract replays every interaction in the contract against a
+// provider's handler and returns a diff for each interaction whose
+// response no longer matches what the consumer expects. Use the
+// Substitute option to replace placeholders left by scrubbers with
+// values the provider will accept. The options are passed on to
+// CheckHTTPResponse too so ones like IgnoreHeaders and Partial can
+// loosen the check of each response.
+func VerifyContract(h http.Handler, c Contract, opts ...Option) string {
+	return contractReport(c, verifyInteractions(c.Interactions, opts, handlerReplayer(h)))
+}
+
+// VerifyContractServer is like VerifyContract but replays the
+// interactions against a running provider at baseURL, using client or
+// http.DefaultClient if client is nil. The scheme and host of each
+// recorded request are replaced by those of baseURL.
+func VerifyContractServer(client *http.Client, baseURL string, c Contract, opts ...Option) string {
+	replay, err := serverReplayer(client, baseURL)
+	if err != nil {
+		return err.Error()
+	}
+	return contractReport(c, verifyInteractions(c.Interactions, opts, replay))
+}
+
+func contractReport(c Contract, diffs []string) string {
+	if len(diffs) > 0 {
+		return fmt.Sprintf("provider %q does not satisfy contract with consumer %q:\n", c.Provider, c.Consumer) + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+// replayer sends an interaction's request to a provider.
+type replayer func(Interaction) (*http.Response, error)
+
+func handlerReplayer(h http.Handler) replayer {
+	return func(i Interaction) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newServerRequest(i.Request))
+		return rec.Result(), nil
+	}
+}
+
+func serverReplayer(client *http.Client, baseURL string) (replayer, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse base url: %v", err)
+	}
+	return func(i Interaction) (*http.Response, error) {
+		u, err := url.Parse(i.Request.URL)
+		if err != nil {
+			return nil, err
+		}
+		u.Scheme = base.Scheme
+		u.Host = base.Host
+		i.Request.URL = u.String()
+		req, err := newClientRequest(i.Request)
+		if err != nil {
+			return nil, err
+		}
+		return client.Do(req)
+	}, nil
+}
+
+// verifyInteractions replays each interaction and returns a diff for
+// every one whose response isn't what was recorded, as checked by
+// CheckHTTPResponse with opts.
+func verifyInteractions(interactions []Interaction, opts []Option, replay replayer) []string {
+	o := newOptions(opts)
+	diffs := []string{}
+	for n, i := range interactions {
+		i = substitute(i, o.substitutes)
+		name := fmt.Sprintf("interaction %d (%s %s)", n, i.Request.Method, i.Request.URL)
+		if i.Description != "" {
+			name = fmt.Sprintf("interaction %d (%s)", n, i.Description)
+		}
+		resp, err := replay(i)
+		if err != nil {
+			diffs = append(diffs, fmt.Sprintf("%s could not be replayed: %v", name, err))
+			continue
+		}
+		diff := CheckHTTPResponse(resp, i.Response, opts...)
+		resp.Body.Close()
+		if diff != "" {
+			diffs = append(diffs, name+" failed, "+diff)
+		}
+	}
+	return diffs
+}
+
+// readAndRestore reads everything from *body and replaces it with a
+// reader over the same bytes so it can be read again.
+func readAndRestore(body *io.ReadCloser) (string, error) {
+	if *body == nil || *body == http.NoBody {
+		return "", nil
+	}
+	b, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	*body = ioutil.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
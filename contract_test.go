@@ -0,0 +1,96 @@
+package testutil_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestContract tests that interactions recorded by a consumer can be
+// written out as a contract and then verified against a provider.
+func TestContract(t *testing.T) {
+	mockProvider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "hello %s", testutil.MustReadAll(r.Body))
+	}))
+	defer mockProvider.Close()
+	transport := &testutil.RecordingTransport{}
+	client := &http.Client{Transport: transport}
+	resp, err := client.Post(mockProvider.URL+"/greet", "text/plain", strings.NewReader("buddy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := testutil.MustReadAll(resp.Body), "hello buddy"; got != want {
+		t.Fatalf("recording transport altered the response body, got %q, want %q", got, want)
+	}
+	path := filepath.Join(t.TempDir(), "contract.json")
+	if err := testutil.WriteContract(path, transport.Contract("consumer", "provider")); err != nil {
+		t.Fatal(err)
+	}
+	contract, err := testutil.ReadContract(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(contract.Interactions), 1; got != want {
+		t.Fatalf("got %d recorded interactions, want %d", got, want)
+	}
+
+	tests := []struct {
+		name     string
+		provider http.HandlerFunc
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name: "provider satisfies contract",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				fmt.Fprintf(w, "hello %s", testutil.MustReadAll(r.Body))
+			},
+			wantDiff: "",
+		},
+		{
+			name: "options loosen the check",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				fmt.Fprintf(w, "hello %s", testutil.MustReadAll(r.Body))
+			},
+			opts:     []testutil.Option{testutil.IgnoreHeaders("Content-Type")},
+			wantDiff: "",
+		},
+		{
+			name: "provider breaks contract",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain")
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, "hi %s", testutil.MustReadAll(r.Body))
+			},
+			wantDiff: fmt.Sprintf(`provider "provider" does not satisfy contract with consumer "consumer":
+interaction 0 (POST %s/greet) failed, response does not match what is expected:
+got status code 201, want 200
+body is not expected, strings differ at index 1, from that index on:
+##### got string #####
+i buddy
+##### want string #####
+ello buddy`, mockProvider.URL),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.VerifyContract(test.provider, contract, test.opts...), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+			server := httptest.NewServer(test.provider)
+			defer server.Close()
+			// A nil client means http.DefaultClient.
+			if diff := testutil.CompareStrings(testutil.VerifyContractServer(nil, server.URL, contract, test.opts...), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
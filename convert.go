@@ -0,0 +1,38 @@
+package testutil
+
+import "net/http"
+
+// FromHTTPRequest captures the method, URL, host, headers and body of
+// r as an HTTPRequest, for example so a mock API can record the
+// requests it receives and return them to the test as JSON later. The
+// body of r is restored so it can still be read. The URL of a request
+// received by a server usually only has a path and query, the host is
+// in Host.
+func FromHTTPRequest(r *http.Request) (HTTPRequest, error) {
+	body, err := readAndRestore(&r.Body)
+	if err != nil {
+		return HTTPRequest{}, err
+	}
+	return HTTPRequest{
+		Method: r.Method,
+		URL:    r.URL.String(),
+		Host:   r.Host,
+		Header: r.Header.Clone(),
+		Body:   body,
+	}, nil
+}
+
+// FromHTTPResponse captures the status code, headers and body of resp
+// as an HTTPResponse. The body of resp is restored so it can still be
+// read.
+func FromHTTPResponse(resp *http.Response) (HTTPResponse, error) {
+	body, err := readAndRestore(&resp.Body)
+	if err != nil {
+		return HTTPResponse{}, err
+	}
+	return HTTPResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       body,
+	}, nil
+}
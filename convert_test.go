@@ -0,0 +1,43 @@
+package testutil_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestFromHTTP tests that requests and responses are captured without
+// consuming their bodies.
+func TestFromHTTP(t *testing.T) {
+	r := httptest.NewRequest("POST", "/users?id=1", strings.NewReader("hi"))
+	r.Header.Set("X-User", "bob")
+	req, err := testutil.FromHTTPRequest(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantReq := testutil.HTTPRequest{Method: "POST", URL: "/users?id=1", Host: "example.com", Header: http.Header{"X-User": {"bob"}}, Body: "hi"}
+	if !reflect.DeepEqual(req, wantReq) {
+		t.Errorf("got request %+v, want %+v", req, wantReq)
+	}
+	if diff := testutil.CheckHTTPRequest(r, wantReq); diff != "" {
+		t.Error(diff)
+	}
+
+	resp, err := testutil.FromHTTPResponse(&http.Response{
+		StatusCode: 201,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Body:       ioutil.NopCloser(strings.NewReader("created")),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantResp := testutil.HTTPResponse{StatusCode: 201, Header: http.Header{"Content-Type": {"text/plain"}}, Body: "created"}
+	if !reflect.DeepEqual(resp, wantResp) {
+		t.Errorf("got response %+v, want %+v", resp, wantResp)
+	}
+}
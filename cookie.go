@@ -0,0 +1,82 @@
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// requestCookieDiffs adds a diff for each cookie in want which is
+// missing from the Cookie header of got or has a different value.
+func requestCookieDiffs(diffs *Diffs, got *http.Request, want map[string]string) {
+	names := make([]string, 0, len(want))
+	for name := range want {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := fmt.Sprintf("cookie %q", name)
+		c, err := got.Cookie(name)
+		if err != nil {
+			diffs.add(path, DiffMissing, nil, want[name], fmt.Sprintf("missing cookie %q, want value %q", name, want[name]))
+			continue
+		}
+		if c.Value != want[name] {
+			diffs.add(path, DiffChanged, c.Value, want[name], fmt.Sprintf("cookie %q got value %q, want %q", name, c.Value, want[name]))
+		}
+	}
+}
+
+// responseCookieDiffs adds a diff for each cookie in want which is not
+// set by a Set-Cookie header in got or whose value or attributes
+// differ.
+func responseCookieDiffs(diffs *Diffs, got *http.Response, want []*http.Cookie) {
+	set := map[string]*http.Cookie{}
+	for _, c := range got.Cookies() {
+		set[c.Name] = c
+	}
+	for _, w := range want {
+		path := fmt.Sprintf("cookie %q", w.Name)
+		g, ok := set[w.Name]
+		if !ok {
+			diffs.add(path, DiffMissing, nil, w.String(), fmt.Sprintf("missing Set-Cookie for cookie %q, want %q", w.Name, w.String()))
+			continue
+		}
+		attr := func(name string, got interface{}, want interface{}) {
+			diffs.add(path+" "+name, DiffChanged, got, want, fmt.Sprintf("cookie %q got %s %v, want %v", w.Name, name, got, want))
+		}
+		if g.Value != w.Value {
+			diffs.add(path, DiffChanged, g.Value, w.Value, fmt.Sprintf("cookie %q got value %q, want %q", w.Name, g.Value, w.Value))
+		}
+		if w.Path != "" && g.Path != w.Path {
+			attr("Path", fmt.Sprintf("%q", g.Path), fmt.Sprintf("%q", w.Path))
+		}
+		if w.Domain != "" && g.Domain != w.Domain {
+			attr("Domain", fmt.Sprintf("%q", g.Domain), fmt.Sprintf("%q", w.Domain))
+		}
+		if w.MaxAge != 0 && g.MaxAge != w.MaxAge {
+			attr("Max-Age", g.MaxAge, w.MaxAge)
+		}
+		if w.SameSite != 0 && g.SameSite != w.SameSite {
+			attr("SameSite", sameSiteName(g.SameSite), sameSiteName(w.SameSite))
+		}
+		if w.Secure && !g.Secure {
+			diffs.add(path+" Secure", DiffMissing, false, true, fmt.Sprintf("cookie %q is not Secure", w.Name))
+		}
+		if w.HttpOnly && !g.HttpOnly {
+			diffs.add(path+" HttpOnly", DiffMissing, false, true, fmt.Sprintf("cookie %q is not HttpOnly", w.Name))
+		}
+	}
+}
+
+func sameSiteName(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return "unset"
+}
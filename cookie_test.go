@@ -0,0 +1,100 @@
+package testutil_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCheckHTTPRequestCookies tests that the cookies sent with a
+// request are checked.
+func TestCheckHTTPRequestCookies(t *testing.T) {
+	tests := []struct {
+		name     string
+		cookies  map[string]string
+		wantDiff string
+	}{
+		{
+			name:     "cookies match",
+			cookies:  map[string]string{"session": "abc", "theme": "dark"},
+			wantDiff: "",
+		},
+		{
+			name:    "cookies differ",
+			cookies: map[string]string{"session": "xyz", "lang": "en"},
+			wantDiff: `request does not match what is expected:
+missing cookie "lang", want value "en"
+cookie "session" got value "abc", want "xyz"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotReq := &http.Request{
+				Method: "GET",
+				URL:    &url.URL{Scheme: "http", Host: "hello.com"},
+				Header: http.Header{"Cookie": {"session=abc; theme=dark; other=1"}},
+				Body:   ioutil.NopCloser(strings.NewReader("")),
+			}
+			wantReq := testutil.HTTPRequest{Method: "GET", URL: "http://hello.com", Cookies: test.cookies}
+			if got, want := testutil.CheckHTTPRequest(gotReq, wantReq), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCheckHTTPResponseCookies tests that the cookies set by a
+// response are checked.
+func TestCheckHTTPResponseCookies(t *testing.T) {
+	tests := []struct {
+		name     string
+		cookies  []*http.Cookie
+		wantDiff string
+	}{
+		{
+			name: "cookies match",
+			cookies: []*http.Cookie{
+				{Name: "session", Value: "abc", Path: "/", MaxAge: 3600, Secure: true, HttpOnly: true, SameSite: http.SameSiteLaxMode},
+				{Name: "theme", Value: "dark"},
+			},
+			wantDiff: "",
+		},
+		{
+			name: "cookies differ",
+			cookies: []*http.Cookie{
+				{Name: "session", Value: "xyz", Path: "/app", Domain: "hello.com", MaxAge: 60, SameSite: http.SameSiteStrictMode},
+				{Name: "theme", Value: "dark", Secure: true, HttpOnly: true},
+				{Name: "lang", Value: "en"},
+			},
+			wantDiff: `response does not match what is expected:
+cookie "session" got value "abc", want "xyz"
+cookie "session" got Path "/", want "/app"
+cookie "session" got Domain "", want "hello.com"
+cookie "session" got Max-Age 3600, want 60
+cookie "session" got SameSite Lax, want Strict
+cookie "theme" is not Secure
+cookie "theme" is not HttpOnly
+missing Set-Cookie for cookie "lang", want "lang=en"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotResp := &http.Response{
+				StatusCode: 200,
+				Header: http.Header{"Set-Cookie": {
+					"theme=dark",
+					"session=abc; Max-Age=3600; HttpOnly; Path=/; SameSite=Lax; Secure",
+				}},
+				Body: ioutil.NopCloser(strings.NewReader("")),
+			}
+			wantResp := testutil.HTTPResponse{StatusCode: 200, Cookies: test.cookies}
+			if got, want := testutil.CheckHTTPResponse(gotResp, wantResp), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
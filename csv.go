@@ -0,0 +1,195 @@
+package testutil
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// IgnoreColumnOrder makes CompareCSV match columns by their header
+// name rather than their position.
+func IgnoreColumnOrder() Option {
+	return func(o *options) {
+		o.ignoreColumnOrder = true
+	}
+}
+
+// UnorderedRows makes CompareCSV treat the rows as a set so the order
+// they appear in does not matter.
+func UnorderedRows() Option {
+	return func(o *options) {
+		o.unorderedRows = true
+	}
+}
+
+// TrimCells makes CompareCSV ignore whitespace at the start and end of
+// every cell, including the header.
+func TrimCells() Option {
+	return func(o *options) {
+		o.trimCells = true
+	}
+}
+
+// CompareCSV parses two CSV documents, whose first row is a header,
+// and returns a string detailing how they differ by row number and
+// column name or "" if they don't. Rows are numbered from 1 not
+// counting the header. Options IgnoreColumnOrder, UnorderedRows,
+// TrimCells and NumericTolerance loosen the comparison.
+func CompareCSV(got string, want string, opts ...Option) string {
+	o := newOptions(opts)
+	gotRows, err := csv.NewReader(strings.NewReader(got)).ReadAll()
+	if err != nil {
+		return fmt.Sprintf("could not parse got CSV: %v", err)
+	}
+	wantRows, err := csv.NewReader(strings.NewReader(want)).ReadAll()
+	if err != nil {
+		return fmt.Sprintf("could not parse want CSV: %v", err)
+	}
+	if o.trimCells {
+		trimCells(gotRows)
+		trimCells(wantRows)
+	}
+	if len(gotRows) == 0 || len(wantRows) == 0 {
+		if len(gotRows) != len(wantRows) {
+			return fmt.Sprintf("got %d rows including the header, want %d", len(gotRows), len(wantRows))
+		}
+		return ""
+	}
+	gotHeader, wantHeader := gotRows[0], wantRows[0]
+	diffs := []string{}
+	// columns maps each column in want to the position of the same
+	// column in got.
+	columns := make([]int, len(wantHeader))
+	if o.ignoreColumnOrder {
+		gotIndex := map[string]int{}
+		for i, name := range gotHeader {
+			gotIndex[name] = i
+		}
+		wantIndex := map[string]bool{}
+		for i, name := range wantHeader {
+			wantIndex[name] = true
+			j, ok := gotIndex[name]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("missing column %q", name))
+				j = -1
+			}
+			columns[i] = j
+		}
+		for _, name := range gotHeader {
+			if !wantIndex[name] {
+				diffs = append(diffs, fmt.Sprintf("unexpected column %q", name))
+			}
+		}
+	} else {
+		if strings.Join(gotHeader, ",") != strings.Join(wantHeader, ",") {
+			diffs = append(diffs, fmt.Sprintf("got columns %q, want %q", gotHeader, wantHeader))
+		}
+		for i := range columns {
+			columns[i] = i
+			if i >= len(gotHeader) {
+				columns[i] = -1
+			}
+		}
+	}
+	// Project the got rows onto the want columns so they can be
+	// compared cell by cell.
+	gotData := [][]string{}
+	for _, row := range gotRows[1:] {
+		projected := make([]string, len(columns))
+		for i, j := range columns {
+			if j >= 0 && j < len(row) {
+				projected[i] = row[j]
+			}
+		}
+		gotData = append(gotData, projected)
+	}
+	wantData := wantRows[1:]
+	if o.unorderedRows {
+		diffs = append(diffs, unorderedRowDiffs(gotData, wantData, wantHeader, o)...)
+	} else {
+		for i := 0; i < len(gotData) && i < len(wantData); i++ {
+			for c, name := range wantHeader {
+				if g, w := gotData[i][c], wantData[i][c]; !cellsEqual(g, w, o) {
+					diffs = append(diffs, fmt.Sprintf("row %d column %q: got %q, want %q", i+1, name, g, w))
+				}
+			}
+		}
+		for i := len(wantData); i < len(gotData); i++ {
+			diffs = append(diffs, fmt.Sprintf("unexpected row %d: %s", i+1, formatRow(wantHeader, gotData[i])))
+		}
+		for i := len(gotData); i < len(wantData); i++ {
+			diffs = append(diffs, fmt.Sprintf("missing row %d: %s", i+1, formatRow(wantHeader, wantData[i])))
+		}
+	}
+	if len(diffs) > 0 {
+		return "CSV does not match:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+func trimCells(rows [][]string) {
+	for _, row := range rows {
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+		}
+	}
+}
+
+// unorderedRowDiffs pairs up got and wanted rows with a maximum
+// matching so, with a NumericTolerance say, a wanted row can't take
+// the only got row another wanted row matches.
+func unorderedRowDiffs(gotData [][]string, wantData [][]string, header []string, o options) []string {
+	matchedGot, matchedWant := maximumMatching(len(gotData), len(wantData), func(i, j int) bool {
+		return rowsEqual(gotData[i], wantData[j], o)
+	})
+	diffs := []string{}
+	for j, w := range wantData {
+		if matchedWant[j] < 0 {
+			diffs = append(diffs, fmt.Sprintf("missing row %d: %s", j+1, formatRow(header, w)))
+		}
+	}
+	for i, g := range gotData {
+		if matchedGot[i] < 0 {
+			diffs = append(diffs, fmt.Sprintf("unexpected row %d: %s", i+1, formatRow(header, g)))
+		}
+	}
+	return diffs
+}
+
+func rowsEqual(got []string, want []string, o options) bool {
+	for i := range want {
+		if !cellsEqual(got[i], want[i], o) {
+			return false
+		}
+	}
+	return true
+}
+
+// cellsEqual compares two cells. Only when there is a NumericTolerance
+// are cells which are both numbers compared numerically, otherwise
+// "007" and "7" would be equal which hides real differences in IDs
+// and codes.
+func cellsEqual(got string, want string, o options) bool {
+	if got == want {
+		return true
+	}
+	if o.numericTolerance <= 0 {
+		return false
+	}
+	g, gErr := strconv.ParseFloat(got, 64)
+	w, wErr := strconv.ParseFloat(want, 64)
+	if gErr != nil || wErr != nil {
+		return false
+	}
+	return math.Abs(g-w) <= o.numericTolerance
+}
+
+func formatRow(header []string, row []string) string {
+	cells := []string{}
+	for i, name := range header {
+		cells = append(cells, fmt.Sprintf("%s=%q", name, row[i]))
+	}
+	return strings.Join(cells, " ")
+}
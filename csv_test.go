@@ -0,0 +1,126 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareCSV tests that the expected diff is generated when
+// comparing CSV documents.
+func TestCompareCSV(t *testing.T) {
+	tests := []struct {
+		name     string
+		gotCSV   string
+		wantCSV  string
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "equal",
+			gotCSV:   "name,price\napple,1.5\n",
+			wantCSV:  "name,price\napple,1.5\n",
+			wantDiff: "",
+		},
+		{
+			name:    "cells differ",
+			gotCSV:  "name,price\napple,1.50\npear,2\n",
+			wantCSV: "name,price\napple,1.50\npear,3\n",
+			wantDiff: `CSV does not match:
+row 2 column "price": got "2", want "3"`,
+		},
+		{
+			name:    "columns in different order",
+			gotCSV:  "price,name\n1.50,apple\n",
+			wantCSV: "name,price\napple,1.50\n",
+			wantDiff: `CSV does not match:
+got columns ["price" "name"], want ["name" "price"]
+row 1 column "name": got "1.50", want "apple"
+row 1 column "price": got "apple", want "1.50"`,
+		},
+		{
+			name:     "ignore column order",
+			gotCSV:   "price,name\n1.50,apple\n",
+			wantCSV:  "name,price\napple,1.50\n",
+			opts:     []testutil.Option{testutil.IgnoreColumnOrder()},
+			wantDiff: "",
+		},
+		{
+			name:    "ignore column order with missing and unexpected columns",
+			gotCSV:  "price,color\n1.50,red\n",
+			wantCSV: "name,price\napple,1.50\n",
+			opts:    []testutil.Option{testutil.IgnoreColumnOrder()},
+			wantDiff: `CSV does not match:
+missing column "name"
+unexpected column "color"
+row 1 column "name": got "", want "apple"`,
+		},
+		{
+			name:     "numeric tolerance",
+			gotCSV:   "name,price\napple,1.501\n",
+			wantCSV:  "name,price\napple,1.5\n",
+			opts:     []testutil.Option{testutil.NumericTolerance(0.01)},
+			wantDiff: "",
+		},
+		{
+			name:    "numbers are compared literally without a tolerance",
+			gotCSV:  "id,score\n007,1e2\n",
+			wantCSV: "id,score\n7,100\n",
+			wantDiff: `CSV does not match:
+row 1 column "id": got "007", want "7"
+row 1 column "score": got "1e2", want "100"`,
+		},
+		{
+			name:     "unordered rows",
+			gotCSV:   "name,price\npear,2\napple,1\n",
+			wantCSV:  "name,price\napple,1\npear,2\n",
+			opts:     []testutil.Option{testutil.UnorderedRows()},
+			wantDiff: "",
+		},
+		{
+			name:     "unordered rows are paired up as well as possible",
+			gotCSV:   "name,price\napple,1.00\napple,1.05\n",
+			wantCSV:  "name,price\napple,1.03\napple,0.98\n",
+			opts:     []testutil.Option{testutil.UnorderedRows(), testutil.NumericTolerance(0.035)},
+			wantDiff: "",
+		},
+		{
+			name:    "unordered rows differ",
+			gotCSV:  "name,price\npear,2\napple,1\nfig,4\n",
+			wantCSV: "name,price\napple,1\npear,3\n",
+			opts:    []testutil.Option{testutil.UnorderedRows()},
+			wantDiff: `CSV does not match:
+missing row 2: name="pear" price="3"
+unexpected row 1: name="pear" price="2"
+unexpected row 3: name="fig" price="4"`,
+		},
+		{
+			name:    "extra and missing rows",
+			gotCSV:  "name,price\napple,1\npear,2\n",
+			wantCSV: "name,price\napple,1\n",
+			wantDiff: `CSV does not match:
+unexpected row 2: name="pear" price="2"`,
+		},
+		{
+			name:     "trim cells",
+			gotCSV:   "name , price\n apple,1 \n",
+			wantCSV:  "price,name\n1,apple\n",
+			opts:     []testutil.Option{testutil.TrimCells(), testutil.IgnoreColumnOrder()},
+			wantDiff: "",
+		},
+		{
+			name:    "cells are not trimmed by default",
+			gotCSV:  "name,price\n apple,1\n",
+			wantCSV: "name,price\napple,1\n",
+			wantDiff: `CSV does not match:
+row 1 column "name": got " apple", want "apple"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.CompareCSV(test.gotCSV, test.wantCSV, test.opts...), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
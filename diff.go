@@ -0,0 +1,77 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffKind says how a value differs from what was wanted.
+type DiffKind string
+
+// The kinds of differences.
+const (
+	// DiffChanged means a value is present in both but different.
+	DiffChanged DiffKind = "changed"
+	// DiffMissing means a wanted value is not present.
+	DiffMissing DiffKind = "missing"
+	// DiffUnexpected means a value is present which was not wanted.
+	DiffUnexpected DiffKind = "unexpected"
+)
+
+// Diff is a single difference found by a comparison. It lets tools
+// inspect and aggregate differences rather than parse the strings
+// returned by the Check and Compare functions, which are built from
+// the String form of their Diffs.
+type Diff struct {
+	// Path locates the difference, for example `header "Accept"`
+	// or "User.Address.Zip".
+	Path string
+	Kind DiffKind
+	// Got and Want are the differing values. One of them is nil
+	// for missing or unexpected values.
+	Got  interface{}
+	Want interface{}
+
+	// message is how the difference is described by String.
+	message string
+}
+
+// String describes the difference the same way the string returning
+// functions do.
+func (d Diff) String() string {
+	if d.message != "" {
+		return d.message
+	}
+	switch d.Kind {
+	case DiffMissing:
+		return fmt.Sprintf("%s: missing, want %v", d.Path, d.Want)
+	case DiffUnexpected:
+		return fmt.Sprintf("%s: unexpected, got %v", d.Path, d.Got)
+	}
+	return fmt.Sprintf("%s: got %v, want %v", d.Path, d.Got, d.Want)
+}
+
+// Diffs is every difference found by a comparison.
+type Diffs []Diff
+
+// String describes the differences one per line.
+func (ds Diffs) String() string {
+	lines := make([]string, len(ds))
+	for i, d := range ds {
+		lines[i] = d.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (ds *Diffs) add(path string, kind DiffKind, got interface{}, want interface{}, message string) {
+	*ds = append(*ds, Diff{Path: path, Kind: kind, Got: got, Want: want, message: message})
+}
+
+// report renders diffs under a heading or returns "" if there are
+// none.
+func (ds Diffs) report(heading string) string {
+	if len(ds) == 0 {
+		return ""
+	}
+	return heading + ds.String()
+}
@@ -0,0 +1,70 @@
+package testutil_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestDiffHTTPResponse tests that response differences can be
+// inspected programmatically.
+func TestDiffHTTPResponse(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 500,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Body:       ioutil.NopCloser(strings.NewReader("oops")),
+	}
+	want := testutil.HTTPResponse{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Body:       "ok",
+	}
+	diffs := testutil.DiffHTTPResponse(resp, want)
+	if got, want := len(diffs), 2; got != want {
+		t.Fatalf("got %d diffs, want %d: %v", got, want, diffs)
+	}
+	if got, want := diffs[0], (testutil.Diff{Path: "status code", Kind: testutil.DiffChanged, Got: 500, Want: 200}); got.Path != want.Path || got.Kind != want.Kind || got.Got != want.Got || got.Want != want.Want {
+		t.Errorf("got diff %#v, want %#v", got, want)
+	}
+	if got, want := diffs[1].Path, "body"; got != want {
+		t.Errorf("got path %q, want %q", got, want)
+	}
+	wantStr := "got status code 500, want 200\nbody is not expected, strings differ at index 1, from that index on:\n##### got string #####\nops\n##### want string #####\nk"
+	if got := diffs.String(); got != wantStr {
+		t.Errorf("got string:\n%s\nwant:\n%s", got, wantStr)
+	}
+}
+
+// TestDiffValues tests that value differences carry their path, kind
+// and values.
+func TestDiffValues(t *testing.T) {
+	type item struct {
+		SKU   string
+		Attrs map[string]int
+	}
+	got := item{SKU: "a", Attrs: map[string]int{"x": 1, "z": 3}}
+	want := item{SKU: "b", Attrs: map[string]int{"x": 1, "y": 2}}
+	diffs := testutil.DiffValues(got, want)
+	var paths []string
+	var kinds []testutil.DiffKind
+	for _, d := range diffs {
+		paths = append(paths, d.Path)
+		kinds = append(kinds, d.Kind)
+	}
+	if want := []string{"item.SKU", `item.Attrs["y"]`, `item.Attrs["z"]`}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("got paths %q, want %q", paths, want)
+	}
+	if want := []testutil.DiffKind{testutil.DiffChanged, testutil.DiffMissing, testutil.DiffUnexpected}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("got kinds %q, want %q", kinds, want)
+	}
+	if got, want := diffs[1].Want, interface{}(2); got != want {
+		t.Errorf("got missing value %v, want %v", got, want)
+	}
+	if got, want := (testutil.Diff{Path: "a.b", Kind: testutil.DiffMissing, Want: 1}).String(), "a.b: missing, want 1"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,272 @@
+package testutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CompareDocumentText extracts the text from a generated PDF or DOCX
+// document and compares it to want. Binary comparisons of generated
+// documents break on embedded timestamps and IDs whereas the text is
+// what's actually worth checking. Leading and trailing whitespace on
+// each line is ignored as are blank lines.
+func CompareDocumentText(got []byte, want string) string {
+	text, err := ExtractDocumentText(got)
+	if err != nil {
+		return fmt.Sprintf("could not extract text from document: %v", err)
+	}
+	if diff := CompareStrings(text, normalizeDocumentText(want)); diff != "" {
+		return "document text is not expected, " + diff
+	}
+	return ""
+}
+
+// ExtractDocumentText returns the text of a PDF or DOCX document, the
+// format is detected from the data. PDF support is basic: it handles
+// uncompressed and Flate compressed content streams using simple
+// fonts, which is what most PDF generators produce for plain text.
+func ExtractDocumentText(doc []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(doc, []byte("%PDF")):
+		return extractPDFText(doc)
+	case bytes.HasPrefix(doc, []byte("PK\x03\x04")):
+		return extractDOCXText(doc)
+	}
+	return "", fmt.Errorf("unrecognized document format")
+}
+
+var pdfStreamRE = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+
+func extractPDFText(doc []byte) (string, error) {
+	var text strings.Builder
+	for _, loc := range pdfStreamRE.FindAllSubmatchIndex(doc, -1) {
+		dict := doc[loc[2]:loc[3]]
+		start := loc[1]
+		end := bytes.Index(doc[start:], []byte("endstream"))
+		if end == -1 {
+			return "", fmt.Errorf("unterminated stream at offset %d", start)
+		}
+		data := doc[start : start+end]
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return "", fmt.Errorf("decompressing stream at offset %d: %v", start, err)
+			}
+			data, err = ioutil.ReadAll(zr)
+			if err != nil && err != io.ErrUnexpectedEOF {
+				return "", fmt.Errorf("decompressing stream at offset %d: %v", start, err)
+			}
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			// Images and the like, they don't contain text.
+			continue
+		}
+		text.WriteString(pdfContentText(data))
+	}
+	return normalizeDocumentText(text.String()), nil
+}
+
+// pdfContentText pulls the text out of a PDF content stream by
+// looking at the text showing and positioning operators.
+func pdfContentText(content []byte) string {
+	var text strings.Builder
+	strs := []string{}
+	nums := []float64{}
+	inArray := false
+	s := content
+	for len(s) > 0 {
+		c := s[0]
+		switch {
+		case c == '(':
+			str, rest := pdfLiteralString(s[1:])
+			strs = append(strs, str)
+			s = rest
+		case c == '<' && len(s) > 1 && s[1] != '<':
+			end := bytes.IndexByte(s, '>')
+			if end == -1 {
+				return text.String()
+			}
+			h := string(bytes.Join(bytes.Fields(s[1:end]), nil))
+			if len(h)%2 == 1 {
+				h += "0"
+			}
+			b, _ := hex.DecodeString(h)
+			strs = append(strs, string(b))
+			s = s[end+1:]
+		case c == '[':
+			inArray = true
+			s = s[1:]
+		case c == ']':
+			inArray = false
+			s = s[1:]
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			s = s[1:]
+		default:
+			end := bytes.IndexAny(s[1:], " \t\r\n()<>[]/")
+			if end == -1 {
+				end = len(s)
+			} else {
+				end++
+			}
+			tok := string(s[:end])
+			s = s[end:]
+			if c == '/' {
+				// Names like fonts don't affect the text.
+				continue
+			}
+			if n, err := strconv.ParseFloat(tok, 64); err == nil {
+				// A large negative adjustment in a TJ array is
+				// how generators usually represent a space.
+				if inArray && n < -200 {
+					strs = append(strs, " ")
+				}
+				nums = append(nums, n)
+				continue
+			}
+			switch tok {
+			case "Tj", "TJ":
+				text.WriteString(strings.Join(strs, ""))
+			case "'", "\"":
+				text.WriteString("\n" + strings.Join(strs, ""))
+			case "T*", "ET":
+				text.WriteString("\n")
+			case "Td", "TD":
+				if len(nums) == 2 && nums[1] != 0 {
+					text.WriteString("\n")
+				} else {
+					text.WriteString(" ")
+				}
+			}
+			strs = strs[:0]
+			nums = nums[:0]
+		}
+	}
+	return text.String()
+}
+
+// pdfLiteralString parses a PDF literal string, s starts just after
+// the opening parenthesis.
+func pdfLiteralString(s []byte) (string, []byte) {
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '\\':
+			i++
+			if i >= len(s) {
+				return b.String(), nil
+			}
+			switch e := s[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case '\r', '\n':
+				// Line continuation.
+			default:
+				if e >= '0' && e <= '7' {
+					j := i
+					for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+						j++
+					}
+					n, _ := strconv.ParseUint(string(s[i:j]), 8, 8)
+					b.WriteByte(byte(n))
+					i = j - 1
+				} else {
+					b.WriteByte(e)
+				}
+			}
+		case '(':
+			depth++
+			b.WriteByte(c)
+		case ')':
+			if depth == 0 {
+				return b.String(), s[i+1:]
+			}
+			depth--
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+func extractDOCXText(doc []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(doc), int64(len(doc)))
+	if err != nil {
+		return "", err
+	}
+	for _, f := range zr.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+		var text strings.Builder
+		dec := xml.NewDecoder(rc)
+		inText := false
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				return normalizeDocumentText(text.String()), nil
+			}
+			if err != nil {
+				return "", err
+			}
+			switch tok := tok.(type) {
+			case xml.StartElement:
+				switch tok.Name.Local {
+				case "t":
+					inText = true
+				case "tab":
+					text.WriteString("\t")
+				case "br", "cr":
+					text.WriteString("\n")
+				}
+			case xml.EndElement:
+				switch tok.Name.Local {
+				case "t":
+					inText = false
+				case "p":
+					text.WriteString("\n")
+				}
+			case xml.CharData:
+				if inText {
+					text.Write(tok)
+				}
+			}
+		}
+	}
+	return "", fmt.Errorf("no word/document.xml in DOCX")
+}
+
+// normalizeDocumentText trims each line and drops blank ones since
+// how whitespace is laid out in a document is rarely worth checking.
+func normalizeDocumentText(text string) string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,102 @@
+package testutil_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// makePDF builds a bare bones PDF whose pages have the given content
+// streams, the second and later ones are compressed.
+func makePDF(contents ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /CreationDate (D:20210304050607Z) >>\nendobj\n")
+	for i, content := range contents {
+		if i == 0 {
+			fmt.Fprintf(&buf, "%d 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", i+2, len(content), content)
+			continue
+		}
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write([]byte(content))
+		zw.Close()
+		fmt.Fprintf(&buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n", i+2, z.Len(), z.Bytes())
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func makeDOCX(documentXML string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, _ := zw.Create("word/document.xml")
+	w.Write([]byte(documentXML))
+	zw.Close()
+	return buf.Bytes()
+}
+
+// TestCompareDocumentText tests that text is extracted from documents
+// and the expected diff generated.
+func TestCompareDocumentText(t *testing.T) {
+	pdf := makePDF(
+		"BT /F1 12 Tf 72 720 Td (Invoice \\(draft\\)) Tj 0 -14 Td [(Total:) -250 (42)] TJ ET",
+		"BT /F1 12 Tf 72 700 Td <5468616e6b73> Tj T* (Bye) Tj ET",
+	)
+	docx := makeDOCX(`<?xml version="1.0"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:body>
+    <w:p><w:r><w:t>Invoice</w:t></w:r></w:p>
+    <w:p><w:r><w:t>Total:</w:t><w:tab/><w:t>42</w:t></w:r></w:p>
+  </w:body>
+</w:document>`)
+	tests := []struct {
+		name     string
+		doc      []byte
+		want     string
+		wantDiff string
+	}{
+		{
+			name:     "pdf text matches",
+			doc:      pdf,
+			want:     "Invoice (draft)\nTotal: 42\nThanks\nBye\n",
+			wantDiff: "",
+		},
+		{
+			name: "pdf text differs",
+			doc:  pdf,
+			want: "Invoice (draft)\nTotal: 43\nThanks\nBye",
+			wantDiff: `document text is not expected, strings differ at index 24 (line 2, column 9), from that index on:
+##### got string #####
+2
+Thanks
+Bye
+##### want string #####
+3
+Thanks
+Bye`,
+		},
+		{
+			name:     "docx text matches",
+			doc:      docx,
+			want:     "Invoice\nTotal:\t42",
+			wantDiff: "",
+		},
+		{
+			name:     "unknown format",
+			doc:      []byte("hello"),
+			want:     "hello",
+			wantDiff: "could not extract text from document: unrecognized document format",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.CompareDocumentText(test.doc, test.want), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
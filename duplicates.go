@@ -0,0 +1,76 @@
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CheckNoDuplicateRequests checks that no request was sent more than
+// once which is useful when testing that code is idempotent or
+// doesn't retry when it shouldn't. Requests are duplicates if their
+// method, URL, body and headers are equal, ignoring volatileHeaders
+// (things like X-Request-Id or Date which are expected to change on
+// every send). Any differences in those volatile headers are
+// included in the diff.
+func CheckNoDuplicateRequests(reqs []HTTPRequest, volatileHeaders ...string) string {
+	volatile := map[string]bool{}
+	for _, h := range volatileHeaders {
+		volatile[http.CanonicalHeaderKey(h)] = true
+	}
+	first := map[string]int{}
+	diffs := []string{}
+	for i, r := range reqs {
+		key := requestKey(r, volatile)
+		j, ok := first[key]
+		if !ok {
+			first[key] = i
+			continue
+		}
+		diff := fmt.Sprintf("request %d is a duplicate of request %d (%s %s)", i, j, r.Method, r.URL)
+		if headerDiffs := volatileHeaderDiffs(reqs[j].Header, r.Header, volatile); len(headerDiffs) > 0 {
+			diff += ", they differ only in:\n  " + strings.Join(headerDiffs, "\n  ")
+		} else {
+			diff += ", they are identical"
+		}
+		diffs = append(diffs, diff)
+	}
+	if len(diffs) > 0 {
+		return "found duplicate requests:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+// CheckNoDuplicateRequests checks that the transport did not send
+// the same request more than once. See the CheckNoDuplicateRequests
+// function.
+func (t *RecordingTransport) CheckNoDuplicateRequests(volatileHeaders ...string) string {
+	return CheckNoDuplicateRequests(t.Requests(), volatileHeaders...)
+}
+
+func requestKey(r HTTPRequest, volatile map[string]bool) string {
+	lines := []string{}
+	for name, values := range r.Header {
+		if name = http.CanonicalHeaderKey(name); !volatile[name] {
+			lines = append(lines, name+": "+strings.Join(values, ", "))
+		}
+	}
+	sort.Strings(lines)
+	return r.Method + "\n" + r.URL + "\n" + strings.Join(lines, "\n") + "\n\n" + r.Body
+}
+
+func volatileHeaderDiffs(first http.Header, dup http.Header, volatile map[string]bool) []string {
+	names := []string{}
+	for name := range volatile {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	diffs := []string{}
+	for _, name := range names {
+		if got, want := dup.Get(name), first.Get(name); got != want {
+			diffs = append(diffs, fmt.Sprintf("header %q got value %q, first request had %q", name, got, want))
+		}
+	}
+	return diffs
+}
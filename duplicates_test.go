@@ -0,0 +1,58 @@
+package testutil_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCheckNoDuplicateRequests tests that the expected diff is
+// generated when duplicate requests were sent.
+func TestCheckNoDuplicateRequests(t *testing.T) {
+	tests := []struct {
+		name            string
+		reqs            []testutil.HTTPRequest
+		volatileHeaders []string
+		wantDiff        string
+	}{
+		{
+			name: "no duplicates",
+			reqs: []testutil.HTTPRequest{
+				{Method: "POST", URL: "http://hello.com/charge", Body: "amount=1"},
+				{Method: "POST", URL: "http://hello.com/charge", Body: "amount=2"},
+				{Method: "GET", URL: "http://hello.com/charge", Body: "amount=1"},
+			},
+			wantDiff: "",
+		},
+		{
+			name: "identical duplicates",
+			reqs: []testutil.HTTPRequest{
+				{Method: "POST", URL: "http://hello.com/charge", Body: "amount=1"},
+				{Method: "POST", URL: "http://hello.com/charge", Body: "amount=2"},
+				{Method: "POST", URL: "http://hello.com/charge", Body: "amount=1"},
+			},
+			wantDiff: `found duplicate requests:
+request 2 is a duplicate of request 0 (POST http://hello.com/charge), they are identical`,
+		},
+		{
+			name: "duplicates differing in volatile headers",
+			reqs: []testutil.HTTPRequest{
+				{Method: "POST", URL: "http://hello.com/charge", Header: http.Header{"X-Request-Id": {"1"}, "Idempotency-Key": {"k"}}},
+				{Method: "POST", URL: "http://hello.com/charge", Header: http.Header{"X-Request-Id": {"2"}, "Idempotency-Key": {"k"}}},
+				{Method: "POST", URL: "http://hello.com/charge", Header: http.Header{"X-Request-Id": {"3"}, "Idempotency-Key": {"other"}}},
+			},
+			volatileHeaders: []string{"x-request-id"},
+			wantDiff: `found duplicate requests:
+request 1 is a duplicate of request 0 (POST http://hello.com/charge), they differ only in:
+  header "X-Request-Id" got value "2", first request had "1"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.CheckNoDuplicateRequests(test.reqs, test.volatileHeaders...), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
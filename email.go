@@ -0,0 +1,175 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// email is the parts of an email message worth comparing.
+type email struct {
+	header      mail.Header
+	text        string
+	html        string
+	attachments map[string]string
+}
+
+// CompareEmails parses two RFC 5322 email messages, including MIME
+// multipart ones, and returns a string detailing how they differ or
+// "" if they don't. Like CheckHTTPResponse only the headers present
+// on want are checked. Encoded headers and bodies are decoded before
+// they're compared, line endings are normalized and whitespace
+// between HTML tags is ignored. Attachments are compared by file name
+// and content.
+func CompareEmails(got string, want string) string {
+	gotEmail, err := parseEmail(got)
+	if err != nil {
+		return fmt.Sprintf("could not parse got email: %v", err)
+	}
+	wantEmail, err := parseEmail(want)
+	if err != nil {
+		return fmt.Sprintf("could not parse want email: %v", err)
+	}
+	diffs := []string{}
+	headerNames := []string{}
+	for name := range wantEmail.header {
+		if name != "Content-Type" && name != "Content-Transfer-Encoding" {
+			headerNames = append(headerNames, name)
+		}
+	}
+	sort.Strings(headerNames)
+	dec := new(mime.WordDecoder)
+	for _, name := range headerNames {
+		got, _ := dec.DecodeHeader(gotEmail.header.Get(name))
+		want, _ := dec.DecodeHeader(wantEmail.header.Get(name))
+		if got != want {
+			diffs = append(diffs, fmt.Sprintf("header %q got value %q, want %q", name, got, want))
+		}
+	}
+	if diff := CompareStrings(gotEmail.text, wantEmail.text); diff != "" {
+		diffs = append(diffs, "text body is not expected, "+diff)
+	}
+	if diff := CompareStrings(gotEmail.html, wantEmail.html); diff != "" {
+		diffs = append(diffs, "html body is not expected, "+diff)
+	}
+	names := []string{}
+	for name := range wantEmail.attachments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g, ok := gotEmail.attachments[name]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("missing attachment %q", name))
+			continue
+		}
+		if diff := CompareStrings(g, wantEmail.attachments[name]); diff != "" {
+			diffs = append(diffs, fmt.Sprintf("attachment %q is not expected, %s", name, diff))
+		}
+	}
+	names = names[:0]
+	for name := range gotEmail.attachments {
+		if _, ok := wantEmail.attachments[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		diffs = append(diffs, fmt.Sprintf("unexpected attachment %q", name))
+	}
+	if len(diffs) > 0 {
+		return "email does not match what is expected:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+func parseEmail(raw string) (email, error) {
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		return email{}, err
+	}
+	e := email{header: msg.Header, attachments: map[string]string{}}
+	err = e.addPart(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Header.Get("Content-Disposition"), msg.Body)
+	return e, err
+}
+
+func (e *email) addPart(contentType string, encoding string, disposition string, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if contentType == "" || err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := e.addPart(p.Header.Get("Content-Type"), p.Header.Get("Content-Transfer-Encoding"), p.Header.Get("Content-Disposition"), p); err != nil {
+				return err
+			}
+		}
+	}
+	switch strings.ToLower(encoding) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, newlineStripper{body})
+	}
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	dispType, dispParams, _ := mime.ParseMediaType(disposition)
+	filename := dispParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	switch {
+	case dispType == "attachment" || filename != "":
+		e.attachments[filename] = string(b)
+	case mediaType == "text/html":
+		e.html += normalizeHTML(string(b))
+	case mediaType == "text/plain":
+		e.text += normalizeText(string(b))
+	}
+	return nil
+}
+
+// newlineStripper removes line breaks so base64 bodies, which are
+// wrapped every 76 characters, can be decoded.
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n newlineStripper) Read(p []byte) (int, error) {
+	c, err := n.r.Read(p)
+	c = copy(p, bytes.Replace(bytes.Replace(p[:c], []byte("\r"), nil, -1), []byte("\n"), nil, -1))
+	return c, err
+}
+
+func normalizeText(s string) string {
+	lines := strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+var betweenTagsRE = regexp.MustCompile(`>\s+<`)
+
+func normalizeHTML(s string) string {
+	return betweenTagsRE.ReplaceAllString(strings.TrimSpace(normalizeText(s)), "><")
+}
@@ -0,0 +1,112 @@
+package testutil_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareEmails tests that the expected diff is generated when
+// comparing email messages.
+func TestCompareEmails(t *testing.T) {
+	crlf := func(s string) string {
+		return strings.Replace(s, "\n", "\r\n", -1)
+	}
+	got := crlf(`From: shop@hello.com
+To: bob@hello.com
+Subject: =?UTF-8?Q?Your_order_=E2=9C=93?=
+Date: Thu, 04 Mar 2021 05:06:07 +0000
+MIME-Version: 1.0
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Thanks for your order=21  
+Total: 42
+--inner
+Content-Type: text/html; charset=utf-8
+
+<html>
+  <body><p>Thanks for your order!</p></body>
+</html>
+--inner--
+--outer
+Content-Type: text/plain
+Content-Disposition: attachment; filename="receipt.txt"
+Content-Transfer-Encoding: base64
+
+cmVjZWlwdCAj
+MTIz
+--outer--
+`)
+	tests := []struct {
+		name     string
+		want     string
+		wantDiff string
+	}{
+		{
+			name: "emails match",
+			want: `From: shop@hello.com
+Subject: Your order ✓
+Content-Type: multipart/mixed; boundary=b
+
+--b
+Content-Type: text/plain
+
+Thanks for your order!
+Total: 42
+--b
+Content-Type: text/html
+
+<html><body><p>Thanks for your order!</p></body></html>
+--b
+Content-Disposition: attachment; filename=receipt.txt
+
+receipt #123
+--b--
+`,
+			wantDiff: "",
+		},
+		{
+			name: "emails differ",
+			want: `From: shop@hello.com
+To: alice@hello.com
+Content-Type: multipart/mixed; boundary=b
+
+--b
+Content-Type: text/plain
+
+Thanks for your order!
+Total: 43
+--b
+Content-Disposition: attachment; filename=invoice.pdf
+
+pdf
+--b--
+`,
+			wantDiff: `email does not match what is expected:
+header "To" got value "bob@hello.com", want "alice@hello.com"
+text body is not expected, strings differ at index 31 (line 2, column 9), from that index on:
+##### got string #####
+2
+##### want string #####
+3
+html body is not expected, got a longer string than what we wanted (characters match otherwise) and the extra characters are: <html><body><p>Thanks for your order!</p></body></html>
+missing attachment "invoice.pdf"
+unexpected attachment "receipt.txt"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.CompareEmails(got, test.want), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
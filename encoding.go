@@ -0,0 +1,92 @@
+package testutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// bodyEncodingBase64 is the body_encoding of HTTPRequests and
+// HTTPResponses whose body isn't valid UTF-8 and was base64 encoded
+// so it survives being marshalled to JSON.
+const bodyEncodingBase64 = "base64"
+
+// MarshalJSON marshals r to JSON. A body which isn't valid UTF-8 is
+// base64 encoded and "body_encoding" is set to "base64" so binary
+// bodies survive the round trip, JSON strings would otherwise mangle
+// them.
+func (r HTTPRequest) MarshalJSON() ([]byte, error) {
+	type plain HTTPRequest
+	body, encoding := encodeBody(r.Body)
+	r.Body = body
+	return json.Marshal(struct {
+		plain
+		BodyEncoding string `json:"body_encoding,omitempty"`
+	}{plain(r), encoding})
+}
+
+// UnmarshalJSON unmarshals r from JSON, decoding the body if it was
+// base64 encoded by MarshalJSON.
+func (r *HTTPRequest) UnmarshalJSON(b []byte) error {
+	type plain HTTPRequest
+	v := struct {
+		*plain
+		BodyEncoding string `json:"body_encoding"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	body, err := decodeBodyEncoding(r.Body, v.BodyEncoding)
+	r.Body = body
+	return err
+}
+
+// MarshalJSON marshals r to JSON. See HTTPRequest.MarshalJSON for how
+// binary bodies are handled.
+func (r HTTPResponse) MarshalJSON() ([]byte, error) {
+	type plain HTTPResponse
+	body, encoding := encodeBody(r.Body)
+	r.Body = body
+	return json.Marshal(struct {
+		plain
+		BodyEncoding string `json:"body_encoding,omitempty"`
+	}{plain(r), encoding})
+}
+
+// UnmarshalJSON unmarshals r from JSON, decoding the body if it was
+// base64 encoded by MarshalJSON.
+func (r *HTTPResponse) UnmarshalJSON(b []byte) error {
+	type plain HTTPResponse
+	v := struct {
+		*plain
+		BodyEncoding string `json:"body_encoding"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	body, err := decodeBodyEncoding(r.Body, v.BodyEncoding)
+	r.Body = body
+	return err
+}
+
+func encodeBody(body string) (string, string) {
+	if utf8.ValidString(body) {
+		return body, ""
+	}
+	return base64.StdEncoding.EncodeToString([]byte(body)), bodyEncodingBase64
+}
+
+func decodeBodyEncoding(body string, encoding string) (string, error) {
+	switch encoding {
+	case "":
+		return body, nil
+	case bodyEncodingBase64:
+		b, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return "", fmt.Errorf("could not decode base64 body: %v", err)
+		}
+		return string(b), nil
+	}
+	return "", fmt.Errorf("unknown body encoding %q", encoding)
+}
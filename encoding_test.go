@@ -0,0 +1,45 @@
+package testutil_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestHTTPJSONRoundTrip tests that requests and responses, including
+// binary bodies, survive being marshalled to JSON and back.
+func TestHTTPJSONRoundTrip(t *testing.T) {
+	interactions := []testutil.Interaction{
+		{
+			Request:  testutil.HTTPRequest{Method: "POST", URL: "/upload", Header: http.Header{"Content-Type": {"image/png"}}, Body: "\x89PNG\r\n\x1a\n\x00\xff"},
+			Response: testutil.HTTPResponse{StatusCode: 201, Header: http.Header{"Content-Type": {"text/plain"}}, Body: "créé"},
+		},
+		{
+			Request:  testutil.HTTPRequest{Method: "GET", URL: "/download"},
+			Response: testutil.HTTPResponse{StatusCode: 200, Body: "\x1f\x8b\x08\x00", Proto: "HTTP/2.0"},
+		},
+	}
+	b, err := json.Marshal(interactions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(b); !strings.Contains(s, `"body":"iVBORw0KGgoA/w==","body_encoding":"base64"`) || !strings.Contains(s, `"body":"créé"`) || !strings.Contains(s, `"status_code":201`) {
+		t.Errorf("got unexpected JSON %s", s)
+	}
+	var got []testutil.Interaction
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, interactions) {
+		t.Errorf("got interactions %+v after a round trip, want %+v", got, interactions)
+	}
+
+	var req testutil.HTTPRequest
+	if err := json.Unmarshal([]byte(`{"body": "abc", "body_encoding": "rot13"}`), &req); err == nil || err.Error() != `unknown body encoding "rot13"` {
+		t.Errorf("got error %v, want an unknown body encoding error", err)
+	}
+}
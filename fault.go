@@ -0,0 +1,100 @@
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Fault is a way for a RecordingServer to misbehave when answering a
+// request so the error handling of clients can be tested. Inject it
+// into a stub with Stub.InjectFault.
+type Fault string
+
+// The faults a RecordingServer can inject.
+const (
+	// FaultDropConnection closes the connection without responding,
+	// clients see an EOF.
+	FaultDropConnection Fault = "drop_connection"
+	// FaultTruncateBody sends the status code, the headers and a
+	// Content-Length for the whole body but only half of it before
+	// closing the connection, clients see io.ErrUnexpectedEOF.
+	FaultTruncateBody Fault = "truncate_body"
+	// FaultMalformedResponse sends something which isn't HTTP.
+	FaultMalformedResponse Fault = "malformed_response"
+	// FaultHang never responds, clients see their timeout expire.
+	// The request is abandoned when the client gives up or the
+	// server is closed.
+	FaultHang Fault = "hang"
+)
+
+// InjectFault makes the stub misbehave as f describes instead of
+// sending its response to the requests it answers with the given call
+// numbers, counting from 1, or to every request if no calls are given.
+// FaultTruncateBody still sends part of the response. For example a
+// client's retries can be tested with a stub which drops the
+// connection on the first call and responds normally on the second.
+func (st *Stub) InjectFault(f Fault, calls ...int) *Stub {
+	st.server.mu.Lock()
+	defer st.server.mu.Unlock()
+	if st.faults == nil {
+		st.faults = map[int]Fault{}
+	}
+	if len(calls) == 0 {
+		calls = []int{0}
+	}
+	for _, call := range calls {
+		st.faults[call] = f
+	}
+	return st
+}
+
+// fault returns the fault to inject into the response to call, or ""
+// if there is none.
+func (st *Stub) fault(call int) Fault {
+	if f, ok := st.faults[call]; ok {
+		return f
+	}
+	return st.faults[0]
+}
+
+// serveFault misbehaves as f describes, sending part of resp for
+// FaultTruncateBody.
+func (s *RecordingServer) serveFault(w http.ResponseWriter, r *http.Request, resp HTTPResponse, f Fault) {
+	switch f {
+	case FaultDropConnection:
+		panic(http.ErrAbortHandler)
+	case FaultTruncateBody:
+		resp.Header = resp.Header.Clone()
+		if resp.Header == nil {
+			resp.Header = http.Header{}
+		}
+		resp.Header.Set("Content-Length", strconv.Itoa(len(resp.Body)))
+		resp.Body = resp.Body[:len(resp.Body)/2]
+		writeHTTPResponse(w, resp)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		panic(http.ErrAbortHandler)
+	case FaultMalformedResponse:
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			panic(http.ErrAbortHandler)
+		}
+		conn, buf, err := hj.Hijack()
+		if err != nil {
+			panic(http.ErrAbortHandler)
+		}
+		defer conn.Close()
+		buf.WriteString("NOT-HTTP\r\n\r\n")
+		buf.Flush()
+	case FaultHang:
+		select {
+		case <-r.Context().Done():
+		case <-s.closing:
+		}
+		panic(http.ErrAbortHandler)
+	default:
+		http.Error(w, fmt.Sprintf("unknown fault %q", f), http.StatusInternalServerError)
+	}
+}
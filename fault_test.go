@@ -0,0 +1,75 @@
+package testutil_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lag13/testutil"
+)
+
+// TestRecordingServerFaults tests that clients see the errors each
+// fault is meant to cause.
+func TestRecordingServerFaults(t *testing.T) {
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	defer server.Close()
+	server.Stub("GET", "/drop", testutil.HTTPResponse{}).InjectFault(testutil.FaultDropConnection)
+	server.Stub("GET", "/truncate", testutil.HTTPResponse{StatusCode: 200, Body: "a long body"}).InjectFault(testutil.FaultTruncateBody)
+	server.Stub("GET", "/malformed", testutil.HTTPResponse{}).InjectFault(testutil.FaultMalformedResponse)
+	server.Stub("GET", "/hang", testutil.HTTPResponse{}).InjectFault(testutil.FaultHang)
+	client := &http.Client{Timeout: 100 * time.Millisecond}
+	get := func(path string) error {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, err = io.ReadAll(resp.Body)
+		return err
+	}
+	t.Run("drop connection", func(t *testing.T) {
+		if err := get("/drop"); !errors.Is(err, io.EOF) {
+			t.Errorf("got error %v, want %v", err, io.EOF)
+		}
+	})
+	t.Run("truncate body", func(t *testing.T) {
+		if err := get("/truncate"); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("got error %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+	t.Run("malformed response", func(t *testing.T) {
+		if diff := testutil.CheckErrHasMsg(get("/malformed"), `Get "`+server.URL+`/malformed": net/http: HTTP/1.x transport connection broken: malformed HTTP response "NOT-HTTP"`); diff != "" {
+			t.Error(diff)
+		}
+	})
+	t.Run("hang", func(t *testing.T) {
+		var netErr interface{ Timeout() bool }
+		if err := get("/hang"); !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Errorf("got error %v, want a timeout", err)
+		}
+	})
+	if got, want := len(server.Requests()), 4; got != want {
+		t.Errorf("got %d recorded requests, want %d", got, want)
+	}
+}
+
+// TestRecordingServerFaultOnCall tests that a fault can be injected
+// into only some of the calls to a stub.
+func TestRecordingServerFaultOnCall(t *testing.T) {
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	defer server.Close()
+	server.Stub("GET", "/flaky", testutil.HTTPResponse{StatusCode: 200, Body: "ok"}).InjectFault(testutil.FaultDropConnection, 1)
+	if _, err := http.Get(server.URL + "/flaky"); !errors.Is(err, io.EOF) {
+		t.Errorf("got error %v on the first call, want %v", err, io.EOF)
+	}
+	resp, err := http.Get(server.URL + "/flaky")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: "ok"}, testutil.Partial()); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,92 @@
+package testutil
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// RetryFlaky runs body, a test known to be flaky, up to attempts times
+// until it passes. Failures from each attempt are collected rather
+// than reported straight away. If a later attempt passes the earlier
+// failures are logged as a flake report and the test passes, if every
+// attempt fails they are reported as an error. This lets a flaky test
+// be quarantined while still showing how often it flakes instead of
+// being deleted.
+func RetryFlaky(t testing.TB, attempts int, body func(t testing.TB)) {
+	t.Helper()
+	if attempts < 1 {
+		attempts = 1
+	}
+	failures := []string{}
+	for i := 1; i <= attempts; i++ {
+		a := &attemptTB{TB: t}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			body(a)
+		}()
+		<-done
+		if !a.Failed() {
+			if len(failures) > 0 {
+				t.Logf("flaky test passed on attempt %d of %d, earlier attempts failed with:\n%s", i, attempts, strings.Join(failures, "\n"))
+			}
+			return
+		}
+		failures = append(failures, fmt.Sprintf("attempt %d:\n%s", i, indent(strings.Join(a.errors, "\n"), "  ")))
+	}
+	Report(t, "RetryFlaky", "", fmt.Sprintf("test failed consistently, all %d attempts failed:\n%s", attempts, strings.Join(failures, "\n")))
+}
+
+// attemptTB records the failures of a single attempt of a test run by
+// RetryFlaky. Everything else is passed through to the real test.
+type attemptTB struct {
+	testing.TB
+	mu     sync.Mutex
+	failed bool
+	errors []string
+}
+
+func (a *attemptTB) fail(msg string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.failed = true
+	if msg != "" {
+		a.errors = append(a.errors, msg)
+	}
+}
+
+func (a *attemptTB) Error(args ...interface{}) {
+	a.fail(strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
+
+func (a *attemptTB) Errorf(format string, args ...interface{}) {
+	a.fail(fmt.Sprintf(format, args...))
+}
+
+func (a *attemptTB) Fail() {
+	a.fail("")
+}
+
+func (a *attemptTB) FailNow() {
+	a.fail("")
+	runtime.Goexit()
+}
+
+func (a *attemptTB) Fatal(args ...interface{}) {
+	a.Error(args...)
+	runtime.Goexit()
+}
+
+func (a *attemptTB) Fatalf(format string, args ...interface{}) {
+	a.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+func (a *attemptTB) Failed() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.failed
+}
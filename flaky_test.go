@@ -0,0 +1,59 @@
+package testutil_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// recordingTB captures what is reported on a test so we can check the
+// reports made by RetryFlaky.
+type recordingTB struct {
+	testing.TB
+	errors []string
+	logs   []string
+}
+
+func (r *recordingTB) Error(args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+func (r *recordingTB) Logf(format string, args ...interface{}) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+// TestRetryFlaky tests that flaky tests are retried and the expected
+// flake report is produced.
+func TestRetryFlaky(t *testing.T) {
+	t.Run("passes on retry", func(t *testing.T) {
+		rec := &recordingTB{TB: t}
+		calls := 0
+		testutil.RetryFlaky(rec, 3, func(t testing.TB) {
+			calls++
+			if calls < 3 {
+				t.Fatalf("timed out on call %d", calls)
+			}
+		})
+		if len(rec.errors) != 0 {
+			t.Errorf("got errors %q, want none", rec.errors)
+		}
+		want := "flaky test passed on attempt 3 of 3, earlier attempts failed with:\nattempt 1:\n  timed out on call 1\nattempt 2:\n  timed out on call 2"
+		if len(rec.logs) != 1 || rec.logs[0] != want {
+			t.Errorf("got logs %q, want:\n%s", rec.logs, want)
+		}
+	})
+	t.Run("consistent failure", func(t *testing.T) {
+		rec := &recordingTB{TB: t}
+		testutil.RetryFlaky(rec, 2, func(t testing.TB) {
+			t.Error("got status code 500, want 200")
+		})
+		want := "test failed consistently, all 2 attempts failed:\nattempt 1:\n  got status code 500, want 200\nattempt 2:\n  got status code 500, want 200"
+		if len(rec.errors) != 1 || rec.errors[0] != want {
+			t.Errorf("got errors %q, want:\n%s", rec.errors, want)
+		}
+		if len(rec.logs) != 0 {
+			t.Errorf("got logs %q, want none", rec.logs)
+		}
+	})
+}
@@ -0,0 +1,26 @@
+package testutil
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CompareForm parses two application/x-www-form-urlencoded bodies
+// and returns a string detailing which fields differ or "" if none
+// do. The order of the fields and how they were escaped, "+" versus
+// "%20" for instance, don't matter.
+func CompareForm(got string, want string, opts ...Option) string {
+	gotForm, err := url.ParseQuery(got)
+	if err != nil {
+		return fmt.Sprintf("could not parse got form: %v", err)
+	}
+	wantForm, err := url.ParseQuery(want)
+	if err != nil {
+		return fmt.Sprintf("could not parse want form: %v", err)
+	}
+	if diffs := valuesDiffs("form field", gotForm, wantForm); len(diffs) > 0 {
+		return "form does not match:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
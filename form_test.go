@@ -0,0 +1,66 @@
+package testutil_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareForm tests that the expected diff is generated when
+// comparing form bodies.
+func TestCompareForm(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		want     string
+		wantDiff string
+	}{
+		{
+			name:     "same fields in a different order and encoding",
+			got:      "name=Bob+Smith&tag=b&tag=a&note=a%2Fb",
+			want:     "note=a/b&tag=a&tag=b&name=Bob%20Smith",
+			wantDiff: "",
+		},
+		{
+			name: "fields differ",
+			got:  "name=Bob&tag=a&extra=1",
+			want: "name=Alice&tag=a&tag=b&age=30",
+			wantDiff: `form does not match:
+missing form field "age", want ["30"]
+unexpected form field "extra" with ["1"]
+form field "name": got ["Bob"], want ["Alice"]
+form field "tag": got ["a"], want ["a" "b"]`,
+		},
+		{
+			name:     "invalid",
+			got:      "a=%zz",
+			want:     "",
+			wantDiff: `could not parse got form: invalid URL escape "%zz"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareForm(test.got, test.want), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCheckHTTPRequestForm tests that form bodies are compared field by
+// field.
+func TestCheckHTTPRequestForm(t *testing.T) {
+	gotReq := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Scheme: "http", Host: "hello.com"},
+		Header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+		Body:   ioutil.NopCloser(strings.NewReader("b=2&a=1")),
+	}
+	if diff := testutil.CheckHTTPRequest(gotReq, testutil.HTTPRequest{Method: "POST", URL: "http://hello.com", Body: "a=1&b=2"}); diff != "" {
+		t.Error(diff)
+	}
+}
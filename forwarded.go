@@ -0,0 +1,53 @@
+package testutil
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CheckForwardedHeaders makes CheckHTTPRequest verify that any
+// X-Forwarded-* headers on the request are well formed: every
+// X-Forwarded-For entry is an IP address, X-Forwarded-Proto is http
+// or https, X-Forwarded-Port is a port number, X-Forwarded-Host is a
+// host and the port agrees with the one in X-Forwarded-Host if both
+// are present. It is meant for testing proxies and middleware.
+func CheckForwardedHeaders() Option {
+	return func(o *options) {
+		o.checkForwardedHeaders = true
+	}
+}
+
+func forwardedHeaderDiffs(h http.Header) []string {
+	diffs := []string{}
+	if xff := h.Get("X-Forwarded-For"); xff != "" {
+		for _, hop := range strings.Split(xff, ",") {
+			if hop = strings.TrimSpace(hop); net.ParseIP(hop) == nil {
+				diffs = append(diffs, fmt.Sprintf("X-Forwarded-For entry %q is not an IP address", hop))
+			}
+		}
+	}
+	if proto := h.Get("X-Forwarded-Proto"); proto != "" && proto != "http" && proto != "https" {
+		diffs = append(diffs, fmt.Sprintf("X-Forwarded-Proto %q is not http or https", proto))
+	}
+	port := h.Get("X-Forwarded-Port")
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			diffs = append(diffs, fmt.Sprintf("X-Forwarded-Port %q is not a port number", port))
+		}
+	}
+	if host := h.Get("X-Forwarded-Host"); host != "" {
+		name, hostPort, err := net.SplitHostPort(host)
+		if err != nil {
+			name, hostPort = host, ""
+		}
+		if name == "" || strings.ContainsAny(name, " /?#@") {
+			diffs = append(diffs, fmt.Sprintf("X-Forwarded-Host %q is not a host", host))
+		} else if hostPort != "" && port != "" && hostPort != port {
+			diffs = append(diffs, fmt.Sprintf("X-Forwarded-Host %q has port %s but X-Forwarded-Port is %s", host, hostPort, port))
+		}
+	}
+	return diffs
+}
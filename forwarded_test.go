@@ -0,0 +1,80 @@
+package testutil_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestConnectionLevelRequestChecks tests that the expected diff is
+// generated when checking host, remote address and X-Forwarded-*
+// headers.
+func TestConnectionLevelRequestChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		wantReq  testutil.HTTPRequest
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name: "host and remote address match",
+			wantReq: testutil.HTTPRequest{
+				Host:       "api.hello.com",
+				RemoteAddr: `^10\.0\.0\.1:\d+$`,
+			},
+			wantDiff: "",
+		},
+		{
+			name: "host and remote address do not match",
+			wantReq: testutil.HTTPRequest{
+				Host:       "hello.com",
+				RemoteAddr: `^127\.0\.0\.1:\d+$`,
+			},
+			wantDiff: `request does not match what is expected:
+got host "api.hello.com", want "hello.com"
+remote address did not match: "10.0.0.1:4567" does not match regexp "^127\\.0\\.0\\.1:\\d+$"`,
+		},
+		{
+			name: "consistent forwarded headers",
+			header: http.Header{
+				"X-Forwarded-For":   {"203.0.113.7, 10.0.0.1"},
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"hello.com:443"},
+				"X-Forwarded-Port":  {"443"},
+			},
+			opts:     []testutil.Option{testutil.CheckForwardedHeaders()},
+			wantDiff: "",
+		},
+		{
+			name: "inconsistent forwarded headers",
+			header: http.Header{
+				"X-Forwarded-For":   {"203.0.113.7, unknown"},
+				"X-Forwarded-Proto": {"ftp"},
+				"X-Forwarded-Host":  {"hello.com:8443"},
+				"X-Forwarded-Port":  {"443"},
+			},
+			opts: []testutil.Option{testutil.CheckForwardedHeaders()},
+			wantDiff: `request does not match what is expected:
+X-Forwarded-For entry "unknown" is not an IP address
+X-Forwarded-Proto "ftp" is not http or https
+X-Forwarded-Host "hello.com:8443" has port 8443 but X-Forwarded-Port is 443`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotReq := testutil.MustNewHTTPRequest("GET", "http://api.hello.com/", strings.NewReader(""))
+			gotReq.RemoteAddr = "10.0.0.1:4567"
+			for name, values := range test.header {
+				gotReq.Header[name] = values
+			}
+			test.wantReq.Method = "GET"
+			test.wantReq.URL = "http://api.hello.com/"
+			if diff := testutil.CompareStrings(testutil.CheckHTTPRequest(gotReq, test.wantReq, test.opts...), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package testutil
+
+import "fmt"
+
+// MatchGlob checks that got matches pattern, where "*" matches any run
+// of characters, including none, and "?" matches exactly one
+// character, and returns a string explaining where the match failed
+// or "" if it didn't. Unlike path.Match "*" also matches "/" so
+// patterns like "/users/*/orders" work on whole URLs.
+func MatchGlob(got string, pattern string) string {
+	g, p := []rune(got), []rune(pattern)
+	// reach[j] is true if the first i runes of got can be matched by
+	// the first j runes of pattern, for the current i.
+	reach := make([]bool, len(p)+1)
+	reach[0] = true
+	for j := 1; j <= len(p) && p[j-1] == '*'; j++ {
+		reach[j] = true
+	}
+	// furthest is the longest prefix of the pattern which matched
+	// and whole is the longest prefix of got the whole pattern
+	// matched.
+	furthest, whole := lastTrue(reach), -1
+	if reach[len(p)] {
+		whole = 0
+	}
+	for i := 1; i <= len(g); i++ {
+		next := make([]bool, len(p)+1)
+		for j := 1; j <= len(p); j++ {
+			switch p[j-1] {
+			case '*':
+				next[j] = next[j-1] || reach[j]
+			case '?':
+				next[j] = reach[j-1]
+			default:
+				next[j] = reach[j-1] && p[j-1] == g[i-1]
+			}
+		}
+		reach = next
+		if j := lastTrue(reach); j > furthest {
+			furthest = j
+		}
+		if reach[len(p)] {
+			whole = i
+		}
+	}
+	if reach[len(p)] {
+		return ""
+	}
+	if furthest == len(p) {
+		return fmt.Sprintf("%q does not match glob %q, there are characters left over after the whole pattern matched: %q", got, pattern, string(g[whole:]))
+	}
+	return fmt.Sprintf("%q does not match glob %q, could not match %q after matching %q", got, pattern, string(p[furthest:]), string(p[:furthest]))
+}
+
+func lastTrue(bs []bool) int {
+	for i := len(bs) - 1; i >= 0; i-- {
+		if bs[i] {
+			return i
+		}
+	}
+	return -1
+}
+
+// Glob returns a Matcher which matches values whose string form
+// matches the glob pattern. See MatchGlob.
+func Glob(pattern string) Matcher {
+	return MatcherFunc(func(got interface{}) string {
+		return MatchGlob(string(toBytes(got)), pattern)
+	})
+}
+
+// matchPattern reports whether s matches the glob pattern, see
+// MatchGlob. It is how the pattern fields, like HTTPRequest.Path and
+// StubMatch.Path, are matched so "*" means the same everywhere.
+func matchPattern(pattern string, s string) bool {
+	return MatchGlob(s, pattern) == ""
+}
@@ -0,0 +1,111 @@
+package testutil_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestMatchGlob tests that glob patterns match and that the expected
+// diff is generated when they don't.
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		pattern  string
+		wantDiff string
+	}{
+		{
+			name:     "literal",
+			got:      "/users",
+			pattern:  "/users",
+			wantDiff: "",
+		},
+		{
+			name:     "star matches across slashes",
+			got:      "http://localhost:8080/users/42/orders",
+			pattern:  "http://*/users/*/orders",
+			wantDiff: "",
+		},
+		{
+			name:     "star matches nothing",
+			got:      "ab",
+			pattern:  "a*b*",
+			wantDiff: "",
+		},
+		{
+			name:     "question mark",
+			got:      "v2",
+			pattern:  "v?",
+			wantDiff: "",
+		},
+		{
+			name:     "fails part way",
+			got:      "/users/42/invoices",
+			pattern:  "/users/*/orders",
+			wantDiff: `"/users/42/invoices" does not match glob "/users/*/orders", could not match "orders" after matching "/users/*/"`,
+		},
+		{
+			name:     "too short",
+			got:      "/users/42",
+			pattern:  "/users/*/orders",
+			wantDiff: `"/users/42" does not match glob "/users/*/orders", could not match "/orders" after matching "/users/*"`,
+		},
+		{
+			name:     "too long",
+			got:      "v22",
+			pattern:  "v?",
+			wantDiff: `"v22" does not match glob "v?", there are characters left over after the whole pattern matched: "2"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.MatchGlob(test.got, test.pattern), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestGlobMatcher tests that globs can be used to match the URL and
+// body of a request.
+func TestGlobMatcher(t *testing.T) {
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Scheme: "http", Host: "127.0.0.1:54321", Path: "/users/42"},
+		Body:   ioutil.NopCloser(strings.NewReader(`{"id": 42}`)),
+	}
+	want := testutil.HTTPRequest{
+		Method:      "GET",
+		URLMatcher:  testutil.Glob("*/users/*"),
+		BodyMatcher: testutil.Glob(`{"id": ??}`),
+	}
+	if diff := testutil.CheckHTTPRequest(req, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// TestPatternFieldsSpanSlashes tests that "*" matches "/" in the
+// pattern fields of HTTPRequest and StubMatch, like it does in
+// MatchGlob.
+func TestPatternFieldsSpanSlashes(t *testing.T) {
+	req := testutil.MustNewHTTPRequest("GET", "http://hello.com/files/a/b.txt", nil)
+	if diff := testutil.CheckHTTPRequest(req, testutil.HTTPRequest{Method: "GET", Path: "/files/*"}, testutil.Partial()); diff != "" {
+		t.Error(diff)
+	}
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	defer server.Close()
+	server.Stub("GET", "/files/*", testutil.HTTPResponse{StatusCode: 200, Body: "file"})
+	resp, err := http.Get(server.URL + "/files/a/b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: "file"}, testutil.Partial()); diff != "" {
+		t.Error(diff)
+	}
+}
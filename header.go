@@ -0,0 +1,116 @@
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// OrderedHeaderValues makes CheckHTTPRequest and CheckHTTPResponse
+// require that headers with several values have them in the same
+// order as wanted. By default only the values themselves matter.
+func OrderedHeaderValues() Option {
+	return func(o *options) {
+		o.orderedHeaderValues = true
+	}
+}
+
+// StrictHeaders makes CheckHTTPRequest and CheckHTTPResponse report
+// headers which were got but not mentioned in want, except for the
+// allowed ones. Unlike Strict it leaves the other fields alone. It catches internal headers leaking
+// out while tolerating ones like Date and Content-Length which are
+// added by the server.
+func StrictHeaders(allowed ...string) Option {
+	return func(o *options) {
+		o.strictHeaders = true
+		o.allowedHeaders = append(o.allowedHeaders, allowed...)
+	}
+}
+
+// headerDiffs adds a diff for each header in want whose values in got
+// differ. Every value of a header is compared, not just the first,
+// and the diff says which values are missing or unexpected. noun is
+// what the headers are called in the diff, "header" or "trailer".
+func headerDiffs(diffs *Diffs, noun string, got http.Header, want http.Header, o options) {
+	names := make([]string, 0, len(want))
+	for name := range want {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if o.ignoredHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		path := fmt.Sprintf("%s %q", noun, name)
+		gotValues, wantValues := got.Values(name), want[name]
+		if len(wantValues) == 1 && len(gotValues) <= 1 {
+			if got, want := got.Get(name), wantValues[0]; got != want {
+				diffs.add(path, DiffChanged, got, want, fmt.Sprintf("%s %q got value %q, want %q", noun, name, got, want))
+			}
+			continue
+		}
+		missing, unexpected := multisetDiff(gotValues, wantValues)
+		for _, v := range missing {
+			diffs.add(path, DiffMissing, nil, v, fmt.Sprintf("%s %q is missing value %q", noun, name, v))
+		}
+		for _, v := range unexpected {
+			diffs.add(path, DiffUnexpected, v, nil, fmt.Sprintf("%s %q has unexpected value %q", noun, name, v))
+		}
+		if len(missing) == 0 && len(unexpected) == 0 && o.orderedHeaderValues && fmt.Sprint(gotValues) != fmt.Sprint(wantValues) {
+			diffs.add(path, DiffChanged, gotValues, wantValues, fmt.Sprintf("%s %q got values in order %q, want %q", noun, name, gotValues, wantValues))
+		}
+	}
+}
+
+// multisetDiff returns the values in want which don't have a matching
+// value in got and the values in got which don't have a matching
+// value in want.
+func multisetDiff(got []string, want []string) (missing []string, unexpected []string) {
+	counts := map[string]int{}
+	for _, v := range got {
+		counts[v]++
+	}
+	for _, v := range want {
+		if counts[v] > 0 {
+			counts[v]--
+		} else {
+			missing = append(missing, v)
+		}
+	}
+	for _, v := range got {
+		if counts[v] > 0 {
+			counts[v]--
+			unexpected = append(unexpected, v)
+		}
+	}
+	return missing, unexpected
+}
+
+// unexpectedHeaderDiffs adds a diff for each header in got which is
+// not mentioned in want or matchers or allowed or ignored. noun is
+// what the headers are called in the diff, "header" or "trailer".
+func unexpectedHeaderDiffs(diffs *Diffs, noun string, got http.Header, want http.Header, matchers map[string]Matcher, allowed []string, ignored map[string]bool) {
+	declared := map[string]bool{}
+	for name := range ignored {
+		declared[name] = true
+	}
+	for _, name := range allowed {
+		declared[http.CanonicalHeaderKey(name)] = true
+	}
+	for name := range want {
+		declared[http.CanonicalHeaderKey(name)] = true
+	}
+	for name := range matchers {
+		declared[http.CanonicalHeaderKey(name)] = true
+	}
+	names := make([]string, 0, len(got))
+	for name := range got {
+		if !declared[http.CanonicalHeaderKey(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		diffs.add(fmt.Sprintf("%s %q", noun, name), DiffUnexpected, got[name], nil, fmt.Sprintf("unexpected %s %q with %q", noun, name, got[name]))
+	}
+}
@@ -0,0 +1,122 @@
+package testutil_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCheckHTTPResponseHeaderValues tests that every value of a header
+// is compared.
+func TestCheckHTTPResponseHeaderValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   http.Header
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "same values in a different order",
+			header:   http.Header{"Vary": {"Origin", "Accept", "Accept"}},
+			wantDiff: "",
+		},
+		{
+			name:   "values missing and unexpected",
+			header: http.Header{"Vary": {"Accept", "Cookie", "Origin", "Origin"}},
+			wantDiff: `response does not match what is expected:
+header "Vary" is missing value "Cookie"
+header "Vary" is missing value "Origin"
+header "Vary" has unexpected value "Accept"`,
+		},
+		{
+			name:   "order matters",
+			header: http.Header{"Vary": {"Origin", "Accept", "Accept"}},
+			opts:   []testutil.Option{testutil.OrderedHeaderValues()},
+			wantDiff: `response does not match what is expected:
+header "Vary" got values in order ["Accept" "Origin" "Accept"], want ["Origin" "Accept" "Accept"]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotResp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Vary": {"Accept", "Origin", "Accept"}},
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			}
+			wantResp := testutil.HTTPResponse{StatusCode: 200, Header: test.header}
+			if got, want := testutil.CheckHTTPResponse(gotResp, wantResp, test.opts...), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCheckHTTPResponseTrailer tests that trailers sent after a
+// chunked body are checked.
+func TestCheckHTTPResponseTrailer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Checksum")
+		w.Write([]byte("hello"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte(" there"))
+		w.Header().Set("X-Checksum", "abc123")
+	}))
+	defer server.Close()
+	tests := []struct {
+		name     string
+		trailer  http.Header
+		wantDiff string
+	}{
+		{
+			name:     "trailer matches",
+			trailer:  http.Header{"X-Checksum": {"abc123"}},
+			wantDiff: "",
+		},
+		{
+			name:    "trailer differs",
+			trailer: http.Header{"X-Checksum": {"def456"}},
+			wantDiff: `response does not match what is expected:
+trailer "X-Checksum" got value "abc123", want "def456"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: "hello there", Trailer: test.trailer})
+			if got, want := diff, test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestStrictHeaders tests that unexpected headers are reported unless
+// they are allowed.
+func TestStrictHeaders(t *testing.T) {
+	newReq := func() *http.Request {
+		req := testutil.MustNewHTTPRequest("GET", "http://hello.com", strings.NewReader(""))
+		req.Header = http.Header{
+			"Accept":            {"application/json"},
+			"Date":              {"Mon, 02 Jan 2006 15:04:05 GMT"},
+			"X-Internal-Secret": {"hunter2"},
+		}
+		return req
+	}
+	want := testutil.HTTPRequest{Method: "GET", URL: "http://hello.com", Header: http.Header{"Accept": {"application/json"}}}
+	wantDiff := `request does not match what is expected:
+unexpected header "X-Internal-Secret" with ["hunter2"]`
+	if got, want := testutil.CheckHTTPRequest(newReq(), want, testutil.StrictHeaders("date")), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+	if diff := testutil.CheckHTTPRequest(newReq(), want, testutil.StrictHeaders("Date", "X-Internal-Secret")); diff != "" {
+		t.Error(diff)
+	}
+}
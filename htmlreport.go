@@ -0,0 +1,122 @@
+package testutil
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RecordComparison records a failed comparison of got and want, for
+// example two JSON bodies, so it can be rendered side by side by
+// WriteHTML. Nothing is recorded if got and want are equal.
+func (r *Reporter) RecordComparison(test string, checker string, endpoint string, got string, want string) {
+	if got == want {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = append(r.failures, reportedDiff{
+		test:     test,
+		checker:  checker,
+		endpoint: endpoint,
+		diff:     CompareStrings(got, want),
+		got:      &got,
+		want:     &want,
+	})
+}
+
+// WriteHTML writes the recorded failures as a standalone HTML report
+// to dir/report.html, creating dir if needed, and returns the path of
+// the report. Comparisons recorded with RecordComparison are shown
+// side by side with differing lines highlighted which is easier to
+// review than terminal output when a large end-to-end run fails.
+func (r *Reporter) WriteHTML(dir string) (string, error) {
+	r.mu.Lock()
+	entries := []htmlEntry{}
+	for _, f := range r.failures {
+		e := htmlEntry{Test: orNone(f.test), Checker: orNone(f.checker), Endpoint: orNone(f.endpoint), Diff: f.diff}
+		if f.got != nil && f.want != nil {
+			e.Rows = sideBySide(*f.got, *f.want)
+		}
+		entries = append(entries, e)
+	}
+	r.mu.Unlock()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "report.html")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := htmlReportTemplate.Execute(f, entries); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+type htmlEntry struct {
+	Test     string
+	Checker  string
+	Endpoint string
+	Diff     string
+	Rows     []htmlRow
+}
+
+type htmlRow struct {
+	Line    int
+	Got     string
+	Want    string
+	Differs bool
+}
+
+// sideBySide pairs up the lines of got and want.
+func sideBySide(got string, want string) []htmlRow {
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	n := len(gotLines)
+	if len(wantLines) > n {
+		n = len(wantLines)
+	}
+	rows := make([]htmlRow, n)
+	for i := range rows {
+		rows[i].Line = i + 1
+		gotOK, wantOK := i < len(gotLines), i < len(wantLines)
+		if gotOK {
+			rows[i].Got = gotLines[i]
+		}
+		if wantOK {
+			rows[i].Want = wantLines[i]
+		}
+		rows[i].Differs = gotOK != wantOK || rows[i].Got != rows[i].Want
+	}
+	return rows
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Diff report</title>
+<style>
+body { font-family: sans-serif; }
+pre, td.code { font-family: monospace; white-space: pre-wrap; }
+table { border-collapse: collapse; width: 100%; }
+td, th { border: 1px solid #ddd; padding: 2px 6px; vertical-align: top; }
+tr.differs td.code { background: #fdd; }
+</style>
+</head>
+<body>
+<h1>{{len .}} failures</h1>
+{{range .}}<section>
+<h2>{{.Test}} [{{.Checker}}] {{.Endpoint}}</h2>
+{{if .Rows}}<table>
+<tr><th>line</th><th>got</th><th>want</th></tr>
+{{range .Rows}}<tr{{if .Differs}} class="differs"{{end}}><td>{{.Line}}</td><td class="code">{{.Got}}</td><td class="code">{{.Want}}</td></tr>
+{{end}}</table>
+{{else}}<pre>{{.Diff}}</pre>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
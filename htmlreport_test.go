@@ -0,0 +1,42 @@
+package testutil_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestReporterWriteHTML tests that the HTML report shows comparisons
+// side by side with the differing lines highlighted.
+func TestReporterWriteHTML(t *testing.T) {
+	r := testutil.NewReporter()
+	r.RecordComparison("TestUsers", "CompareJSON", "GET /users", "{\n  \"name\": \"bob\"\n}", "{\n  \"name\": \"alice\"\n}")
+	r.RecordComparison("TestUsers", "CompareJSON", "GET /users", "same", "same")
+	r.Record("TestOrders", "CheckHTTPResponse", "POST /orders", "got status code <500>, want 200")
+	dir := filepath.Join(t.TempDir(), "artifacts")
+	path, err := r.WriteHTML(dir)
+	if err != nil {
+		t.Fatalf("could not write report: %v", err)
+	}
+	if got, want := path, filepath.Join(dir, "report.html"); got != want {
+		t.Errorf("got path %q, want %q", got, want)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<h1>2 failures</h1>",
+		"<h2>TestUsers [CompareJSON] GET /users</h2>",
+		`<tr><td>1</td><td class="code">{</td><td class="code">{</td></tr>`,
+		`<tr class="differs"><td>2</td><td class="code">  &#34;name&#34;: &#34;bob&#34;</td><td class="code">  &#34;name&#34;: &#34;alice&#34;</td></tr>`,
+		"<pre>got status code &lt;500&gt;, want 200</pre>",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("report does not contain %q:\n%s", want, b)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Register the JPEG decoder.
+	"image/png"
+	"os"
+)
+
+// PixelTolerance makes CompareImages treat pixels as equal if none of
+// their color channels differ by more than tolerance (out of 255).
+// Useful for lossy formats like JPEG.
+func PixelTolerance(tolerance uint8) Option {
+	return func(o *options) {
+		o.pixelTolerance = tolerance
+	}
+}
+
+// WriteDiffImage makes CompareImages write a PNG to path when the
+// images differ. Differing pixels are drawn in red on top of a faded
+// copy of the want image.
+func WriteDiffImage(path string) Option {
+	return func(o *options) {
+		o.diffImagePath = path
+	}
+}
+
+// CompareImages decodes two PNG or JPEG images and returns a string
+// detailing how they differ or "" if they don't. It is meant for
+// golden file tests of code which renders charts, badges and the like.
+func CompareImages(got []byte, want []byte, opts ...Option) string {
+	o := newOptions(opts)
+	gotImg, _, err := image.Decode(bytes.NewReader(got))
+	if err != nil {
+		return fmt.Sprintf("could not decode got image: %v", err)
+	}
+	wantImg, _, err := image.Decode(bytes.NewReader(want))
+	if err != nil {
+		return fmt.Sprintf("could not decode want image: %v", err)
+	}
+	gb, wb := gotImg.Bounds(), wantImg.Bounds()
+	if gb.Dx() != wb.Dx() || gb.Dy() != wb.Dy() {
+		return fmt.Sprintf("got image of size %dx%d, want %dx%d", gb.Dx(), gb.Dy(), wb.Dx(), wb.Dy())
+	}
+	diffImg := image.NewRGBA(image.Rect(0, 0, wb.Dx(), wb.Dy()))
+	region := image.Rectangle{}
+	differing := 0
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			g := gotImg.At(gb.Min.X+x, gb.Min.Y+y)
+			w := wantImg.At(wb.Min.X+x, wb.Min.Y+y)
+			if pixelsEqual(g, w, o.pixelTolerance) {
+				gray := color.GrayModel.Convert(w).(color.Gray)
+				faded := 128 + gray.Y/2
+				diffImg.Set(x, y, color.RGBA{faded, faded, faded, 255})
+				continue
+			}
+			diffImg.Set(x, y, color.RGBA{255, 0, 0, 255})
+			differing++
+			region = region.Union(image.Rect(x, y, x+1, y+1))
+		}
+	}
+	if differing == 0 {
+		return ""
+	}
+	diff := fmt.Sprintf("%d of %d pixels differ within the region (%d,%d)-(%d,%d)", differing, wb.Dx()*wb.Dy(), region.Min.X, region.Min.Y, region.Max.X-1, region.Max.Y-1)
+	if o.diffImagePath != "" {
+		if err := writePNG(o.diffImagePath, diffImg); err != nil {
+			diff += fmt.Sprintf(", could not write diff image: %v", err)
+		} else {
+			diff += ", diff image written to " + o.diffImagePath
+		}
+	}
+	return diff
+}
+
+func pixelsEqual(got color.Color, want color.Color, tolerance uint8) bool {
+	gr, gg, gb, ga := got.RGBA()
+	wr, wg, wb, wa := want.RGBA()
+	t := uint32(tolerance) * 0x101
+	within := func(a, b uint32) bool {
+		if a > b {
+			return a-b <= t
+		}
+		return b-a <= t
+	}
+	return within(gr, wr) && within(gg, wg) && within(gb, wb) && within(ga, wa)
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
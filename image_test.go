@@ -0,0 +1,108 @@
+package testutil_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+func makePNG(width int, height int, paint func(img *image.RGBA)) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	if paint != nil {
+		paint(img)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// TestCompareImages tests that the expected diff is generated when
+// comparing images.
+func TestCompareImages(t *testing.T) {
+	diffPath := filepath.Join(t.TempDir(), "diff.png")
+	tests := []struct {
+		name     string
+		got      []byte
+		want     []byte
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "equal",
+			got:      makePNG(4, 4, nil),
+			want:     makePNG(4, 4, nil),
+			wantDiff: "",
+		},
+		{
+			name:     "different sizes",
+			got:      makePNG(4, 3, nil),
+			want:     makePNG(4, 4, nil),
+			wantDiff: "got image of size 4x3, want 4x4",
+		},
+		{
+			name: "pixels differ",
+			got: makePNG(4, 4, func(img *image.RGBA) {
+				img.Set(1, 1, color.Black)
+				img.Set(2, 3, color.Black)
+			}),
+			want:     makePNG(4, 4, nil),
+			wantDiff: "2 of 16 pixels differ within the region (1,1)-(2,3)",
+		},
+		{
+			name: "pixels within tolerance",
+			got: makePNG(4, 4, func(img *image.RGBA) {
+				img.Set(1, 1, color.RGBA{250, 250, 250, 255})
+			}),
+			want:     makePNG(4, 4, nil),
+			opts:     []testutil.Option{testutil.PixelTolerance(5)},
+			wantDiff: "",
+		},
+		{
+			name: "diff image written",
+			got: makePNG(4, 4, func(img *image.RGBA) {
+				img.Set(0, 0, color.Black)
+			}),
+			want:     makePNG(4, 4, nil),
+			opts:     []testutil.Option{testutil.WriteDiffImage(diffPath)},
+			wantDiff: "1 of 16 pixels differ within the region (0,0)-(0,0), diff image written to " + diffPath,
+		},
+		{
+			name:     "not an image",
+			got:      []byte("hello"),
+			want:     makePNG(4, 4, nil),
+			wantDiff: "could not decode got image: image: unknown format",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareImages(test.got, test.want, test.opts...), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+	f, err := os.Open(diffPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	diffImg, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, _, _, _ := diffImg.At(0, 0).RGBA(); r != 0xffff {
+		t.Errorf("differing pixel was not drawn in red")
+	}
+}
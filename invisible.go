@@ -0,0 +1,56 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// invisibleLegend explains how visualizeInvisible renders characters.
+const invisibleLegend = `space "·", tab "⇥", newline "↵", other characters as escapes like \r or \u00a0`
+
+// onlyInvisibleDiffers reports whether two strings are the same once
+// whitespace and other invisible characters are removed. That is when
+// a diff is confusing since both strings look identical.
+func onlyInvisibleDiffers(got string, want string) bool {
+	return stripInvisible(got) == stripInvisible(want)
+}
+
+func stripInvisible(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isInvisible(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func isInvisible(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r) || unicode.Is(unicode.Cf, r)
+}
+
+// visualizeInvisible renders whitespace and other invisible
+// characters so they can be seen.
+func visualizeInvisible(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == utf8.RuneError && !strings.HasPrefix(s[i:], string(utf8.RuneError)):
+			fmt.Fprintf(&b, `\x%02x`, s[i])
+		case r == ' ':
+			b.WriteString("·")
+		case r == '\t':
+			b.WriteString("⇥")
+		case r == '\n':
+			b.WriteString("↵\n")
+		case r == '\r':
+			b.WriteString(`\r`)
+		case isInvisible(r):
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
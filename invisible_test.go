@@ -0,0 +1,75 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareStringsInvisible tests that invisible characters are
+// shown when they are the only difference between two strings.
+func TestCompareStringsInvisible(t *testing.T) {
+	legend := "\n(the strings only differ in invisible characters which are shown as: space \"·\", tab \"⇥\", newline \"↵\", other characters as escapes like \\r or \\u00a0)"
+	tests := []struct {
+		name     string
+		gotStr   string
+		wantStr  string
+		wantDiff string
+	}{
+		{
+			name:    "tabs vs spaces",
+			gotStr:  "a\tb",
+			wantStr: "a  b",
+			wantDiff: `strings differ at index 1, from that index on:
+##### got string #####
+⇥b
+##### want string #####
+··b` + legend,
+		},
+		{
+			name:    "carriage returns",
+			gotStr:  "a\r\nb",
+			wantStr: "a\nb",
+			wantDiff: `strings differ at index 1, from that index on:
+##### got string #####
+\r↵
+b
+##### want string #####
+↵
+b` + legend,
+		},
+		{
+			name:     "trailing space",
+			gotStr:   "hello ",
+			wantStr:  "hello",
+			wantDiff: "got a longer string than what we wanted (characters match otherwise) and the extra characters are: ·" + legend,
+		},
+		{
+			name:    "non-breaking and zero width spaces",
+			gotStr:  "a\u00a0b\u200b",
+			wantStr: "a b",
+			wantDiff: `strings differ at index 1, from that index on:
+##### got string #####
+\u00a0b\u200b
+##### want string #####
+·b` + legend,
+		},
+		{
+			name:    "visible difference",
+			gotStr:  "a b",
+			wantStr: "a c",
+			wantDiff: `strings differ at index 2, from that index on:
+##### got string #####
+b
+##### want string #####
+c`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareStrings(test.gotStr, test.wantStr), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// CompareJSON parses two JSON documents and returns a string detailing
+// the paths where they differ, like "$.items[0].id", or "" if they
+// don't. Whitespace and key order don't matter and numbers are
+// compared by value so 1, 1.0 and 1e0 are equal, even for integers too
+// large for a float64. Pass NumericTolerance to treat numbers which
+// are close enough as equal.
+func CompareJSON(got string, want string, opts ...Option) string {
+	o := newOptions(opts)
+	gotDoc, err := decodeJSON(got)
+	if err != nil {
+		return fmt.Sprintf("could not parse got JSON: %v", err)
+	}
+	wantDoc, err := decodeJSON(want)
+	if err != nil {
+		return fmt.Sprintf("could not parse want JSON: %v", err)
+	}
+	if diffs := compareJSONValues("$", gotDoc, wantDoc, o); len(diffs) > 0 {
+		return "JSON does not match:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+// CanonicalJSON reformats a JSON document so that documents which
+// CompareJSON considers equal, without a tolerance, are byte for byte
+// identical: keys are sorted, insignificant whitespace is removed and
+// numbers are written in a canonical form.
+func CanonicalJSON(s string) (string, error) {
+	doc, err := decodeJSON(s)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(canonicalizeJSON(doc))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func decodeJSON(s string) (interface{}, error) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON document")
+	}
+	return doc, nil
+}
+
+func canonicalizeJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = canonicalizeJSON(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = canonicalizeJSON(e)
+		}
+	case json.Number:
+		return json.Number(canonicalNumber(v))
+	}
+	return v
+}
+
+// canonicalNumber writes integers in full and everything else in the
+// shortest form which round trips through a float64.
+func canonicalNumber(n json.Number) string {
+	if r, ok := new(big.Rat).SetString(string(n)); ok && r.IsInt() {
+		return r.Num().String()
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return string(n)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func numbersEqual(got json.Number, want json.Number, o options) bool {
+	if o.numericTolerance > 0 {
+		g, gErr := got.Float64()
+		w, wErr := want.Float64()
+		if gErr == nil && wErr == nil {
+			return math.Abs(g-w) <= o.numericTolerance
+		}
+	}
+	g, gOK := new(big.Rat).SetString(string(got))
+	w, wOK := new(big.Rat).SetString(string(want))
+	if !gOK || !wOK {
+		return got == want
+	}
+	return g.Cmp(w) == 0
+}
+
+func compareJSONValues(path string, got interface{}, want interface{}, o options) []string {
+	switch want := want.(type) {
+	case map[string]interface{}:
+		gotObj, ok := got.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: got %s, want an object", path, compactJSON(got))}
+		}
+		diffs := []string{}
+		for _, k := range sortedKeys(want) {
+			if _, ok := gotObj[k]; !ok {
+				diffs = append(diffs, fmt.Sprintf("%s: missing key %q", path, k))
+			}
+		}
+		for _, k := range sortedKeys(gotObj) {
+			if _, ok := want[k]; !ok {
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected key %q", path, k))
+			}
+		}
+		for _, k := range sortedKeys(want) {
+			if g, ok := gotObj[k]; ok {
+				diffs = append(diffs, compareJSONValues(path+"."+k, g, want[k], o)...)
+			}
+		}
+		return diffs
+	case []interface{}:
+		gotArr, ok := got.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: got %s, want an array", path, compactJSON(got))}
+		}
+		diffs := []string{}
+		if len(gotArr) != len(want) {
+			diffs = append(diffs, fmt.Sprintf("%s: got %d elements, want %d", path, len(gotArr), len(want)))
+		}
+		for i := 0; i < len(gotArr) && i < len(want); i++ {
+			diffs = append(diffs, compareJSONValues(fmt.Sprintf("%s[%d]", path, i), gotArr[i], want[i], o)...)
+		}
+		return diffs
+	case json.Number:
+		if g, ok := got.(json.Number); ok && numbersEqual(g, want, o) {
+			return nil
+		}
+	case string:
+		if o.placeholders {
+			// Placeholders can stand for any value, a {{NUMBER}}
+			// matches 42 as well as "42".
+			g, ok := got.(string)
+			if !ok {
+				g = compactJSON(got)
+			}
+			if CompareWithPlaceholders(g, want) == "" {
+				return nil
+			}
+		}
+		if compactJSON(got) == compactJSON(want) {
+			return nil
+		}
+	default:
+		if compactJSON(got) == compactJSON(want) {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("%s: got %s, want %s", path, compactJSON(got), compactJSON(want))}
+}
+
+func compactJSON(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
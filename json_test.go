@@ -0,0 +1,70 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareJSON tests that the expected diff is generated when
+// comparing JSON documents.
+func TestCompareJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		want     string
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "equal apart from whitespace, key order and number formatting",
+			got:      `{"b": [1.0, 2e0, 3.50], "a": {"y": null, "x": "<tag>"}, "big": 12345678901234567890}`,
+			want:     `{"a":{"x":"<tag>","y":null},"b":[1,2,3.5],"big":1.2345678901234567890e19}`,
+			wantDiff: "",
+		},
+		{
+			name: "differ",
+			got:  `{"id": 12345678901234567891, "items": [{"sku": "a", "qty": 1}], "extra": true, "total": "9.5"}`,
+			want: `{"id": 12345678901234567890, "items": [{"sku": "b", "qty": 1}, {"sku": "c"}], "total": 9.5, "note": "x"}`,
+			wantDiff: `JSON does not match:
+$: missing key "note"
+$: unexpected key "extra"
+$.id: got 12345678901234567891, want 12345678901234567890
+$.items: got 1 elements, want 2
+$.items[0].sku: got "a", want "b"
+$.total: got "9.5", want 9.5`,
+		},
+		{
+			name:     "numeric tolerance",
+			got:      `{"price": 9.99}`,
+			want:     `{"price": 10}`,
+			opts:     []testutil.Option{testutil.NumericTolerance(0.05)},
+			wantDiff: "",
+		},
+		{
+			name:     "invalid",
+			got:      `{"a": 1} {}`,
+			want:     `{}`,
+			wantDiff: "could not parse got JSON: unexpected data after the JSON document",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareJSON(test.got, test.want, test.opts...), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCanonicalJSON tests that equal documents have the same canonical
+// form.
+func TestCanonicalJSON(t *testing.T) {
+	got, err := testutil.CanonicalJSON(`{ "b": [1.0, 2.50e1], "a": 1e0, "c": 0.1 }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":1,"b":[1,25],"c":0.1}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
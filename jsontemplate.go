@@ -0,0 +1,139 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MatchJSONTemplate checks that the JSON document got has the same
+// structure as the JSON template and returns the values captured by
+// the template along with a diff. String values in the template of
+// the form "{{name}}" match any value and capture it under name, for
+// example matching this template:
+//
+//	{"order_id": "{{id}}", "status": "pending"}
+//
+// would capture the order id so later requests in the same test can
+// refer to it. Captured values which are not JSON strings are
+// returned in their JSON encoded form. Placeholders understood by
+// CompareWithPlaceholders, like "{{UUID}}", match without capturing.
+func MatchJSONTemplate(got string, template string) (map[string]string, string) {
+	var gotDoc, tmplDoc interface{}
+	if err := json.Unmarshal([]byte(template), &tmplDoc); err != nil {
+		return nil, fmt.Sprintf("could not parse JSON template: %v", err)
+	}
+	if err := json.Unmarshal([]byte(got), &gotDoc); err != nil {
+		return nil, fmt.Sprintf("could not parse got JSON: %v", err)
+	}
+	m := templateMatch{vars: map[string]string{}}
+	m.match("$", gotDoc, tmplDoc)
+	if len(m.diffs) > 0 {
+		return m.vars, "JSON does not match template:\n" + strings.Join(m.diffs, "\n")
+	}
+	return m.vars, ""
+}
+
+// JSONTemplate returns a Matcher which checks values with
+// MatchJSONTemplate and stores any captured values in vars.
+func JSONTemplate(template string, vars map[string]string) Matcher {
+	return MatcherFunc(func(got interface{}) string {
+		captured, diff := MatchJSONTemplate(string(toBytes(got)), template)
+		for k, v := range captured {
+			vars[k] = v
+		}
+		return diff
+	})
+}
+
+type templateMatch struct {
+	vars  map[string]string
+	diffs []string
+}
+
+func (m *templateMatch) match(path string, got interface{}, tmpl interface{}) {
+	switch tmpl := tmpl.(type) {
+	case map[string]interface{}:
+		gotObj, ok := got.(map[string]interface{})
+		if !ok {
+			m.diffs = append(m.diffs, fmt.Sprintf("%s: got %s, want an object", path, jsonString(got)))
+			return
+		}
+		for _, k := range sortedKeys(tmpl) {
+			v, ok := gotObj[k]
+			if !ok {
+				m.diffs = append(m.diffs, fmt.Sprintf("%s: missing key %q", path, k))
+				continue
+			}
+			m.match(path+"."+k, v, tmpl[k])
+		}
+		for _, k := range sortedKeys(gotObj) {
+			if _, ok := tmpl[k]; !ok {
+				m.diffs = append(m.diffs, fmt.Sprintf("%s: unexpected key %q", path, k))
+			}
+		}
+	case []interface{}:
+		gotArr, ok := got.([]interface{})
+		if !ok {
+			m.diffs = append(m.diffs, fmt.Sprintf("%s: got %s, want an array", path, jsonString(got)))
+			return
+		}
+		if len(gotArr) != len(tmpl) {
+			m.diffs = append(m.diffs, fmt.Sprintf("%s: got %d elements, want %d", path, len(gotArr), len(tmpl)))
+			return
+		}
+		for i := range tmpl {
+			m.match(fmt.Sprintf("%s[%d]", path, i), gotArr[i], tmpl[i])
+		}
+	case string:
+		if sub := placeholderRE.FindStringSubmatch(tmpl); sub != nil && sub[0] == tmpl {
+			if _, ok := placeholders[sub[1]]; !ok {
+				m.capture(path, sub[1], got)
+				return
+			}
+		}
+		// Like CompareJSON with UsePlaceholders, a placeholder can
+		// stand for any value so {{NUMBER}} matches 42 as well as "42".
+		gotStr, ok := got.(string)
+		if !ok {
+			gotStr = jsonString(got)
+		}
+		if diff := CompareWithPlaceholders(gotStr, tmpl); diff != "" {
+			m.diffs = append(m.diffs, fmt.Sprintf("%s: got %s, want %s", path, jsonString(got), jsonString(tmpl)))
+		}
+	default:
+		if jsonString(got) != jsonString(tmpl) {
+			m.diffs = append(m.diffs, fmt.Sprintf("%s: got %s, want %s", path, jsonString(got), jsonString(tmpl)))
+		}
+	}
+}
+
+func (m *templateMatch) capture(path string, name string, got interface{}) {
+	v, ok := got.(string)
+	if !ok {
+		v = jsonString(got)
+	}
+	if prev, ok := m.vars[name]; ok && prev != v {
+		m.diffs = append(m.diffs, fmt.Sprintf("%s: got %q for {{%s}} but it was already captured as %q", path, v, name, prev))
+		return
+	}
+	m.vars[name] = v
+}
+
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
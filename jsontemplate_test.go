@@ -0,0 +1,81 @@
+package testutil_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestMatchJSONTemplate tests that JSON templates capture the
+// expected values and generate the expected diff.
+func TestMatchJSONTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		template string
+		wantVars map[string]string
+		wantDiff string
+	}{
+		{
+			name:     "captures values",
+			got:      `{"order_id": "abc-123", "total": 42.5, "status": "pending", "items": [{"sku": "x1"}]}`,
+			template: `{"order_id": "{{id}}", "total": "{{total}}", "status": "pending", "items": [{"sku": "{{sku}}"}]}`,
+			wantVars: map[string]string{"id": "abc-123", "total": "42.5", "sku": "x1"},
+			wantDiff: "",
+		},
+		{
+			name:     "placeholders do not capture",
+			got:      `{"id": "0b4d4c2e-0f4e-4c79-9a4a-7e1f5c1d2a3b"}`,
+			template: `{"id": "{{UUID}}"}`,
+			wantVars: map[string]string{},
+			wantDiff: "",
+		},
+		{
+			name:     "placeholders match values which are not strings",
+			got:      `{"id": 5, "meta": {"a": [1, 2]}, "ok": true}`,
+			template: `{"id": "{{NUMBER}}", "meta": "{{ANY}}", "ok": "{{ANY}}"}`,
+			wantVars: map[string]string{},
+			wantDiff: "",
+		},
+		{
+			name:     "placeholders which don't match values which are not strings",
+			got:      `{"id": true, "name": 7}`,
+			template: `{"id": "{{NUMBER}}", "name": "bob"}`,
+			wantVars: map[string]string{},
+			wantDiff: `JSON does not match template:
+$.id: got true, want "{{NUMBER}}"
+$.name: got 7, want "bob"`,
+		},
+		{
+			name:     "structure differs",
+			got:      `{"order_id": "abc-123", "status": "shipped", "extra": true, "items": []}`,
+			template: `{"order_id": "{{id}}", "status": "pending", "total": 1, "items": [{"sku": "{{sku}}"}]}`,
+			wantVars: map[string]string{"id": "abc-123"},
+			wantDiff: `JSON does not match template:
+$.items: got 0 elements, want 1
+$.status: got "shipped", want "pending"
+$: missing key "total"
+$: unexpected key "extra"`,
+		},
+		{
+			name:     "same variable captured inconsistently",
+			got:      `{"a": "1", "b": "2"}`,
+			template: `{"a": "{{id}}", "b": "{{id}}"}`,
+			wantVars: map[string]string{"id": "1"},
+			wantDiff: `JSON does not match template:
+$.b: got "2" for {{id}} but it was already captured as "1"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vars, diff := testutil.MatchJSONTemplate(test.got, test.template)
+			if got, want := diff, test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+			if got, want := vars, test.wantVars; !reflect.DeepEqual(got, want) {
+				t.Errorf("got captured values %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lineContext is the number of lines shown either side of the first
+// differing line by ByLine.
+const lineContext = 2
+
+// ByLine makes CompareStrings report the first line at which the
+// strings differ along with a few lines of surrounding context instead
+// of a byte index. It is easier to use when comparing long multi-line
+// strings like HTTP bodies or rendered templates.
+func ByLine() Option {
+	return func(o *options) {
+		o.byLine = true
+	}
+}
+
+func compareByLine(got string, want string, p palette) string {
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(want, "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		if i < len(gotLines) && i < len(wantLines) && gotLines[i] == wantLines[i] {
+			continue
+		}
+		return fmt.Sprintf("strings differ at line %d:\n##### got lines #####\n%s\n##### want lines #####\n%s", i+1, lineWindow(gotLines, i, p.removed), lineWindow(wantLines, i, p.added))
+	}
+	return ""
+}
+
+// lineWindow shows the lines around line i, marking line i with ">"
+// and styling it with style.
+func lineWindow(lines []string, i int, style func(string) string) string {
+	start, end := i-lineContext, i+lineContext+1
+	if start < 0 {
+		start = 0
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	width := len(fmt.Sprint(i + lineContext + 1))
+	out := []string{}
+	for j := start; j < end; j++ {
+		marker := " "
+		if j == i {
+			marker = ">"
+		}
+		line := fmt.Sprintf("%s %*d | %s", marker, width, j+1, lines[j])
+		if j == i {
+			line = style(line)
+		}
+		out = append(out, line)
+	}
+	if i >= len(lines) {
+		out = append(out, style(fmt.Sprintf("> %*d | (end of string)", width, i+1)))
+	}
+	return strings.Join(out, "\n")
+}
+
+// CompareLinesUnordered treats got and want as multisets of lines and
+// returns a string listing the lines which are missing from got and
+// the ones which are unexpected, or "" if both have the same lines the
+// same number of times. Missing lines which look like a typo of an
+// unexpected line get a "did you mean" suggestion. It is useful for
+// output whose order isn't guaranteed like logs from concurrent code.
+func CompareLinesUnordered(got string, want string) string {
+	counts := map[string]int{}
+	for _, l := range strings.Split(want, "\n") {
+		counts[l]++
+	}
+	unexpected := []string{}
+	for _, l := range strings.Split(got, "\n") {
+		if counts[l] == 0 {
+			unexpected = append(unexpected, l)
+			continue
+		}
+		counts[l]--
+	}
+	diffs := []string{}
+	for _, l := range strings.Split(want, "\n") {
+		if counts[l] > 0 {
+			diff := fmt.Sprintf("missing line %q", l)
+			if i := closestMatch(l, unexpected); i >= 0 {
+				diff += fmt.Sprintf(", did you mean %q?", unexpected[i])
+			}
+			diffs = append(diffs, diff)
+			counts[l]--
+		}
+	}
+	for _, l := range unexpected {
+		diffs = append(diffs, fmt.Sprintf("unexpected line %q", l))
+	}
+	if len(diffs) > 0 {
+		return "lines differ ignoring order:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareStringsByLine tests that the expected line based diff is
+// generated.
+func TestCompareStringsByLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		gotStr   string
+		wantStr  string
+		wantDiff string
+	}{
+		{
+			name:     "equal",
+			gotStr:   "a\nb\nc",
+			wantStr:  "a\nb\nc",
+			wantDiff: "",
+		},
+		{
+			name:    "line differs",
+			gotStr:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10",
+			wantStr: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10",
+			wantDiff: `strings differ at line 5:
+##### got lines #####
+  3 | 3
+  4 | 4
+> 5 | five
+  6 | 6
+  7 | 7
+##### want lines #####
+  3 | 3
+  4 | 4
+> 5 | 5
+  6 | 6
+  7 | 7`,
+		},
+		{
+			name:    "got has fewer lines",
+			gotStr:  "a\nb",
+			wantStr: "a\nb\nc",
+			wantDiff: `strings differ at line 3:
+##### got lines #####
+  1 | a
+  2 | b
+> 3 | (end of string)
+##### want lines #####
+  1 | a
+  2 | b
+> 3 | c`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareStrings(test.gotStr, test.wantStr, testutil.ByLine()), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCompareLinesUnordered tests that lines are compared as a
+// multiset.
+func TestCompareLinesUnordered(t *testing.T) {
+	tests := []struct {
+		name     string
+		gotStr   string
+		wantStr  string
+		wantDiff string
+	}{
+		{
+			name:     "same lines in a different order",
+			gotStr:   "worker 2 done\nworker 1 done\nworker 1 done",
+			wantStr:  "worker 1 done\nworker 1 done\nworker 2 done",
+			wantDiff: "",
+		},
+		{
+			name:    "missing and unexpected lines",
+			gotStr:  "GET /b\nGET /a\nGET /x",
+			wantStr: "GET /a\nGET /b\nGET /b\nGET /c",
+			wantDiff: `lines differ ignoring order:
+missing line "GET /b", did you mean "GET /x"?
+missing line "GET /c", did you mean "GET /x"?
+unexpected line "GET /x"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareLinesUnordered(test.gotStr, test.wantStr), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matcher is a flexible expectation which can be used in place of a
+// literal value. Match returns "" if got is acceptable, otherwise it
+// returns a message explaining why it is not.
+type Matcher interface {
+	Match(got interface{}) string
+}
+
+// MatcherFunc lets an ordinary function be used as a Matcher.
+type MatcherFunc func(got interface{}) string
+
+// Match calls f(got).
+func (f MatcherFunc) Match(got interface{}) string {
+	return f(got)
+}
+
+// Any returns a Matcher which matches anything.
+func Any() Matcher {
+	return MatcherFunc(func(got interface{}) string {
+		return ""
+	})
+}
+
+// Equals returns a Matcher which matches values equal to want. Values
+// are considered equal if their string forms are equal so Equals(200)
+// matches a status code of 200 and Equals("ok") a body of "ok".
+func Equals(want interface{}) Matcher {
+	return MatcherFunc(func(got interface{}) string {
+		if g, w := string(toBytes(got)), string(toBytes(want)); g != w {
+			return fmt.Sprintf("%q does not equal %q", g, w)
+		}
+		return ""
+	})
+}
+
+// Contains returns a Matcher which matches values whose string form
+// contains sub.
+func Contains(sub string) Matcher {
+	return MatcherFunc(func(got interface{}) string {
+		if s := string(toBytes(got)); !strings.Contains(s, sub) {
+			return fmt.Sprintf("%q does not contain %q", s, sub)
+		}
+		return ""
+	})
+}
+
+// HasPrefix returns a Matcher which matches values whose string form
+// starts with prefix. As a URLMatcher it copes with URLs which end in
+// something volatile like a pagination cursor.
+func HasPrefix(prefix string) Matcher {
+	return MatcherFunc(func(got interface{}) string {
+		if s := string(toBytes(got)); !strings.HasPrefix(s, prefix) {
+			return fmt.Sprintf("%q does not start with %q", s, prefix)
+		}
+		return ""
+	})
+}
+
+// AnyOf returns a Matcher which matches values matched by at least
+// one of matchers.
+func AnyOf(matchers ...Matcher) Matcher {
+	return MatcherFunc(func(got interface{}) string {
+		msgs := []string{}
+		for _, m := range matchers {
+			msg := m.Match(got)
+			if msg == "" {
+				return ""
+			}
+			msgs = append(msgs, msg)
+		}
+		return fmt.Sprintf("none of the matchers matched: %s", strings.Join(msgs, "; "))
+	})
+}
+
+// Regexp returns a Matcher which matches values whose string form
+// matches the regular expression pattern. It panic's if the pattern
+// cannot be compiled.
+func Regexp(pattern string) Matcher {
+	re := regexp.MustCompile(pattern)
+	return MatcherFunc(func(got interface{}) string {
+		if s := string(toBytes(got)); !re.MatchString(s) {
+			return fmt.Sprintf("%q does not match regexp %q", s, pattern)
+		}
+		return ""
+	})
+}
+
+// OneOf returns a Matcher which matches values equal to any of wants.
+// Values are considered equal if their string forms are equal so, for
+// example, OneOf(200, 201) can be used for a status code and
+// OneOf("gzip", "br") for a header.
+func OneOf(wants ...interface{}) Matcher {
+	return MatcherFunc(func(got interface{}) string {
+		s := string(toBytes(got))
+		for _, want := range wants {
+			if s == string(toBytes(want)) {
+				return ""
+			}
+		}
+		return fmt.Sprintf("%q is not one of %v", s, wants)
+	})
+}
+
+// JSONPath returns a Matcher which parses a JSON document, finds the
+// value at path and checks it with m. Paths look like
+// "$.items[0].id", the leading "$" is optional. Values found are the
+// ones produced by encoding/json i.e. strings, float64s, bools, nil,
+// []interface{} and map[string]interface{}.
+func JSONPath(path string, m Matcher) Matcher {
+	return MatcherFunc(func(got interface{}) string {
+		var doc interface{}
+		if err := json.Unmarshal(toBytes(got), &doc); err != nil {
+			return fmt.Sprintf("could not parse JSON to look up path %q: %v", path, err)
+		}
+		v, err := lookupJSONPath(doc, path)
+		if err != nil {
+			return fmt.Sprintf("JSON path %q: %v", path, err)
+		}
+		if msg := m.Match(v); msg != "" {
+			return fmt.Sprintf("JSON path %q: %s", path, msg)
+		}
+		return ""
+	})
+}
+
+// JSONField returns a Matcher which parses a JSON document and checks
+// that the value at path, see JSONPath, equals want once want is
+// encoded as JSON. Numbers are compared like CompareJSON does so
+// JSONField("count", 3) matches a count of 3.0. As a BodyMatcher it
+// keeps tests of large or partly nondeterministic bodies focused on
+// the fields they care about, as do Contains and HasPrefix.
+func JSONField(path string, want interface{}) Matcher {
+	return JSONPath(path, MatcherFunc(func(got interface{}) string {
+		g, w := compactJSON(got), compactJSON(want)
+		if CompareJSON(g, w) != "" {
+			return fmt.Sprintf("got %s, want %s", g, w)
+		}
+		return ""
+	}))
+}
+
+// matchField runs a matcher against a field and prefixes any failure
+// with the field's name.
+func matchField(name string, m Matcher, got interface{}) string {
+	if msg := m.Match(got); msg != "" {
+		return fmt.Sprintf("%s did not match: %s", name, msg)
+	}
+	return ""
+}
+
+// toBytes is the string form of a value matchers work with. Bytes,
+// including json.RawMessage, are used as they are rather than
+// formatted as a list of numbers.
+func toBytes(v interface{}) []byte {
+	switch v := v.(type) {
+	case []byte:
+		return v
+	case json.RawMessage:
+		return v
+	case string:
+		return []byte(v)
+	}
+	return []byte(fmt.Sprint(v))
+}
+
+func lookupJSONPath(doc interface{}, path string) (interface{}, error) {
+	p := strings.TrimPrefix(path, "$")
+	cur := doc
+	for p != "" {
+		switch {
+		case p[0] == '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+			key := p[:end]
+			p = p[end:]
+			obj, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot look up key %q in non-object %v", key, cur)
+			}
+			v, ok := obj[key]
+			if !ok {
+				return nil, fmt.Errorf("key %q not found", key)
+			}
+			cur = v
+		case p[0] == '[':
+			end := strings.IndexByte(p, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated index")
+			}
+			i, err := strconv.Atoi(p[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q", p[1:end])
+			}
+			p = p[end+1:]
+			arr, ok := cur.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot index non-array %v", cur)
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, fmt.Errorf("index %d out of range, array has %d elements", i, len(arr))
+			}
+			cur = arr[i]
+		default:
+			// Allow paths like "a.b" without a leading "$.".
+			p = "." + p
+		}
+	}
+	return cur, nil
+}
@@ -0,0 +1,202 @@
+package testutil_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestMatchers tests that the built in matchers explain why a value
+// did not match.
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		name     string
+		matcher  testutil.Matcher
+		got      interface{}
+		wantDiff string
+	}{
+		{
+			name:     "any",
+			matcher:  testutil.Any(),
+			got:      "whatever",
+			wantDiff: "",
+		},
+		{
+			name:     "regexp matches",
+			matcher:  testutil.Regexp(`^[0-9a-f-]{36}$`),
+			got:      "0b4d4c2e-0f4e-4c79-9a4a-7e1f5c1d2a3b",
+			wantDiff: "",
+		},
+		{
+			name:     "regexp does not match",
+			matcher:  testutil.Regexp(`^\d+$`),
+			got:      "abc",
+			wantDiff: `"abc" does not match regexp "^\\d+$"`,
+		},
+		{
+			name:     "regexp matches bytes",
+			matcher:  testutil.Regexp(`^\{"id": \d+\}$`),
+			got:      json.RawMessage(`{"id": 42}`),
+			wantDiff: "",
+		},
+		{
+			name:     "prefix matches",
+			matcher:  testutil.HasPrefix("http://127.0.0.1:54321/orders?cursor="),
+			got:      "http://127.0.0.1:54321/orders?cursor=eyJpZCI6NDJ9",
+			wantDiff: "",
+		},
+		{
+			name:     "prefix does not match",
+			matcher:  testutil.HasPrefix("/orders"),
+			got:      "/users",
+			wantDiff: `"/users" does not start with "/orders"`,
+		},
+		{
+			name:     "json field matches",
+			matcher:  testutil.JSONField("$.items[1]", map[string]interface{}{"id": 2, "tags": []string{"a"}}),
+			got:      `{"items": [{"id": 1}, {"tags": ["a"], "id": 2.0}]}`,
+			wantDiff: "",
+		},
+		{
+			name:     "json field does not match",
+			matcher:  testutil.JSONField("status", "done"),
+			got:      `{"status": "queued"}`,
+			wantDiff: `JSON path "status": got "queued", want "done"`,
+		},
+		{
+			name:     "one of matches",
+			matcher:  testutil.OneOf(200, 201),
+			got:      201,
+			wantDiff: "",
+		},
+		{
+			name:     "one of matches bytes",
+			matcher:  testutil.OneOf("gzip", "br"),
+			got:      []byte("br"),
+			wantDiff: "",
+		},
+		{
+			name:     "one of does not match",
+			matcher:  testutil.OneOf("gzip", "br"),
+			got:      "deflate",
+			wantDiff: `"deflate" is not one of [gzip br]`,
+		},
+		{
+			name:     "json path matches",
+			matcher:  testutil.JSONPath("$.items[1].id", testutil.OneOf(2)),
+			got:      `{"items": [{"id": 1}, {"id": 2}]}`,
+			wantDiff: "",
+		},
+		{
+			name:     "json path value does not match",
+			matcher:  testutil.JSONPath("items[0].id", testutil.OneOf(2)),
+			got:      `{"items": [{"id": 1}, {"id": 2}]}`,
+			wantDiff: `JSON path "items[0].id": "1" is not one of [2]`,
+		},
+		{
+			name:     "json path not found",
+			matcher:  testutil.JSONPath("$.items[2].id", testutil.Any()),
+			got:      `{"items": [{"id": 1}, {"id": 2}]}`,
+			wantDiff: `JSON path "$.items[2].id": index 2 out of range, array has 2 elements`,
+		},
+		{
+			name:     "equals matches",
+			matcher:  testutil.Equals(200),
+			got:      200,
+			wantDiff: "",
+		},
+		{
+			name:     "equals does not match",
+			matcher:  testutil.Equals("ok"),
+			got:      []byte("not ok"),
+			wantDiff: `"not ok" does not equal "ok"`,
+		},
+		{
+			name:     "contains matches",
+			matcher:  testutil.Contains(`"status": "done"`),
+			got:      `{"id": 1, "status": "done"}`,
+			wantDiff: "",
+		},
+		{
+			name:     "contains does not match",
+			matcher:  testutil.Contains("done"),
+			got:      "queued",
+			wantDiff: `"queued" does not contain "done"`,
+		},
+		{
+			name:     "any of matches",
+			matcher:  testutil.AnyOf(testutil.Equals("GET"), testutil.Regexp("^P")),
+			got:      "PUT",
+			wantDiff: "",
+		},
+		{
+			name:     "any of does not match",
+			matcher:  testutil.AnyOf(testutil.Equals("GET"), testutil.Contains("OST")),
+			got:      "DELETE",
+			wantDiff: `none of the matchers matched: "DELETE" does not equal "GET"; "DELETE" does not contain "OST"`,
+		},
+		{
+			name: "user defined matcher",
+			matcher: testutil.MatcherFunc(func(got interface{}) string {
+				if got.(int) < 500 {
+					return ""
+				}
+				return "server error"
+			}),
+			got:      503,
+			wantDiff: "server error",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := test.matcher.Match(test.got), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestHTTPMatchers tests that matchers can be used in place of the
+// literal fields of HTTPRequest and HTTPResponse.
+func TestHTTPMatchers(t *testing.T) {
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Scheme: "http", Host: "127.0.0.1:54321", Path: "/orders"},
+		Header: http.Header{"X-Request-Id": {"abc123"}},
+		Body:   ioutil.NopCloser(strings.NewReader(`{"id": 7}`)),
+	}
+	wantReq := testutil.HTTPRequest{
+		MethodMatcher:  testutil.AnyOf(testutil.Equals("PUT"), testutil.Equals("POST")),
+		URLMatcher:     testutil.Regexp(`/users$`),
+		HeaderMatchers: map[string]testutil.Matcher{"X-Request-Id": testutil.Regexp(`^\d+$`)},
+		BodyMatcher:    testutil.JSONPath("id", testutil.OneOf(7)),
+	}
+	wantDiff := `request does not match what is expected:
+header "X-Request-Id" did not match: "abc123" does not match regexp "^\\d+$"
+url did not match: "http://127.0.0.1:54321/orders" does not match regexp "/users$"`
+	if diff := testutil.CompareStrings(testutil.CheckHTTPRequest(req, wantReq), wantDiff); diff != "" {
+		t.Error(diff)
+	}
+
+	resp := &http.Response{
+		StatusCode: 202,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"status": "queued"}`)),
+	}
+	wantResp := testutil.HTTPResponse{
+		StatusCodeMatcher: testutil.OneOf(200, 201),
+		HeaderMatchers:    map[string]testutil.Matcher{"Content-Type": testutil.Regexp("^application/json")},
+		BodyMatcher:       testutil.JSONPath("status", testutil.OneOf("done")),
+	}
+	wantDiff = `response does not match what is expected:
+status code did not match: "202" is not one of [200 201]
+body did not match: JSON path "status": "queued" is not one of [done]`
+	if diff := testutil.CompareStrings(testutil.CheckHTTPResponse(resp, wantResp), wantDiff); diff != "" {
+		t.Error(diff)
+	}
+}
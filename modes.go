@@ -0,0 +1,54 @@
+package testutil
+
+import "net/http"
+
+// Partial makes CheckHTTPRequest and CheckHTTPResponse skip the
+// method, URL, body and status code when they are left empty in want
+// instead of requiring them to be empty, so an expectation only has
+// to mention what a test cares about.
+func Partial() Option {
+	return func(o *options) {
+		o.partial = true
+	}
+}
+
+// Strict makes CheckHTTPRequest and CheckHTTPResponse require every
+// field to match: empty fields in want are compared even if Partial is
+// passed too, and headers and trailers which were got but not
+// mentioned in want, either in Header or HeaderMatchers, are reported.
+// Use StrictHeaders to only report unexpected headers.
+func Strict() Option {
+	return func(o *options) {
+		o.strict = true
+		o.strictHeaders = true
+	}
+}
+
+// IgnoreHeaders makes CheckHTTPRequest and CheckHTTPResponse skip the
+// named headers, which is handy for volatile ones like Date or
+// X-Request-Id, without having to scrub them first.
+func IgnoreHeaders(names ...string) Option {
+	return func(o *options) {
+		if o.ignoredHeaders == nil {
+			o.ignoredHeaders = map[string]bool{}
+		}
+		for _, name := range names {
+			o.ignoredHeaders[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+}
+
+// IgnoreBody makes CheckHTTPRequest and CheckHTTPResponse skip the
+// body.
+func IgnoreBody() Option {
+	return func(o *options) {
+		o.ignoreBody = true
+	}
+}
+
+// IgnoreURLQuery makes URL comparisons ignore the query.
+func IgnoreURLQuery() Option {
+	return func(o *options) {
+		o.ignoreURLQuery = true
+	}
+}
@@ -0,0 +1,99 @@
+package testutil_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestPartialAndStrict tests that Partial skips empty fields and
+// Strict requires every field to match.
+func TestPartialAndStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantResp testutil.HTTPResponse
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "empty fields are compared by default",
+			wantResp: testutil.HTTPResponse{Header: http.Header{"Content-Type": {"text/plain"}}},
+			wantDiff: `response does not match what is expected:
+got status code 200, want 0
+body is not expected, got a longer string than what we wanted (characters match otherwise) and the extra characters are: hello`,
+		},
+		{
+			name:     "partial skips empty fields",
+			wantResp: testutil.HTTPResponse{Header: http.Header{"Content-Type": {"text/plain"}}},
+			opts:     []testutil.Option{testutil.Partial()},
+			wantDiff: "",
+		},
+		{
+			name:     "strict reports unexpected headers and trailers",
+			wantResp: testutil.HTTPResponse{StatusCode: 200, Header: http.Header{"content-type": {"text/plain"}}, Body: "hello"},
+			opts:     []testutil.Option{testutil.Strict()},
+			wantDiff: `response does not match what is expected:
+unexpected header "X-Debug" with ["a" "b"]
+unexpected header "X-Internal-Host" with ["db-1"]
+unexpected trailer "X-Checksum" with ["abc"]`,
+		},
+		{
+			name:     "strict compares empty fields even when partial",
+			wantResp: testutil.HTTPResponse{Header: http.Header{"Content-Type": {"text/plain"}, "X-Internal-Host": {"db-1"}, "X-Debug": {"a", "b"}}},
+			opts:     []testutil.Option{testutil.Strict(), testutil.Partial()},
+			wantDiff: `response does not match what is expected:
+got status code 200, want 0
+body is not expected, got a longer string than what we wanted (characters match otherwise) and the extra characters are: hello
+unexpected trailer "X-Checksum" with ["abc"]`,
+		},
+		{
+			name:     "strict headers only reports unexpected headers",
+			wantResp: testutil.HTTPResponse{Header: http.Header{"Content-Type": {"text/plain"}}},
+			opts:     []testutil.Option{testutil.StrictHeaders("X-Debug"), testutil.Partial()},
+			wantDiff: `response does not match what is expected:
+unexpected header "X-Internal-Host" with ["db-1"]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotResp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {"text/plain"}, "X-Internal-Host": {"db-1"}, "X-Debug": {"a", "b"}},
+				Body:       ioutil.NopCloser(strings.NewReader("hello")),
+				Trailer:    http.Header{"X-Checksum": {"abc"}},
+			}
+			if got, want := testutil.CheckHTTPResponse(gotResp, test.wantResp, test.opts...), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestIgnoreOptions tests that headers, bodies and URL queries can be
+// skipped.
+func TestIgnoreOptions(t *testing.T) {
+	newReq := func() *http.Request {
+		req := testutil.MustNewHTTPRequest("GET", "http://hello.com/orders?cursor=abc", strings.NewReader("volatile"))
+		req.Header.Set("X-Request-Id", "123")
+		req.Header.Set("Date", "Mon, 02 Jan 2006 15:04:05 GMT")
+		return req
+	}
+	want := testutil.HTTPRequest{
+		Method: "GET",
+		URL:    "http://hello.com/orders?cursor=xyz",
+		Header: http.Header{"X-Request-Id": {"456"}},
+		Body:   "",
+	}
+	if diff := testutil.CheckHTTPRequest(newReq(), want, testutil.IgnoreHeaders("x-request-id", "Date"), testutil.IgnoreBody(), testutil.IgnoreURLQuery(), testutil.Strict()); diff != "" {
+		t.Error(diff)
+	}
+	wantDiff := `request does not match what is expected:
+header "X-Request-Id" got value "123", want "456"
+body is not expected, got a longer string than what we wanted (characters match otherwise) and the extra characters are: volatile`
+	if got, want := testutil.CheckHTTPRequest(newReq(), want, testutil.IgnoreURLQuery()), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+}
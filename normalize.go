@@ -0,0 +1,72 @@
+package testutil
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Normalizer canonicalizes a string before it is compared, for
+// example by redacting a timestamp. Normalizers can be chained with
+// Chain and passed to CompareStrings or CheckHTTPResponse with
+// Normalize.
+type Normalizer func(s string) string
+
+// Normalize makes CompareStrings, and the body checks which use it,
+// run got and want through the normalizers, in order, before comparing
+// them.
+func Normalize(normalizers ...Normalizer) Option {
+	return func(o *options) {
+		o.normalizers = append(o.normalizers, normalizers...)
+	}
+}
+
+// Chain returns a Normalizer which runs each of normalizers in turn.
+func Chain(normalizers ...Normalizer) Normalizer {
+	return func(s string) string {
+		for _, n := range normalizers {
+			s = n(s)
+		}
+		return s
+	}
+}
+
+// SortLines returns a Normalizer which sorts the lines of a string,
+// for output whose order doesn't matter.
+func SortLines() Normalizer {
+	return func(s string) string {
+		lines := strings.Split(s, "\n")
+		sort.Strings(lines)
+		return strings.Join(lines, "\n")
+	}
+}
+
+var ansiEscapeRE = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// StripANSI returns a Normalizer which removes ANSI escape sequences,
+// like the ones used to color terminal output.
+func StripANSI() Normalizer {
+	return func(s string) string {
+		return ansiEscapeRE.ReplaceAllString(s, "")
+	}
+}
+
+var spacesRE = regexp.MustCompile(`[ \t]+`)
+
+// CollapseSpaces returns a Normalizer which replaces each run of
+// spaces and tabs with a single space.
+func CollapseSpaces() Normalizer {
+	return func(s string) string {
+		return spacesRE.ReplaceAllString(s, " ")
+	}
+}
+
+// RedactPattern returns a Normalizer which replaces everything
+// matching the regular expression pattern with replacement. It panic's
+// if the pattern cannot be compiled.
+func RedactPattern(pattern string, replacement string) Normalizer {
+	re := regexp.MustCompile(pattern)
+	return func(s string) string {
+		return re.ReplaceAllString(s, replacement)
+	}
+}
@@ -0,0 +1,72 @@
+package testutil_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestNormalizers tests that the built in normalizers canonicalize
+// strings as expected.
+func TestNormalizers(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalizer testutil.Normalizer
+		in         string
+		want       string
+	}{
+		{
+			name:       "sort lines",
+			normalizer: testutil.SortLines(),
+			in:         "c\na\nb",
+			want:       "a\nb\nc",
+		},
+		{
+			name:       "strip ANSI",
+			normalizer: testutil.StripANSI(),
+			in:         "\x1b[1;31merror\x1b[0m: bad",
+			want:       "error: bad",
+		},
+		{
+			name:       "collapse spaces",
+			normalizer: testutil.CollapseSpaces(),
+			in:         "a  \t b\n c",
+			want:       "a b\n c",
+		},
+		{
+			name:       "redact pattern",
+			normalizer: testutil.RedactPattern(`\d{4}-\d{2}-\d{2}`, "<date>"),
+			in:         "created 2021-03-04, updated 2021-03-05",
+			want:       "created <date>, updated <date>",
+		},
+		{
+			name:       "chain",
+			normalizer: testutil.Chain(testutil.StripANSI(), testutil.CollapseSpaces(), testutil.SortLines()),
+			in:         "\x1b[32mb   2\x1b[0m\na  1",
+			want:       "a 1\nb 2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.normalizer(test.in); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// TestNormalizeOption tests that normalizers are applied to both sides
+// of a comparison.
+func TestNormalizeOption(t *testing.T) {
+	opt := testutil.Normalize(testutil.RedactPattern(`id=\w+`, "id=<id>"), testutil.SortLines())
+	if diff := testutil.CompareStrings("b id=123\na id=456", "a id=x\nb id=y", opt); diff != "" {
+		t.Errorf("got diff %q, want none", diff)
+	}
+	resp := &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader("\x1b[32mok\x1b[0m"))}
+	if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: "ok"}, testutil.Normalize(testutil.StripANSI())); diff != "" {
+		t.Errorf("got diff %q, want none", diff)
+	}
+}
@@ -0,0 +1,116 @@
+package testutil
+
+import (
+	"time"
+)
+
+// Option changes how a comparison is made.
+type Option func(*options)
+
+type options struct {
+	sortQueryParams     bool
+	ignoreDefaultPorts  bool
+	ignoreTrailingSlash bool
+	lowercaseHost       bool
+
+	checkForwardedHeaders bool
+
+	numericTolerance  float64
+	ignoreColumnOrder bool
+	unorderedRows     bool
+	trimCells         bool
+
+	pixelTolerance uint8
+	diffImagePath  string
+
+	reportTemplateAction bool
+
+	readLimit            int64
+	normalizeLineEndings bool
+
+	substitutes map[string]string
+
+	byLine        bool
+	unifiedDiff   bool
+	contextWindow int
+
+	ignoreCase bool
+	trimSpace  bool
+
+	ignoreAttributeOrder          bool
+	ignoreNamespacePrefixes       bool
+	ignoreInsignificantWhitespace bool
+
+	ignoreOrder bool
+
+	ignoreFields     []string
+	ignoreUnexported bool
+	timeTolerance    time.Duration
+
+	maxDiffBytes *int
+	maxDiffLines int
+
+	color bool
+
+	placeholders bool
+	normalizers  []Normalizer
+
+	jsonBody bool
+
+	orderedHeaderValues bool
+
+	partial        bool
+	strict         bool
+	strictHeaders  bool
+	allowedHeaders []string
+
+	ignoredHeaders map[string]bool
+	ignoreBody     bool
+	ignoreURLQuery bool
+
+	bodyComparator BodyComparator
+	contentType    string
+}
+
+func newOptions(opts []Option) options {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.strict {
+		o.partial = false
+	}
+	return o
+}
+
+// NumericTolerance makes comparisons which understand numbers treat
+// two numbers as equal if they differ by no more than tolerance.
+func NumericTolerance(tolerance float64) Option {
+	return func(o *options) {
+		o.numericTolerance = tolerance
+	}
+}
+
+// NormalizeLineEndings makes comparisons treat "\r\n" the same as
+// "\n". Golden files checked out on different platforms often only
+// differ in their line endings.
+func NormalizeLineEndings() Option {
+	return func(o *options) {
+		o.normalizeLineEndings = true
+	}
+}
+
+// IgnoreCase makes string comparisons case insensitive.
+func IgnoreCase() Option {
+	return func(o *options) {
+		o.ignoreCase = true
+	}
+}
+
+// TrimSpace makes string comparisons ignore leading and trailing
+// whitespace.
+func TrimSpace() Option {
+	return func(o *options) {
+		o.trimSpace = true
+	}
+}
@@ -0,0 +1,59 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareStringsNormalization tests that normalization options
+// are applied before strings are compared.
+func TestCompareStringsNormalization(t *testing.T) {
+	tests := []struct {
+		name     string
+		gotStr   string
+		wantStr  string
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "ignore case",
+			gotStr:   "Hello World",
+			wantStr:  "hello world",
+			opts:     []testutil.Option{testutil.IgnoreCase()},
+			wantDiff: "",
+		},
+		{
+			name:     "trim space",
+			gotStr:   "\n  hello world\n",
+			wantStr:  "hello world",
+			opts:     []testutil.Option{testutil.TrimSpace()},
+			wantDiff: "",
+		},
+		{
+			name:     "normalize line endings",
+			gotStr:   "line one\r\nline two\r\n",
+			wantStr:  "line one\nline two\n",
+			opts:     []testutil.Option{testutil.NormalizeLineEndings()},
+			wantDiff: "",
+		},
+		{
+			name:    "combined options still report differences",
+			gotStr:  " Hello There\r\n",
+			wantStr: "hello world",
+			opts:    []testutil.Option{testutil.IgnoreCase(), testutil.TrimSpace(), testutil.NormalizeLineEndings()},
+			wantDiff: `strings differ at index 6, from that index on:
+##### got string #####
+there
+##### want string #####
+world`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareStrings(test.gotStr, test.wantStr, test.opts...), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
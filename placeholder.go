@@ -0,0 +1,140 @@
+package testutil
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// placeholders maps the placeholders which can appear in a want
+// string to the regular expressions describing what they match.
+var placeholders = map[string]string{
+	"ANY":     `(?s:.*?)`,
+	"UUID":    `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	"RFC3339": `\d{4}-\d{2}-\d{2}[Tt]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:[Zz]|[+-]\d{2}:\d{2})`,
+	"NUMBER":  `-?\d+(?:\.\d+)?(?:[eE][+-]?\d+)?`,
+}
+
+var placeholderRE = regexp.MustCompile(`\{\{([A-Za-z0-9_]+)\}\}`)
+
+// wantToken is either a literal piece of a want string or a
+// placeholder.
+type wantToken struct {
+	literal     string
+	placeholder string
+}
+
+func (t wantToken) pattern() string {
+	if t.placeholder != "" {
+		return placeholders[t.placeholder]
+	}
+	return regexp.QuoteMeta(t.literal)
+}
+
+func (t wantToken) String() string {
+	if t.placeholder != "" {
+		return "{{" + t.placeholder + "}}"
+	}
+	return t.literal
+}
+
+// tokenizeWant splits a want string into literals and placeholders.
+// Anything which looks like a placeholder but isn't one we know about
+// is treated literally.
+func tokenizeWant(want string) []wantToken {
+	tokens := []wantToken{}
+	last := 0
+	for _, loc := range placeholderRE.FindAllStringSubmatchIndex(want, -1) {
+		name := want[loc[2]:loc[3]]
+		if _, ok := placeholders[name]; !ok {
+			continue
+		}
+		if loc[0] > last {
+			tokens = append(tokens, wantToken{literal: want[last:loc[0]]})
+		}
+		tokens = append(tokens, wantToken{placeholder: name})
+		last = loc[1]
+	}
+	if last < len(want) {
+		tokens = append(tokens, wantToken{literal: want[last:]})
+	}
+	return tokens
+}
+
+func tokensPattern(tokens []wantToken) string {
+	p := ""
+	for _, t := range tokens {
+		p += t.pattern()
+	}
+	return p
+}
+
+// CompareWithPlaceholders is like CompareStrings except the want
+// string can contain placeholders which match dynamic content:
+//
+//   - {{ANY}} matches anything
+//   - {{UUID}} matches a UUID
+//   - {{RFC3339}} matches a RFC 3339 timestamp
+//   - {{NUMBER}} matches a JSON number
+//
+// This means a real payload can be copy-pasted into a test and only
+// the parts which change on every run need to be edited.
+func CompareWithPlaceholders(got string, want string) string {
+	tokens := tokenizeWant(want)
+	if regexp.MustCompile("^" + tokensPattern(tokens) + "$").MatchString(got) {
+		return ""
+	}
+	// Find the longest run of tokens which matches the start of got
+	// to report where things went wrong.
+	k, end := 0, 0
+	for k = len(tokens); k > 0; k-- {
+		if loc := regexp.MustCompile("^" + tokensPattern(tokens[:k])).FindStringIndex(got); loc != nil {
+			end = loc[1]
+			break
+		}
+	}
+	rest := ""
+	for _, t := range tokens[k:] {
+		rest += t.String()
+	}
+	if k < len(tokens) && tokens[k].placeholder == "" {
+		lit := tokens[k].literal
+		i := 0
+		for i < len(lit) && end+i < len(got) {
+			_, size := utf8.DecodeRuneInString(lit[i:])
+			if !strings.HasPrefix(got[end+i:], lit[i:i+size]) {
+				break
+			}
+			i += size
+		}
+		end += i
+		rest = rest[i:]
+	}
+	if rest == "" {
+		return fmt.Sprintf("got a longer string than what we wanted (characters match otherwise) and the extra characters are: %s", got[end:])
+	}
+	if end == len(got) {
+		return fmt.Sprintf("got a shorter string than what we wanted (characters match otherwise) and the missing characters are: %s", rest)
+	}
+	return fmt.Sprintf("strings differ at %s, from that index on:\n##### got string #####\n%s\n##### want string #####\n%s", runeIndex(got, end), got[end:], rest)
+}
+
+// UsePlaceholders makes CompareStrings and the string values compared
+// by CompareJSON, and so the body checks of CheckHTTPRequest and
+// CheckHTTPResponse, understand the placeholders described in
+// CompareWithPlaceholders so responses with generated IDs and
+// timestamps can be checked without scrubbing them first.
+func UsePlaceholders() Option {
+	return func(o *options) {
+		o.placeholders = true
+	}
+}
+
+// Placeholders returns a Matcher which compares values against a want
+// string which can contain placeholders. See CompareWithPlaceholders.
+func Placeholders(want string) Matcher {
+	return MatcherFunc(func(got interface{}) string {
+		return CompareWithPlaceholders(string(toBytes(got)), want)
+	})
+}
@@ -0,0 +1,102 @@
+package testutil_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareWithPlaceholders tests that placeholders in the want
+// string match dynamic content and that the expected diff is
+// generated when they don't.
+func TestCompareWithPlaceholders(t *testing.T) {
+	tests := []struct {
+		name     string
+		gotStr   string
+		wantStr  string
+		wantDiff string
+	}{
+		{
+			name:     "placeholders match",
+			gotStr:   `{"id": "0b4d4c2e-0f4e-4c79-9a4a-7e1f5c1d2a3b", "created": "2021-03-04T05:06:07.89Z", "count": 12, "etag": "xyz"}`,
+			wantStr:  `{"id": "{{UUID}}", "created": "{{RFC3339}}", "count": {{NUMBER}}, "etag": "{{ANY}}"}`,
+			wantDiff: "",
+		},
+		{
+			name:     "unknown placeholders are literal",
+			gotStr:   "hello {{name}}",
+			wantStr:  "hello {{name}}",
+			wantDiff: "",
+		},
+		{
+			name:    "literal differs after placeholder",
+			gotStr:  `{"id": "0b4d4c2e-0f4e-4c79-9a4a-7e1f5c1d2a3b", "name": "bob"}`,
+			wantStr: `{"id": "{{UUID}}", "name": "alice"}`,
+			wantDiff: `strings differ at index 56, from that index on:
+##### got string #####
+bob"}
+##### want string #####
+alice"}`,
+		},
+		{
+			name:    "placeholder does not match",
+			gotStr:  `{"id": "not-a-uuid"}`,
+			wantStr: `{"id": "{{UUID}}"}`,
+			wantDiff: `strings differ at index 8, from that index on:
+##### got string #####
+not-a-uuid"}
+##### want string #####
+{{UUID}}"}`,
+		},
+		{
+			name:    "multi-byte literal differs",
+			gotStr:  `{"id": 7, "name": "café"}`,
+			wantStr: `{"id": {{NUMBER}}, "name": "cafè"}`,
+			wantDiff: `strings differ at index 22, from that index on:
+##### got string #####
+é"}
+##### want string #####
+è"}`,
+		},
+		{
+			name:     "got is shorter",
+			gotStr:   `id=12`,
+			wantStr:  `id={{NUMBER}}&page=2`,
+			wantDiff: "got a shorter string than what we wanted (characters match otherwise) and the missing characters are: &page=2",
+		},
+		{
+			name:     "got is longer",
+			gotStr:   `id=12&page=2`,
+			wantStr:  `id={{NUMBER}}`,
+			wantDiff: "got a longer string than what we wanted (characters match otherwise) and the extra characters are: &page=2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareWithPlaceholders(test.gotStr, test.wantStr), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCheckHTTPResponsePlaceholders tests that response bodies can
+// contain placeholders.
+func TestCheckHTTPResponsePlaceholders(t *testing.T) {
+	newResp := func() *http.Response {
+		return &http.Response{
+			StatusCode: 201,
+			Body:       ioutil.NopCloser(strings.NewReader(`{"id": "0b4d4c2e-0f4e-4c79-9a4a-7e1f5c1d2a3b", "created": "2021-03-04T05:06:07Z"}`)),
+		}
+	}
+	want := testutil.HTTPResponse{StatusCode: 201, Body: `{"id": "{{UUID}}", "created": "{{RFC3339}}"}`}
+	if diff := testutil.CheckHTTPResponse(newResp(), want, testutil.UsePlaceholders()); diff != "" {
+		t.Errorf("got diff %q, want none", diff)
+	}
+	if diff := testutil.CheckHTTPResponse(newResp(), want); diff == "" {
+		t.Error("placeholders matched without UsePlaceholders")
+	}
+}
@@ -0,0 +1,101 @@
+// Package prototest compares protobuf bodies field by field. Importing
+// it registers CompareProto with testutil for the application/protobuf,
+// application/x-protobuf and application/vnd.google.protobuf media
+// types. It lives in its own package so that only the users of
+// testutil who need protobuf depend on it.
+//
+// It requires google.golang.org/protobuf v1.36.6 or later.
+package prototest
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/lag13/testutil"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+func init() {
+	for _, mediaType := range []string{"application/protobuf", "application/x-protobuf", "application/vnd.google.protobuf"} {
+		testutil.RegisterBodyComparator(mediaType, CompareProto)
+	}
+}
+
+// ProtoMessage makes testutil.CheckHTTPRequest and
+// testutil.CheckHTTPResponse compare bodies as protobuf messages of
+// the type of m, whatever their Content-Type. m is only used for its
+// type.
+func ProtoMessage(m proto.Message) testutil.Option {
+	mt := m.ProtoReflect().Type()
+	return testutil.CompareBodyWith(func(got string, want string, opts ...testutil.Option) string {
+		return compare(mt, got, want, opts)
+	})
+}
+
+// CompareProto unmarshals two protobuf messages in the binary wire
+// format and returns a string detailing the fields which differ, like
+// "$.items[0].sku", or "" if none do. The message type comes from a
+// "messageType" or "proto" parameter on the Content-Type of the body
+// being compared naming a registered message.
+func CompareProto(got string, want string, opts ...testutil.Option) string {
+	mt, err := messageType(testutil.BodyContentType(opts...))
+	if err != nil {
+		return fmt.Sprintf("cannot compare protobuf messages: %v", err)
+	}
+	return compare(mt, got, want, opts)
+}
+
+// CompareMessages is like CompareProto but the messages are of the
+// type of m.
+func CompareMessages(m proto.Message, got string, want string, opts ...testutil.Option) string {
+	return compare(m.ProtoReflect().Type(), got, want, opts)
+}
+
+// compare converts the messages to JSON to compare them field by
+// field with testutil.CompareJSON.
+func compare(mt protoreflect.MessageType, got string, want string, opts []testutil.Option) string {
+	gotJSON, err := toJSON(mt, got)
+	if err != nil {
+		return fmt.Sprintf("could not parse got protobuf message: %v", err)
+	}
+	wantJSON, err := toJSON(mt, want)
+	if err != nil {
+		return fmt.Sprintf("could not parse want protobuf message: %v", err)
+	}
+	if diff := testutil.CompareJSON(gotJSON, wantJSON, opts...); diff != "" {
+		return fmt.Sprintf("protobuf %s does not match:\n%s", mt.Descriptor().FullName(), strings.TrimPrefix(diff, "JSON does not match:\n"))
+	}
+	return ""
+}
+
+func messageType(contentType string) (protoreflect.MessageType, error) {
+	_, params, _ := mime.ParseMediaType(contentType)
+	name := params["messagetype"]
+	if name == "" {
+		name = params["proto"]
+	}
+	if name == "" {
+		return nil, fmt.Errorf("the message type is unknown, pass the ProtoMessage option")
+	}
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("could not find message type %q: %v", name, err)
+	}
+	return mt, nil
+}
+
+func toJSON(mt protoreflect.MessageType, b string) (string, error) {
+	m := mt.New().Interface()
+	if err := proto.Unmarshal([]byte(b), m); err != nil {
+		return "", err
+	}
+	j, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return string(j), nil
+}
@@ -0,0 +1,80 @@
+package prototest_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+	"github.com/lag13/testutil/prototest"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// TestCompareProto tests that protobuf messages are compared field by
+// field.
+func TestCompareProto(t *testing.T) {
+	marshal := func(m proto.Message) string {
+		b, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	got := marshal(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("orders.proto"),
+		Dependency:  []string{"a.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Order")}},
+	})
+	want := marshal(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("orders.proto"),
+		Package:     proto.String("shop"),
+		MessageType: []*descriptorpb.DescriptorProto{{Name: proto.String("Item")}},
+	})
+	tests := []struct {
+		name        string
+		contentType string
+		opts        []testutil.Option
+		wantBody    string
+		wantDiff    string
+	}{
+		{
+			name:        "equal",
+			contentType: "application/x-protobuf",
+			opts:        []testutil.Option{prototest.ProtoMessage(&descriptorpb.FileDescriptorProto{})},
+			wantBody:    got,
+			wantDiff:    "",
+		},
+		{
+			name:        "message type from the content type",
+			contentType: `application/x-protobuf; messageType="google.protobuf.FileDescriptorProto"`,
+			wantBody:    want,
+			wantDiff: `response does not match what is expected:
+body is not expected, protobuf google.protobuf.FileDescriptorProto does not match:
+$: missing key "package"
+$: unexpected key "dependency"
+$.message_type[0].name: got "Order", want "Item"`,
+		},
+		{
+			name:        "unknown message type",
+			contentType: "application/x-protobuf",
+			wantBody:    want,
+			wantDiff: `response does not match what is expected:
+body is not expected, cannot compare protobuf messages: the message type is unknown, pass the ProtoMessage option`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {test.contentType}},
+				Body:       ioutil.NopCloser(strings.NewReader(got)),
+			}
+			diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: test.wantBody}, test.opts...)
+			if got, want := diff, test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package testutil
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+)
+
+// RecordingProxy is an HTTP proxy which forwards requests to their
+// real destinations and records each request and response. Point the
+// legacy code under test at it, with the HTTP_PROXY environment
+// variable or a http.Transport's Proxy, to find out what it sends.
+// HTTPS requests are tunnelled with CONNECT without being recorded
+// since they can't be read. It embeds the *httptest.Server it runs on
+// so URL and Close are available as usual.
+type RecordingProxy struct {
+	*httptest.Server
+
+	recorder *RecordingTransport
+}
+
+// hopHeaders are only meant for the proxy so they aren't forwarded.
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// NewRecordingProxy starts a RecordingProxy. The caller should Close
+// it when finished.
+func NewRecordingProxy() *RecordingProxy {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Forwarding through another proxy, which could be this one if
+	// HTTP_PROXY is set, isn't wanted.
+	transport.Proxy = nil
+	p := &RecordingProxy{recorder: &RecordingTransport{Transport: transport}}
+	p.Server = httptest.NewServer(p)
+	return p
+}
+
+// Client returns a client which sends its requests through the proxy.
+func (p *RecordingProxy) Client() *http.Client {
+	u, _ := url.Parse(p.URL)
+	return &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(u)}}
+}
+
+// Interactions returns the requests forwarded so far, with absolute
+// URLs, along with the responses they got.
+func (p *RecordingProxy) Interactions() []Interaction {
+	return p.recorder.Interactions()
+}
+
+// ServeHTTP forwards r to its destination.
+func (p *RecordingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "not a proxy request, the URL must be absolute", http.StatusBadRequest)
+		return
+	}
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	for _, name := range hopHeaders {
+		out.Header.Del(name)
+	}
+	resp, err := p.recorder.RoundTrip(out)
+	if err != nil {
+		http.Error(w, "could not forward request: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	for _, name := range hopHeaders {
+		w.Header().Del(name)
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// tunnel connects the client to the host it asked for and copies bytes
+// between them until either side is done.
+func (p *RecordingProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	dest, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		http.Error(w, "could not connect: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		dest.Close()
+		http.Error(w, "tunnelling is not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		dest.Close()
+		return
+	}
+	io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n")
+	go func() {
+		io.Copy(dest, buf)
+		dest.Close()
+	}()
+	io.Copy(conn, dest)
+	conn.Close()
+}
@@ -0,0 +1,71 @@
+package testutil_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestRecordingProxy tests that requests sent through the proxy reach
+// their destination and are recorded.
+func TestRecordingProxy(t *testing.T) {
+	dest := testutil.NewRecordingServer(testutil.HTTPResponse{StatusCode: 201, Body: "created"})
+	defer dest.Close()
+	proxy := testutil.NewRecordingProxy()
+	defer proxy.Close()
+	req := testutil.MustNewHTTPRequest("POST", dest.URL+"/orders?id=7", strings.NewReader("an order"))
+	req.Header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+	resp, err := proxy.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 201, Body: "created"}, testutil.Partial()); diff != "" {
+		t.Error(diff)
+	}
+	if got, want := len(dest.Requests()), 1; got != want {
+		t.Fatalf("got %d requests at the destination, want %d", got, want)
+	}
+	if got := dest.Requests()[0].Header.Get("Proxy-Authorization"); got != "" {
+		t.Errorf("got Proxy-Authorization header %q forwarded, want none", got)
+	}
+	interactions := proxy.Interactions()
+	if got, want := len(interactions), 1; got != want {
+		t.Fatalf("got %d recorded interactions, want %d", got, want)
+	}
+	i := interactions[0]
+	if diff := testutil.CompareValues(
+		[]interface{}{i.Request.Method, i.Request.URL, i.Request.Body, i.Response.StatusCode, i.Response.Body},
+		[]interface{}{"POST", dest.URL + "/orders?id=7", "an order", 201, "created"},
+	); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// TestRecordingProxyTunnel tests that HTTPS requests are tunnelled to
+// their destination without being recorded.
+func TestRecordingProxyTunnel(t *testing.T) {
+	dest := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "secure")
+	}))
+	defer dest.Close()
+	proxy := testutil.NewRecordingProxy()
+	defer proxy.Close()
+	client := proxy.Client()
+	client.Transport.(*http.Transport).TLSClientConfig = dest.Client().Transport.(*http.Transport).TLSClientConfig
+	resp, err := client.Get(dest.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: "secure"}, testutil.Partial()); diff != "" {
+		t.Error(diff)
+	}
+	if got := len(proxy.Interactions()); got != 0 {
+		t.Errorf("got %d recorded interactions, want none", got)
+	}
+}
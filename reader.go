@@ -0,0 +1,129 @@
+package testutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// readerChunkSize is how much of each reader is compared at a time.
+const readerChunkSize = 32 * 1024
+
+// readerContext is how many bytes after the first difference are
+// shown in the diff.
+const readerContext = 80
+
+// ReadLimit makes CompareReaders only compare the first n bytes of
+// each reader.
+func ReadLimit(n int64) Option {
+	return func(o *options) {
+		o.readLimit = n
+	}
+}
+
+// CompareReaders compares everything read from two readers and
+// returns a string detailing the offset where they differ or "" if
+// they don't. Unlike CompareStrings the readers are compared a chunk
+// at a time so neither has to fit in memory. Options ReadLimit and
+// NormalizeLineEndings change what gets compared.
+func CompareReaders(got io.Reader, want io.Reader, opts ...Option) string {
+	o := newOptions(opts)
+	if o.readLimit > 0 {
+		got = io.LimitReader(got, o.readLimit)
+		want = io.LimitReader(want, o.readLimit)
+	}
+	if o.normalizeLineEndings {
+		got = &crlfReader{r: bufio.NewReader(got)}
+		want = &crlfReader{r: bufio.NewReader(want)}
+	}
+	gotBuf := make([]byte, readerChunkSize)
+	wantBuf := make([]byte, readerChunkSize)
+	var offset int64
+	for {
+		gn, gErr := io.ReadFull(got, gotBuf)
+		if gErr != nil && gErr != io.EOF && gErr != io.ErrUnexpectedEOF {
+			return fmt.Sprintf("could not read got reader: %v", gErr)
+		}
+		wn, wErr := io.ReadFull(want, wantBuf)
+		if wErr != nil && wErr != io.EOF && wErr != io.ErrUnexpectedEOF {
+			return fmt.Sprintf("could not read want reader: %v", wErr)
+		}
+		n := gn
+		if wn < n {
+			n = wn
+		}
+		for i := 0; i < n; i++ {
+			if gotBuf[i] != wantBuf[i] {
+				return fmt.Sprintf("readers differ at offset %d, from that offset on:\n##### got string #####\n%s\n##### want string #####\n%s",
+					offset+int64(i), readContext(gotBuf[i:gn], got), readContext(wantBuf[i:wn], want))
+			}
+		}
+		if gn < wn {
+			return fmt.Sprintf("got a shorter reader than what we wanted (bytes match otherwise), it ended at offset %d and the missing bytes start with: %s", offset+int64(gn), readContext(wantBuf[gn:wn], want))
+		}
+		if gn > wn {
+			return fmt.Sprintf("got a longer reader than what we wanted (bytes match otherwise), it should have ended at offset %d and the extra bytes start with: %s", offset+int64(wn), readContext(gotBuf[wn:gn], got))
+		}
+		if gn < readerChunkSize {
+			return ""
+		}
+		offset += int64(n)
+	}
+}
+
+// CompareFiles compares the contents of two files with
+// CompareReaders so large files, like exports, can be compared without
+// reading either into memory.
+func CompareFiles(gotPath string, wantPath string, opts ...Option) string {
+	got, err := os.Open(gotPath)
+	if err != nil {
+		return fmt.Sprintf("could not open got file: %v", err)
+	}
+	defer got.Close()
+	want, err := os.Open(wantPath)
+	if err != nil {
+		return fmt.Sprintf("could not open want file: %v", err)
+	}
+	defer want.Close()
+	return CompareReaders(got, want, opts...)
+}
+
+// readContext returns up to readerContext bytes starting with buf and
+// continuing with whatever is left in r.
+func readContext(buf []byte, r io.Reader) string {
+	if len(buf) >= readerContext {
+		return string(buf[:readerContext]) + "..."
+	}
+	rest := make([]byte, readerContext-len(buf)+1)
+	n, _ := io.ReadFull(r, rest)
+	s := string(buf) + string(rest[:n])
+	if len(s) > readerContext {
+		return s[:readerContext] + "..."
+	}
+	return s
+}
+
+// crlfReader replaces "\r\n" with "\n" as it reads.
+type crlfReader struct {
+	r *bufio.Reader
+}
+
+func (c *crlfReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return n, err
+		}
+		if b == '\r' {
+			if next, err := c.r.Peek(1); err == nil && bytes.Equal(next, []byte("\n")) {
+				continue
+			}
+		}
+		p[n] = b
+		n++
+	}
+	return n, nil
+}
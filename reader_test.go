@@ -0,0 +1,99 @@
+package testutil_test
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareReaders tests that the expected diff is generated when
+// comparing readers.
+func TestCompareReaders(t *testing.T) {
+	large := strings.Repeat("0123456789", 10000)
+	tests := []struct {
+		name     string
+		got      io.Reader
+		want     io.Reader
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "equal",
+			got:      strings.NewReader(large),
+			want:     strings.NewReader(large),
+			wantDiff: "",
+		},
+		{
+			name: "differ past the first chunk",
+			got:  strings.NewReader(large[:50000] + "X" + large[50001:]),
+			want: strings.NewReader(large),
+			wantDiff: `readers differ at offset 50000, from that offset on:
+##### got string #####
+X1234567890123456789012345678901234567890123456789012345678901234567890123456789...
+##### want string #####
+01234567890123456789012345678901234567890123456789012345678901234567890123456789...`,
+		},
+		{
+			name:     "got is shorter",
+			got:      strings.NewReader("hello"),
+			want:     strings.NewReader("hello there"),
+			wantDiff: "got a shorter reader than what we wanted (bytes match otherwise), it ended at offset 5 and the missing bytes start with:  there",
+		},
+		{
+			name:     "got is longer",
+			got:      strings.NewReader("hello there"),
+			want:     strings.NewReader("hello"),
+			wantDiff: "got a longer reader than what we wanted (bytes match otherwise), it should have ended at offset 5 and the extra bytes start with:  there",
+		},
+		{
+			name:     "read limit",
+			got:      strings.NewReader("hello there"),
+			want:     strings.NewReader("hello world"),
+			opts:     []testutil.Option{testutil.ReadLimit(6)},
+			wantDiff: "",
+		},
+		{
+			name:     "normalize line endings",
+			got:      strings.NewReader("a\r\nb\r\n"),
+			want:     strings.NewReader("a\nb\n"),
+			opts:     []testutil.Option{testutil.NormalizeLineEndings()},
+			wantDiff: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.CompareReaders(test.got, test.want, test.opts...), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+// TestCompareFiles tests that files are compared by streaming their
+// contents.
+func TestCompareFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	got := write("got.csv", strings.Repeat("id,name\n", 10000)+"1,bob\n")
+	want := write("want.csv", strings.Repeat("id,name\n", 10000)+"1,alice\n")
+	wantDiff := "readers differ at offset 80002, from that offset on:\n##### got string #####\nbob\n\n##### want string #####\nalice\n"
+	if got, want := testutil.CompareFiles(got, want), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+	if got := testutil.CompareFiles(got, got); got != "" {
+		t.Errorf("got diff %q comparing a file with itself", got)
+	}
+	if got, want := testutil.CompareFiles(filepath.Join(dir, "missing"), want), "could not open got file: "; !strings.HasPrefix(got, want) {
+		t.Errorf("got diff %q, want it to start with %q", got, want)
+	}
+}
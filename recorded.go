@@ -0,0 +1,107 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// WriteInteractions writes each interaction as a JSON file in dir,
+// creating it if needed, so they can be checked in and verified later
+// with VerifyRecorded. Files are named after the order, method and
+// path of each request. The scrubbers are applied before writing.
+func WriteInteractions(dir string, interactions []Interaction, scrubbers ...Scrubber) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating directory: %v", err)
+	}
+	for n, i := range scrubInteractions(interactions, scrubbers) {
+		b, err := json.MarshalIndent(i, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshalling interaction %d: %v", n, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, interactionFileName(n, i)), b, 0644); err != nil {
+			return fmt.Errorf("writing interaction %d: %v", n, err)
+		}
+	}
+	return nil
+}
+
+var unsafeFileNameRE = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func interactionFileName(n int, i Interaction) string {
+	path := i.Request.URL
+	if u, err := url.Parse(path); err == nil {
+		path = u.Path
+	}
+	name := strings.Trim(unsafeFileNameRE.ReplaceAllString(path, "_"), "_")
+	return fmt.Sprintf("%03d-%s-%s.json", n, i.Request.Method, name)
+}
+
+// ReadInteractions reads every JSON file in dir as an Interaction, in
+// file name order. Interactions without a description are described
+// by their file name.
+func ReadInteractions(dir string) ([]Interaction, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	interactions := []Interaction{}
+	for _, path := range paths {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading interaction: %v", err)
+		}
+		var i Interaction
+		if err := json.Unmarshal(b, &i); err != nil {
+			return nil, fmt.Errorf("unmarshalling interaction %s: %v", path, err)
+		}
+		if i.Description == "" {
+			i.Description = filepath.Base(path)
+		}
+		interactions = append(interactions, i)
+	}
+	return interactions, nil
+}
+
+// VerifyRecorded replays a directory of recorded interactions, like
+// those written by WriteInteractions, against a provider's handler
+// and returns a report of the interactions it no longer satisfies or
+// "" if it satisfies them all. It works as a lightweight backward
+// compatibility gate: record what clients send today and make sure
+// tomorrow's provider still answers the same way.
+func VerifyRecorded(h http.Handler, dir string, opts ...Option) string {
+	return verifyRecorded(dir, opts, handlerReplayer(h))
+}
+
+// VerifyRecordedServer is like VerifyRecorded but replays the
+// interactions against a running provider at baseURL, using client or
+// http.DefaultClient if client is nil.
+func VerifyRecordedServer(client *http.Client, baseURL string, dir string, opts ...Option) string {
+	replay, err := serverReplayer(client, baseURL)
+	if err != nil {
+		return err.Error()
+	}
+	return verifyRecorded(dir, opts, replay)
+}
+
+func verifyRecorded(dir string, opts []Option, replay replayer) string {
+	interactions, err := ReadInteractions(dir)
+	if err != nil {
+		return fmt.Sprintf("could not read recorded interactions: %v", err)
+	}
+	if len(interactions) == 0 {
+		return fmt.Sprintf("no recorded interactions found in %s", dir)
+	}
+	if diffs := verifyInteractions(interactions, opts, replay); len(diffs) > 0 {
+		return fmt.Sprintf("provider no longer satisfies %d of %d recorded interactions in %s:\n", len(diffs), len(interactions), dir) + strings.Join(diffs, "\n")
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package testutil_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestVerifyRecorded tests that recorded interactions written to a
+// directory are replayed against a provider.
+func TestVerifyRecorded(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recorded")
+	err := testutil.WriteInteractions(dir, []testutil.Interaction{
+		{
+			Request:  testutil.HTTPRequest{Method: "GET", URL: "http://old-host/users/1"},
+			Response: testutil.HTTPResponse{StatusCode: 200, Body: "user 1"},
+		},
+		{
+			Request:  testutil.HTTPRequest{Method: "GET", URL: "http://old-host/users/2?verbose=true"},
+			Response: testutil.HTTPResponse{StatusCode: 200, Body: "user 2"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		provider http.HandlerFunc
+		wantDiff string
+	}{
+		{
+			name: "provider is compatible",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, "user %s", r.URL.Path[len("/users/"):])
+			},
+			wantDiff: "",
+		},
+		{
+			name: "provider is not compatible",
+			provider: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("verbose") != "" {
+					http.Error(w, "unknown parameter", http.StatusBadRequest)
+					return
+				}
+				fmt.Fprintf(w, "user %s", r.URL.Path[len("/users/"):])
+			},
+			wantDiff: fmt.Sprintf(`provider no longer satisfies 1 of 2 recorded interactions in %s:
+interaction 1 (001-GET-users_2.json) failed, response does not match what is expected:
+got status code 400, want 200
+body is not expected, strings differ at index 1, from that index on:
+##### got string #####
+nknown parameter
+
+##### want string #####
+ser 2`, dir),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.VerifyRecorded(test.provider, dir), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+			server := httptest.NewServer(test.provider)
+			defer server.Close()
+			if diff := testutil.CompareStrings(testutil.VerifyRecordedServer(http.DefaultClient, server.URL, dir), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+	if got, want := testutil.VerifyRecorded(http.NotFoundHandler(), t.TempDir()), "no recorded interactions found in "; len(got) < len(want) || got[:len(want)] != want {
+		t.Errorf("got diff %q for an empty directory", got)
+	}
+}
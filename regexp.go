@@ -0,0 +1,58 @@
+package testutil
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// MatchRegexp checks that got matches the regular expression pattern
+// and returns a string explaining why it doesn't or "" if it does.
+// When it doesn't the longest leading part of the pattern which does
+// match is reported along with the text it matched.
+func MatchRegexp(got string, pattern string) string {
+	_, diff := MatchRegexpCapture(got, pattern)
+	return diff
+}
+
+// MatchRegexpCapture is like MatchRegexp but also returns the text of
+// the leftmost match and its capture groups, as returned by
+// regexp.FindStringSubmatch, so later assertions can use them.
+func MatchRegexpCapture(got string, pattern string) ([]string, string) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Sprintf("could not compile regexp %q: %v", pattern, err)
+	}
+	if m := re.FindStringSubmatch(got); m != nil {
+		return m, ""
+	}
+	return nil, fmt.Sprintf("%q does not match regexp %q, %s", got, pattern, regexpMismatch(got, pattern))
+}
+
+// regexpMismatch finds the longest run of the top level pieces of
+// pattern which matches got.
+func regexpMismatch(got string, pattern string) string {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil || parsed.Op != syntax.OpConcat {
+		return "no part of the pattern matches"
+	}
+	// Pieces are rendered back into a pattern by the syntax package
+	// which spells anchors differently to how people write them.
+	anchors := strings.NewReplacer(`\A`, "^", `(?-m:$)`, "$", `\z`, "$")
+	pieces := make([]string, len(parsed.Sub))
+	for i, sub := range parsed.Sub {
+		pieces[i] = anchors.Replace(sub.String())
+	}
+	for k := len(pieces) - 1; k > 0; k-- {
+		prefix := strings.Join(pieces[:k], "")
+		re, err := regexp.Compile(prefix)
+		if err != nil {
+			continue
+		}
+		if loc := re.FindStringIndex(got); loc != nil {
+			return fmt.Sprintf("the pattern matches up to %q which matched %q, then %q does not match %q", prefix, got[loc[0]:loc[1]], strings.Join(pieces[k:], ""), got[loc[1]:])
+		}
+	}
+	return "no part of the pattern matches"
+}
@@ -0,0 +1,62 @@
+package testutil_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestMatchRegexp tests that the expected diff is generated when a
+// string does not match a regular expression.
+func TestMatchRegexp(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		pattern  string
+		wantDiff string
+	}{
+		{
+			name:     "matches",
+			got:      "order 42 shipped",
+			pattern:  `order \d+ shipped`,
+			wantDiff: "",
+		},
+		{
+			name:     "fails part way",
+			got:      "order 42 pending",
+			pattern:  `^order \d+ shipped$`,
+			wantDiff: `"order 42 pending" does not match regexp "^order \\d+ shipped$", the pattern matches up to "^order [0-9]+" which matched "order 42", then " shipped$" does not match " pending"`,
+		},
+		{
+			name:     "nothing matches",
+			got:      "abc",
+			pattern:  `\d`,
+			wantDiff: `"abc" does not match regexp "\\d", no part of the pattern matches`,
+		},
+		{
+			name:     "invalid",
+			got:      "abc",
+			pattern:  `(`,
+			wantDiff: "could not compile regexp \"(\": error parsing regexp: missing closing ): `(`",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.MatchRegexp(test.got, test.pattern), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestMatchRegexpCapture tests that capture groups are returned.
+func TestMatchRegexpCapture(t *testing.T) {
+	groups, diff := testutil.MatchRegexpCapture("Location: /orders/42", `/orders/(\d+)`)
+	if diff != "" {
+		t.Fatal(diff)
+	}
+	if want := []string{"/orders/42", "42"}; !reflect.DeepEqual(groups, want) {
+		t.Errorf("got groups %q, want %q", groups, want)
+	}
+}
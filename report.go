@@ -0,0 +1,145 @@
+package testutil
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Reporter collects the diffs produced during a test run so a grouped
+// summary can be printed at the end. Large failing end-to-end runs are
+// much easier to navigate when you can see that, say, every failure
+// came from one endpoint. It is usually hooked in via TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(testutil.NewReporter().Run(m, os.Stderr))
+//	}
+type Reporter struct {
+	mu       sync.Mutex
+	failures []reportedDiff
+}
+
+type reportedDiff struct {
+	test     string
+	checker  string
+	endpoint string
+	diff     string
+
+	// got and want are set for comparisons recorded with
+	// RecordComparison.
+	got  *string
+	want *string
+}
+
+var (
+	activeReporterMu sync.Mutex
+	activeReporter   *Reporter
+)
+
+// NewReporter returns an empty Reporter.
+func NewReporter() *Reporter {
+	return &Reporter{}
+}
+
+// Record records a diff produced by checker in test. endpoint
+// identifies what was being tested, for example "GET /users", and may
+// be empty. Empty diffs are ignored.
+func (r *Reporter) Record(test string, checker string, endpoint string, diff string) {
+	if diff == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures = append(r.failures, reportedDiff{test: test, checker: checker, endpoint: endpoint, diff: diff})
+}
+
+// Summary returns the recorded diffs grouped by endpoint, by checker
+// and by test or "" if nothing was recorded.
+func (r *Reporter) Summary() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.failures) == 0 {
+		return ""
+	}
+	lines := []string{fmt.Sprintf("diff summary: %d failures", len(r.failures))}
+	group := func(title string, key func(reportedDiff) string) {
+		counts := map[string]int{}
+		for _, f := range r.failures {
+			counts[key(f)]++
+		}
+		keys := make([]string, 0, len(counts))
+		for k := range counts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		lines = append(lines, title)
+		for _, k := range keys {
+			lines = append(lines, fmt.Sprintf("  %s: %d", k, counts[k]))
+		}
+	}
+	group("by endpoint:", func(f reportedDiff) string { return orNone(f.endpoint) })
+	group("by checker:", func(f reportedDiff) string { return orNone(f.checker) })
+	group("by test:", func(f reportedDiff) string { return orNone(f.test) })
+	failures := append([]reportedDiff(nil), r.failures...)
+	sort.SliceStable(failures, func(i, j int) bool { return failures[i].test < failures[j].test })
+	lines = append(lines, "failures:")
+	for _, f := range failures {
+		lines = append(lines, fmt.Sprintf("  %s [%s] %s:", orNone(f.test), orNone(f.checker), orNone(f.endpoint)))
+		lines = append(lines, indent(f.diff, "    "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Run makes r the reporter used by Report, runs the tests and writes
+// the summary to w if anything failed. It returns the exit code from
+// m.Run.
+func (r *Reporter) Run(m *testing.M, w io.Writer) int {
+	activeReporterMu.Lock()
+	activeReporter = r
+	activeReporterMu.Unlock()
+	code := m.Run()
+	activeReporterMu.Lock()
+	activeReporter = nil
+	activeReporterMu.Unlock()
+	if summary := r.Summary(); summary != "" {
+		fmt.Fprintln(w, summary)
+	}
+	return code
+}
+
+// Report reports diff as an error on t and, if a Reporter is running,
+// records it for the end of run summary. It does nothing when diff is
+// "".
+func Report(t testing.TB, checker string, endpoint string, diff string) {
+	t.Helper()
+	if diff == "" {
+		return
+	}
+	activeReporterMu.Lock()
+	r := activeReporter
+	activeReporterMu.Unlock()
+	if r != nil {
+		r.Record(t.Name(), checker, endpoint, diff)
+	}
+	t.Error(diff)
+}
+
+// requestEndpoint describes a request for grouping in reports.
+func requestEndpoint(req HTTPRequest) string {
+	path := req.URL
+	if u, err := url.Parse(req.URL); err == nil && u.Path != "" {
+		path = u.Path
+	}
+	return strings.TrimSpace(req.Method + " " + path)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
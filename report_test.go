@@ -0,0 +1,41 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestReporter tests that recorded diffs are summarized grouped by
+// endpoint, checker and test.
+func TestReporter(t *testing.T) {
+	r := testutil.NewReporter()
+	if got := r.Summary(); got != "" {
+		t.Errorf("got summary %q for an empty reporter, want \"\"", got)
+	}
+	r.Record("TestUsers", "CheckHTTPResponse", "GET /users", "got status code 500, want 200")
+	r.Record("TestOrders", "CheckHTTPResponse", "POST /orders", "body is not expected")
+	r.Record("TestUsers", "CompareStrings", "", "")
+	r.Record("TestUsers", "CompareStrings", "", "strings differ")
+	want := `diff summary: 3 failures
+by endpoint:
+  (none): 1
+  GET /users: 1
+  POST /orders: 1
+by checker:
+  CheckHTTPResponse: 2
+  CompareStrings: 1
+by test:
+  TestOrders: 1
+  TestUsers: 2
+failures:
+  TestOrders [CheckHTTPResponse] POST /orders:
+    body is not expected
+  TestUsers [CheckHTTPResponse] GET /users:
+    got status code 500, want 200
+  TestUsers [CompareStrings] (none):
+    strings differ`
+	if got := r.Summary(); got != want {
+		t.Errorf("got summary:\n%s\nwant:\n%s", got, want)
+	}
+}
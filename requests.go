@@ -0,0 +1,92 @@
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CheckHTTPRequests checks that got is the sequence of requests in
+// want, like CheckHTTPRequest does for a single request. By default
+// the order matters. Pass IgnoreOrder when requests are sent
+// concurrently, then each wanted request can be matched by any got
+// request and the diff says which wanted requests were never seen.
+func CheckHTTPRequests(got []*http.Request, want []HTTPRequest, opts ...Option) string {
+	bodies := make([]string, len(got))
+	for i, r := range got {
+		body, err := readAndRestore(&r.Body)
+		if err != nil {
+			return fmt.Sprintf("could not read the body of request %d: %v", i+1, err)
+		}
+		bodies[i] = body
+	}
+	diff := func(i int, j int) string {
+		return DiffHTTPRequest(got[i], want[j], opts...).String()
+	}
+	var diffs []string
+	if newOptions(opts).ignoreOrder {
+		diffs = unorderedRequestDiffs(got, bodies, want, diff)
+	} else {
+		diffs = orderedRequestDiffs(got, want, diff)
+	}
+	if len(diffs) > 0 {
+		return "requests do not match what is expected:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+func orderedRequestDiffs(got []*http.Request, want []HTTPRequest, diff func(int, int) string) []string {
+	diffs := []string{}
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if d := diff(i, i); d != "" {
+			diffs = append(diffs, fmt.Sprintf("request %d does not match:\n%s", i+1, indent(d, "  ")))
+		}
+	}
+	for i := len(want); i < len(got); i++ {
+		diffs = append(diffs, fmt.Sprintf("unexpected request %d: %s %s", i+1, got[i].Method, got[i].URL))
+	}
+	for j := len(got); j < len(want); j++ {
+		diffs = append(diffs, fmt.Sprintf("expected request %d was never seen: %s %s", j+1, want[j].Method, want[j].URL))
+	}
+	return diffs
+}
+
+// unorderedRequestDiffs pairs up got and wanted requests with a
+// maximum matching, rather than taking the first match for each wanted
+// request, so a loose expectation can't take the only request a
+// stricter one would have matched.
+func unorderedRequestDiffs(got []*http.Request, bodies []string, want []HTTPRequest, diff func(int, int) string) []string {
+	matchedGot, matchedWant := maximumMatching(len(got), len(want), func(i, j int) bool { return diff(i, j) == "" })
+	unseen := []int{}
+	for j := range want {
+		if matchedWant[j] < 0 {
+			unseen = append(unseen, j)
+		}
+	}
+	used := make([]bool, len(got))
+	for i, j := range matchedGot {
+		used[i] = j >= 0
+	}
+	// Suggest the closest of the requests which weren't matched for
+	// each wanted request which wasn't seen.
+	left, summaries := []int{}, []string{}
+	for i, r := range got {
+		if !used[i] {
+			left = append(left, i)
+			summaries = append(summaries, r.Method+" "+r.URL.String()+"\n"+bodies[i])
+		}
+	}
+	diffs := []string{}
+	for _, j := range unseen {
+		d := fmt.Sprintf("expected request %d was never seen: %s %s", j+1, want[j].Method, want[j].URL)
+		if closest := closestMatch(requestSummary(want[j]), summaries); closest >= 0 {
+			i := left[closest]
+			d += fmt.Sprintf(", did you mean request %d which differed with:\n%s", i+1, indent(diff(i, j), "  "))
+		}
+		diffs = append(diffs, d)
+	}
+	for _, i := range left {
+		diffs = append(diffs, fmt.Sprintf("unexpected request %d: %s %s", i+1, got[i].Method, got[i].URL))
+	}
+	return diffs
+}
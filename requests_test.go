@@ -0,0 +1,100 @@
+package testutil_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCheckHTTPRequests tests that the expected diff is generated
+// when comparing sequences of requests.
+func TestCheckHTTPRequests(t *testing.T) {
+	newRequests := func() []*http.Request {
+		return []*http.Request{
+			testutil.MustNewHTTPRequest("POST", "http://hello.com/users", strings.NewReader(`bob`)),
+			testutil.MustNewHTTPRequest("POST", "http://hello.com/users", strings.NewReader(`alice`)),
+			testutil.MustNewHTTPRequest("DELETE", "http://hello.com/users/1", strings.NewReader("")),
+		}
+	}
+	tests := []struct {
+		name     string
+		want     []testutil.HTTPRequest
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name: "in order",
+			want: []testutil.HTTPRequest{
+				{Method: "POST", URL: "http://hello.com/users", Body: "bob"},
+				{Method: "POST", URL: "http://hello.com/users", Body: "alice"},
+				{Method: "DELETE", URL: "http://hello.com/users/1"},
+			},
+			wantDiff: "",
+		},
+		{
+			name: "out of order",
+			want: []testutil.HTTPRequest{
+				{Method: "POST", URL: "http://hello.com/users", Body: "alice"},
+				{Method: "POST", URL: "http://hello.com/users", Body: "bob"},
+			},
+			wantDiff: `requests do not match what is expected:
+request 1 does not match:
+  body is not expected, strings differ at index 0, from that index on:
+  ##### got string #####
+  bob
+  ##### want string #####
+  alice
+request 2 does not match:
+  body is not expected, strings differ at index 0, from that index on:
+  ##### got string #####
+  alice
+  ##### want string #####
+  bob
+unexpected request 3: DELETE http://hello.com/users/1`,
+		},
+		{
+			name: "ignoring order",
+			want: []testutil.HTTPRequest{
+				{Method: "DELETE", URL: "http://hello.com/users/1"},
+				{Method: "POST", URL: "http://hello.com/users", Body: "alice"},
+				{Method: "POST", URL: "http://hello.com/users", Body: "bob"},
+			},
+			opts:     []testutil.Option{testutil.IgnoreOrder()},
+			wantDiff: "",
+		},
+		{
+			name: "loose expectation doesn't take a stricter one's request",
+			want: []testutil.HTTPRequest{
+				{Method: "POST", URL: "http://hello.com/users"},
+				{Method: "POST", URL: "http://hello.com/users", Body: "bob"},
+				{Method: "DELETE", URL: "http://hello.com/users/1"},
+			},
+			opts:     []testutil.Option{testutil.IgnoreOrder(), testutil.Partial()},
+			wantDiff: "",
+		},
+		{
+			name: "never seen",
+			want: []testutil.HTTPRequest{
+				{Method: "POST", URL: "http://hello.com/users", Body: "alice"},
+				{Method: "POST", URL: "http://hello.com/users", Body: "bobby"},
+				{Method: "GET", URL: "http://hello.com/health"},
+			},
+			opts: []testutil.Option{testutil.IgnoreOrder()},
+			wantDiff: `requests do not match what is expected:
+expected request 2 was never seen: POST http://hello.com/users, did you mean request 1 which differed with:
+  body is not expected, got a shorter string than what we wanted (characters match otherwise) and the missing characters are: by
+expected request 3 was never seen: GET http://hello.com/health
+unexpected request 1: POST http://hello.com/users
+unexpected request 3: DELETE http://hello.com/users/1`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CheckHTTPRequests(newRequests(), test.want, test.opts...), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Eventually calls check every interval until it returns "" or timeout
+// elapses, in which case it returns the last diff check returned. It
+// is for asserting on things which happen asynchronously like a
+// service calling one of its dependencies.
+func Eventually(check func() string, timeout time.Duration, interval time.Duration) string {
+	deadline := time.Now().Add(timeout)
+	for {
+		diff := check()
+		if diff == "" {
+			return ""
+		}
+		if time.Now().After(deadline) {
+			return fmt.Sprintf("condition not met after %v, last diff was:\n%s", timeout, diff)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Scenario builds a multi-step end-to-end test: requests sent to the
+// system under test, values extracted from responses for use in later
+// steps, waits for calls to mock dependencies and checks of
+// intermediate state. Values captured by Extract can be referred to as
+// "{{name}}" in the URL, headers and body of later requests and the
+// body of later expected responses.
+type Scenario struct {
+	client       *http.Client
+	vars         map[string]string
+	lastBody     string
+	steps        []Step
+	pollInterval time.Duration
+}
+
+// NewScenario starts a scenario which sends requests with client, or
+// http.DefaultClient if client is nil.
+func NewScenario(client *http.Client) *Scenario {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Scenario{client: client, vars: map[string]string{}, pollInterval: 10 * time.Millisecond}
+}
+
+// Send adds a step which sends req and checks the response is want.
+func (s *Scenario) Send(name string, req HTTPRequest, want HTTPResponse) *Scenario {
+	s.steps = append(s.steps, Step{Name: name, Check: func() string {
+		req := s.interpolateRequest(req)
+		want := want
+		want.Body = s.interpolate(want.Body)
+		r, err := newClientRequest(req)
+		if err != nil {
+			return fmt.Sprintf("could not build request: %v", err)
+		}
+		resp, err := s.client.Do(r)
+		if err != nil {
+			return fmt.Sprintf("could not send request: %v", err)
+		}
+		defer resp.Body.Close()
+		body, err := readAndRestore(&resp.Body)
+		if err != nil {
+			return fmt.Sprintf("could not read response: %v", err)
+		}
+		s.lastBody = body
+		return CheckHTTPResponse(resp, want)
+	}})
+	return s
+}
+
+// Extract adds a step which matches the body of the last response
+// against a JSON template, see MatchJSONTemplate, and keeps the
+// captured values for later steps.
+func (s *Scenario) Extract(name string, template string) *Scenario {
+	s.steps = append(s.steps, Step{Name: name, Check: func() string {
+		vars, diff := MatchJSONTemplate(s.lastBody, template)
+		for k, v := range vars {
+			s.vars[k] = v
+		}
+		return diff
+	}})
+	return s
+}
+
+// WaitFor adds a step which waits until one of the requests returned
+// by recorded matches want, for example a request a mock dependency
+// received. RecordingTransport.Requests is a good fit for recorded.
+func (s *Scenario) WaitFor(name string, recorded func() []HTTPRequest, want HTTPRequest, timeout time.Duration) *Scenario {
+	s.steps = append(s.steps, Step{Name: name, Timeout: timeout + time.Second, Check: func() string {
+		want := s.interpolateRequest(want)
+		return Eventually(func() string {
+			reqs := recorded()
+			if len(reqs) == 0 {
+				return "no requests were recorded"
+			}
+			summaries, diffs := []string{}, []string{}
+			for _, r := range reqs {
+				diff := CheckHTTPRequest(newServerRequest(r), want)
+				if diff == "" {
+					return ""
+				}
+				summaries = append(summaries, requestSummary(r))
+				diffs = append(diffs, diff)
+			}
+			closest := closestMatch(requestSummary(want), summaries)
+			if closest < 0 {
+				return fmt.Sprintf("none of the %d recorded requests matched, the last one differed with:\n%s", len(reqs), diffs[len(diffs)-1])
+			}
+			return fmt.Sprintf("none of the %d recorded requests matched, did you mean request %d which differed with:\n%s", len(reqs), closest+1, diffs[closest])
+		}, timeout, s.pollInterval)
+	}})
+	return s
+}
+
+// Check adds a step which asserts on intermediate state. It is passed
+// the values extracted so far.
+func (s *Scenario) Check(name string, check func(vars map[string]string) string) *Scenario {
+	s.steps = append(s.steps, Step{Name: name, Check: func() string {
+		return check(s.vars)
+	}})
+	return s
+}
+
+// Vars returns the values extracted so far.
+func (s *Scenario) Vars() map[string]string {
+	return s.vars
+}
+
+// Run runs the steps in order and returns a transcript showing where
+// the scenario diverged or "" if every step passed. See RunSteps.
+func (s *Scenario) Run() string {
+	return RunSteps(s.steps...)
+}
+
+func (s *Scenario) interpolate(str string) string {
+	for k, v := range s.vars {
+		str = strings.Replace(str, "{{"+k+"}}", v, -1)
+	}
+	return str
+}
+
+func (s *Scenario) interpolateRequest(req HTTPRequest) HTTPRequest {
+	req.URL = s.interpolate(req.URL)
+	req.Body = s.interpolate(req.Body)
+	header := http.Header{}
+	for name, values := range req.Header {
+		for _, v := range values {
+			header.Add(name, s.interpolate(v))
+		}
+	}
+	req.Header = header
+	return req
+}
+
+// requestSummary describes a request for finding the closest match.
+func requestSummary(r HTTPRequest) string {
+	return r.Method + " " + r.URL + "\n" + r.Body
+}
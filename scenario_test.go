@@ -0,0 +1,97 @@
+package testutil_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lag13/testutil"
+)
+
+// TestScenario tests a multi-step flow where an order is created, the
+// system under test asynchronously calls a payment dependency and the
+// order is then fetched.
+func TestScenario(t *testing.T) {
+	payments := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer payments.Close()
+	transport := &testutil.RecordingTransport{}
+	paymentsClient := &http.Client{Transport: transport}
+	sut := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/orders":
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				resp, err := paymentsClient.Post(payments.URL+"/charges", "application/json", strings.NewReader(`{"order": "o-17"}`))
+				if err == nil {
+					resp.Body.Close()
+				}
+			}()
+			fmt.Fprint(w, `{"id": "o-17", "status": "pending"}`)
+		case r.Method == "GET" && r.URL.Path == "/orders/o-17":
+			fmt.Fprint(w, `{"id": "o-17", "status": "paid"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer sut.Close()
+
+	scenario := testutil.NewScenario(nil).
+		Send("create order", testutil.HTTPRequest{Method: "POST", URL: sut.URL + "/orders"}, testutil.HTTPResponse{StatusCode: 200, BodyMatcher: testutil.Any()}).
+		Extract("capture order id", `{"id": "{{id}}", "status": "pending"}`).
+		WaitFor("payment requested", transport.Requests, testutil.HTTPRequest{Method: "POST", URL: payments.URL + "/charges", Body: `{"order": "{{id}}"}`}, time.Second).
+		Send("fetch order", testutil.HTTPRequest{Method: "GET", URL: sut.URL + "/orders/{{id}}"}, testutil.HTTPResponse{StatusCode: 200, Body: `{"id": "{{id}}", "status": "paid"}`}).
+		Check("id captured", func(vars map[string]string) string {
+			if vars["id"] != "o-17" {
+				return fmt.Sprintf("got id %q, want o-17", vars["id"])
+			}
+			return ""
+		})
+	if diff := scenario.Run(); diff != "" {
+		t.Error(diff)
+	}
+
+	diff := testutil.NewScenario(nil).
+		WaitFor("never happens", transport.Requests, testutil.HTTPRequest{Method: "DELETE", URL: payments.URL + "/charges"}, 30*time.Millisecond).
+		Run()
+	if want := "workflow diverged at step 1 \"never happens\":\n  FAILED  1 \"never happens\":\n          condition not met after 30ms"; !strings.HasPrefix(diff, want) {
+		t.Errorf("got diff:\n%s\nwant it to start with:\n%s", diff, want)
+	}
+
+	diff = testutil.NewScenario(nil).
+		WaitFor("wrong order", transport.Requests, testutil.HTTPRequest{Method: "POST", URL: payments.URL + "/charges", Body: `{"order": "o-18"}`}, 30*time.Millisecond).
+		Run()
+	if want := "none of the 1 recorded requests matched, did you mean request 1 which differed with:"; !strings.Contains(diff, want) {
+		t.Errorf("got diff:\n%s\nwant it to contain:\n%s", diff, want)
+	}
+}
+
+// TestScenarioRunTwice tests that running a scenario again uses the
+// values extracted in that run rather than those of the first run.
+func TestScenarioRunTwice(t *testing.T) {
+	orders := 0
+	sut := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/orders":
+			orders++
+			fmt.Fprintf(w, `{"id": "o-%d"}`, orders)
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/orders/"):
+			fmt.Fprintf(w, `{"id": %q}`, strings.TrimPrefix(r.URL.Path, "/orders/"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer sut.Close()
+
+	scenario := testutil.NewScenario(nil).
+		Send("create order", testutil.HTTPRequest{Method: "POST", URL: sut.URL + "/orders"}, testutil.HTTPResponse{StatusCode: 200, BodyMatcher: testutil.Any()}).
+		Extract("capture order id", `{"id": "{{id}}"}`).
+		Send("fetch order", testutil.HTTPRequest{Method: "GET", URL: sut.URL + "/orders/{{id}}"}, testutil.HTTPResponse{StatusCode: 200, Body: `{"id": "{{id}}"}`})
+	for i := 1; i <= 2; i++ {
+		if diff := scenario.Run(); diff != "" {
+			t.Errorf("run %d: %s", i, diff)
+		}
+	}
+}
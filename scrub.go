@@ -0,0 +1,103 @@
+package testutil
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Scrubber modifies an interaction before it is written to disk, for
+// example to replace credentials with a placeholder.
+type Scrubber func(i *Interaction)
+
+// ScrubHeader replaces the value of a request and response header
+// with placeholder.
+func ScrubHeader(name string, placeholder string) Scrubber {
+	return func(i *Interaction) {
+		for _, h := range []http.Header{i.Request.Header, i.Response.Header} {
+			if _, ok := h[http.CanonicalHeaderKey(name)]; ok {
+				h.Set(name, placeholder)
+			}
+		}
+	}
+}
+
+// ScrubPattern replaces everything matching the regular expression
+// pattern in URLs, header values and bodies with placeholder, for
+// example to replace emails or API keys. It panic's if the pattern
+// cannot be compiled.
+func ScrubPattern(pattern string, placeholder string) Scrubber {
+	re := regexp.MustCompile(pattern)
+	scrub := func(s string) string {
+		return re.ReplaceAllLiteralString(s, placeholder)
+	}
+	return func(i *Interaction) {
+		i.Request.URL = scrub(i.Request.URL)
+		i.Request.Body = scrub(i.Request.Body)
+		i.Response.Body = scrub(i.Response.Body)
+		for _, h := range []http.Header{i.Request.Header, i.Response.Header} {
+			for _, values := range h {
+				for j := range values {
+					values[j] = scrub(values[j])
+				}
+			}
+		}
+	}
+}
+
+// Substitute makes a replay of scrubbed interactions replace
+// placeholder with value, in both the request sent and the response
+// expected, so the provider gets a credential which is valid in the
+// test environment.
+func Substitute(placeholder string, value string) Option {
+	return func(o *options) {
+		if o.substitutes == nil {
+			o.substitutes = map[string]string{}
+		}
+		o.substitutes[placeholder] = value
+	}
+}
+
+// scrubInteractions applies scrubbers to copies of the interactions
+// so the caller's are left alone.
+func scrubInteractions(interactions []Interaction, scrubbers []Scrubber) []Interaction {
+	if len(scrubbers) == 0 {
+		return interactions
+	}
+	scrubbed := make([]Interaction, len(interactions))
+	for n, i := range interactions {
+		i.Request.Header = i.Request.Header.Clone()
+		i.Response.Header = i.Response.Header.Clone()
+		for _, scrub := range scrubbers {
+			scrub(&i)
+		}
+		scrubbed[n] = i
+	}
+	return scrubbed
+}
+
+// substitute returns a copy of the interaction with the placeholders
+// replaced.
+func substitute(i Interaction, substitutes map[string]string) Interaction {
+	if len(substitutes) == 0 {
+		return i
+	}
+	pairs := []string{}
+	for placeholder, value := range substitutes {
+		pairs = append(pairs, placeholder, value)
+	}
+	r := strings.NewReplacer(pairs...)
+	i.Request.URL = r.Replace(i.Request.URL)
+	i.Request.Body = r.Replace(i.Request.Body)
+	i.Response.Body = r.Replace(i.Response.Body)
+	i.Request.Header = i.Request.Header.Clone()
+	i.Response.Header = i.Response.Header.Clone()
+	for _, h := range []http.Header{i.Request.Header, i.Response.Header} {
+		for _, values := range h {
+			for j := range values {
+				values[j] = r.Replace(values[j])
+			}
+		}
+	}
+	return i
+}
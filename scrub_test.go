@@ -0,0 +1,70 @@
+package testutil_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestScrubbing tests that scrubbers remove secrets from written
+// contracts and that substitutes are put back in when replaying.
+func TestScrubbing(t *testing.T) {
+	contract := testutil.Contract{
+		Consumer: "consumer",
+		Provider: "provider",
+		Interactions: []testutil.Interaction{
+			{
+				Request: testutil.HTTPRequest{
+					Method: "GET",
+					URL:    "/me?api_key=sk_live_123",
+					Header: http.Header{"Authorization": {"Bearer real-token"}},
+				},
+				Response: testutil.HTTPResponse{
+					StatusCode: 200,
+					Body:       `{"email": "bob@hello.com", "key": "sk_live_123"}`,
+				},
+			},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "contract.json")
+	err := testutil.WriteContract(path, contract,
+		testutil.ScrubHeader("Authorization", "Bearer {{TOKEN}}"),
+		testutil.ScrubPattern(`sk_live_\w+`, "{{API_KEY}}"),
+		testutil.ScrubPattern(`\w+@hello\.com`, "user@example.com"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	written, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"real-token", "sk_live_123", "bob@hello.com"} {
+		if strings.Contains(string(written), secret) {
+			t.Errorf("written contract contains secret %q:\n%s", secret, written)
+		}
+	}
+	if got, want := contract.Interactions[0].Request.Header.Get("Authorization"), "Bearer real-token"; got != want {
+		t.Errorf("scrubbing modified the original contract, got %q, want %q", got, want)
+	}
+
+	scrubbed, err := testutil.ReadContract(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"email": "user@example.com", "key": "%s"}`, r.URL.Query().Get("api_key"))
+	})
+	if diff := testutil.VerifyContract(provider, scrubbed, testutil.Substitute("{{TOKEN}}", "test-token"), testutil.Substitute("{{API_KEY}}", "sk_test_456")); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,463 @@
+package testutil
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordingServer is a mock API. It records every request it
+// receives, as described in the HTTPRequest docs, and answers them
+// with the response of the stub matching the request or, if none do,
+// a fallback response. It embeds the *httptest.Server it runs on so
+// URL, Client and Close are available as usual.
+type RecordingServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []RecordedRequest
+	seq      int
+	inFlight int
+	verified map[int]bool
+	stubs    []*Stub
+	response HTTPResponse
+	errs     []string
+
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// StubMatch describes the requests a stub answers. Empty fields match
+// anything so the more fields which are set the more specific the
+// stub is.
+type StubMatch struct {
+	// Method is the method of the request.
+	Method string
+	// Path is a glob pattern, see MatchGlob, which the path of the
+	// request matches. "*" also matches "/".
+	Path string
+	// Header holds header values which the request must have, it can
+	// have others.
+	Header http.Header
+	// Query holds query parameter values which the request must have,
+	// it can have others.
+	Query url.Values
+	// Body matches the body of the request.
+	Body Matcher
+}
+
+func (m StubMatch) matches(r *http.Request, body string) bool {
+	if m.Method != "" && m.Method != r.Method {
+		return false
+	}
+	if m.Path != "" && !matchPattern(m.Path, r.URL.Path) {
+		return false
+	}
+	if !containsValues(r.Header, m.Header) || !containsValues(r.URL.Query(), m.Query) {
+		return false
+	}
+	return m.Body == nil || m.Body.Match(body) == ""
+}
+
+// specificity scores how specific m is. A literal path counts for
+// more than a pattern.
+func (m StubMatch) specificity() int {
+	n := len(m.Header) + len(m.Query)
+	if m.Method != "" {
+		n++
+	}
+	if m.Path != "" {
+		n++
+		if !strings.ContainsAny(m.Path, "*?") {
+			n++
+		}
+	}
+	if m.Body != nil {
+		n++
+	}
+	return n
+}
+
+func (m StubMatch) String() string {
+	method, path := m.Method, m.Path
+	if method == "" {
+		method = "*"
+	}
+	if path == "" {
+		path = "*"
+	}
+	return method + " " + path
+}
+
+// containsValues reports whether got has all of the values in want.
+// Names are looked up the way http.Header.Values does so it works for
+// headers and query parameters.
+func containsValues(got map[string][]string, want map[string][]string) bool {
+	for name, values := range want {
+		gotValues, ok := got[name]
+		if !ok {
+			gotValues = got[http.CanonicalHeaderKey(name)]
+		}
+		if missing, _ := multisetDiff(gotValues, values); len(missing) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Stub holds the canned responses for the requests matching a
+// StubMatch. If there are several they are sent one after another.
+type Stub struct {
+	match     StubMatch
+	responses []HTTPResponse
+	// faults maps call numbers to the fault to inject, 0 is for
+	// every call.
+	faults   map[int]Fault
+	server   *RecordingServer
+	requests []HTTPRequest
+}
+
+// Requests returns the requests this stub answered.
+func (st *Stub) Requests() []HTTPRequest {
+	st.server.mu.Lock()
+	defer st.server.mu.Unlock()
+	return append([]HTTPRequest(nil), st.requests...)
+}
+
+// NewRecordingServer starts a RecordingServer which answers requests
+// no stub matches with fallback. A zero StatusCode is sent as 200
+// unless stubs have been added, then it is sent as 404 so a single
+// server can stand in for a whole API. The caller should Close it
+// when finished.
+func NewRecordingServer(fallback HTTPResponse) *RecordingServer {
+	s := newRecordingServer(fallback)
+	s.Start()
+	return s
+}
+
+// StartTLSRecordingServer is like NewRecordingServer but serves HTTPS
+// with a certificate for localhost, 127.0.0.1 and ::1 signed by a CA
+// generated on the fly, so no certificates need to be committed. The
+// returned client trusts the CA; its TLSClientConfig can be copied to
+// configure other clients.
+func StartTLSRecordingServer(fallback HTTPResponse) (*RecordingServer, *http.Client, error) {
+	cfg, roots, err := generateTLSConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	s := newRecordingServer(fallback)
+	s.TLS = cfg
+	s.StartTLS()
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	return s, client, nil
+}
+
+// StartUnixRecordingServer is like NewRecordingServer but serves HTTP
+// on a unix socket created at socketPath, for testing clients of
+// sidecars which only listen on local sockets. The server's URL is
+// "http://unix" and the returned client sends every request to the
+// socket whatever its URL, see UnixSocketTransport.
+func StartUnixRecordingServer(fallback HTTPResponse, socketPath string) (*RecordingServer, *http.Client, error) {
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := newRecordingServer(fallback)
+	s.Listener.Close()
+	s.Listener = l
+	s.Start()
+	s.URL = "http://unix"
+	return s, &http.Client{Transport: UnixSocketTransport(socketPath)}, nil
+}
+
+// UnixSocketTransport returns a transport which connects to the unix
+// socket at socketPath instead of the host in a request's URL.
+func UnixSocketTransport(socketPath string) *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+}
+
+func newRecordingServer(fallback HTTPResponse) *RecordingServer {
+	s := &RecordingServer{response: fallback, closing: make(chan struct{})}
+	s.Server = httptest.NewUnstartedServer(s)
+	return s
+}
+
+// Close abandons requests which are hanging because of FaultHang and
+// shuts the server down.
+func (s *RecordingServer) Close() {
+	s.closeOnce.Do(func() { close(s.closing) })
+	s.Server.Close()
+}
+
+// SetResponse changes the fallback response sent to subsequent
+// requests which no stub matches.
+func (s *RecordingServer) SetResponse(resp HTTPResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.response = resp
+}
+
+// Stub makes the server answer requests with method, or any method
+// if it is empty, and a path matching the glob pattern path, see
+// MatchGlob, with resps. It is short for StubRequest with only
+// those fields of the StubMatch set.
+func (s *RecordingServer) Stub(method string, path string, resps ...HTTPResponse) *Stub {
+	return s.StubRequest(StubMatch{Method: method, Path: path}, resps...)
+}
+
+// StubRequest makes the server answer requests matching match with
+// resps. With no responses an empty 200 is sent every time, which
+// suits stubs that only inject a fault, see Stub.InjectFault. A single
+// response is sent every time. Several are sent in
+// turn, one per request, which is handy for testing retries (a 503
+// followed by a 200 say); a request after the last one has been sent
+// gets a 500 and is reported by Err. If several stubs match a request
+// the most specific one answers it, the one added first if there's a
+// tie. The returned Stub records the requests it answered.
+func (s *RecordingServer) StubRequest(match StubMatch, resps ...HTTPResponse) *Stub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := &Stub{match: match, responses: resps, server: s}
+	s.stubs = append(s.stubs, st)
+	return st
+}
+
+// Err returns an error describing the requests the server could not
+// answer as intended, like those arriving after a stub's sequence of
+// responses ran out, or nil if there were none.
+func (s *RecordingServer) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(s.errs, "\n"))
+}
+
+// respond records req, which was read from r, against the stub
+// matching it and returns the response for it and the fault to inject
+// instead of sending it normally, if any.
+func (s *RecordingServer) respond(r *http.Request, req HTTPRequest) (HTTPResponse, Fault) {
+	var st *Stub
+	for _, candidate := range s.stubs {
+		if candidate.match.matches(r, req.Body) && (st == nil || candidate.match.specificity() > st.match.specificity()) {
+			st = candidate
+		}
+	}
+	if st != nil {
+		st.requests = append(st.requests, req)
+		calls := len(st.requests)
+		switch {
+		case len(st.responses) == 0:
+			return HTTPResponse{StatusCode: http.StatusOK}, st.fault(calls)
+		case len(st.responses) == 1:
+			return st.responses[0], st.fault(calls)
+		case calls <= len(st.responses):
+			return st.responses[calls-1], st.fault(calls)
+		}
+		msg := fmt.Sprintf("stub %s ran out of responses: got request %d, want at most %d", st.match, calls, len(st.responses))
+		s.errs = append(s.errs, msg)
+		return HTTPResponse{StatusCode: http.StatusInternalServerError, Body: msg}, ""
+	}
+	if len(s.stubs) > 0 && s.response.StatusCode == 0 {
+		return HTTPResponse{
+			StatusCode: http.StatusNotFound,
+			Body:       fmt.Sprintf("no stub matches %s %s", r.Method, r.URL.Path),
+		}, ""
+	}
+	return s.response, ""
+}
+
+// RecordedRequest is a request received by a RecordingServer along
+// with when it arrived. It lets tests of code making concurrent
+// requests check how many were made at once and in what order.
+type RecordedRequest struct {
+	// Request is the request. Its RemoteAddr is the address it came
+	// from.
+	Request HTTPRequest
+	// Seq numbers the requests from 1 in the order they arrived. It
+	// keeps counting after a Reset.
+	Seq int
+	// Received is when the request arrived.
+	Received time.Time
+	// InFlight is how many requests, including this one, were being
+	// handled when it arrived.
+	InFlight int
+}
+
+// Requests returns the requests received so far in the order they
+// arrived. Their URLs only hold the path and query. It is safe to call
+// while requests are being received.
+func (s *RecordingServer) Requests() []HTTPRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reqs := make([]HTTPRequest, len(s.requests))
+	for i, r := range s.requests {
+		reqs[i] = r.Request
+	}
+	return reqs
+}
+
+// RecordedRequests is like Requests but includes when each request
+// arrived.
+func (s *RecordingServer) RecordedRequests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RecordedRequest(nil), s.requests...)
+}
+
+// Reset forgets the requests received so far.
+func (s *RecordingServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests, s.verified = nil, nil
+}
+
+// ManagementPrefix is the path prefix of a RecordingServer's control
+// endpoints which let tests running in another process drive it:
+//
+//   - GET /_testutil/requests returns the requests received so far as
+//     a JSON array of HTTPRequests
+//   - POST /_testutil/reset forgets them
+//   - POST /_testutil/stubs adds a stub, sent as a JSON object with
+//     "method", "path", "header" and "query" keys describing the
+//     StubMatch and either a "response" or "responses" key, or sets
+//     the fallback response if sent a plain JSON HTTPResponse
+//
+// Requests to these endpoints are not recorded.
+const ManagementPrefix = "/_testutil/"
+
+// ServeHTTP records r and answers it, or handles it as a control
+// request if its path starts with ManagementPrefix. It lets a
+// RecordingServer be served by a standalone process too.
+func (s *RecordingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, ManagementPrefix) {
+		s.serveManagement(w, r)
+		return
+	}
+	req, err := FromHTTPRequest(r)
+	if err != nil {
+		http.Error(w, "could not read request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.RemoteAddr = r.RemoteAddr
+	s.mu.Lock()
+	s.seq++
+	s.inFlight++
+	s.requests = append(s.requests, RecordedRequest{Request: req, Seq: s.seq, Received: time.Now(), InFlight: s.inFlight})
+	resp, fault := s.respond(r, req)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+	if fault != "" {
+		s.serveFault(w, r, resp, fault)
+		return
+	}
+	writeHTTPResponse(w, resp)
+}
+
+func (s *RecordingServer) serveManagement(w http.ResponseWriter, r *http.Request) {
+	switch endpoint := strings.TrimPrefix(r.URL.Path, ManagementPrefix); {
+	case endpoint == "requests" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Requests())
+	case endpoint == "reset" && r.Method == http.MethodPost:
+		s.Reset()
+		w.WriteHeader(http.StatusNoContent)
+	case endpoint == "stubs" && r.Method == http.MethodPost:
+		body, err := readAndRestore(&r.Body)
+		if err != nil {
+			http.Error(w, "could not read stub: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var st struct {
+			Method    string         `json:"method"`
+			Path      string         `json:"path"`
+			Header    http.Header    `json:"header"`
+			Query     url.Values     `json:"query"`
+			Response  *HTTPResponse  `json:"response"`
+			Responses []HTTPResponse `json:"responses"`
+		}
+		var resp HTTPResponse
+		err = json.Unmarshal([]byte(body), &st)
+		match := StubMatch{Method: st.Method, Path: st.Path, Header: st.Header, Query: st.Query}
+		if err == nil && st.Response != nil {
+			s.StubRequest(match, *st.Response)
+		} else if err == nil && len(st.Responses) > 0 {
+			s.StubRequest(match, st.Responses...)
+		} else if err := json.Unmarshal([]byte(body), &resp); err == nil {
+			s.SetResponse(resp)
+		} else {
+			http.Error(w, "could not decode stub: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, fmt.Sprintf("unknown control endpoint %s %s", r.Method, r.URL.Path), http.StatusNotFound)
+	}
+}
+
+// FetchRecordedRequests asks the RecordingServer at baseURL for the
+// requests it has received using its control endpoint. If client is
+// nil http.DefaultClient is used.
+func FetchRecordedRequests(client *http.Client, baseURL string) ([]HTTPRequest, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Get(strings.TrimSuffix(baseURL, "/") + ManagementPrefix + "requests")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got status code %d fetching recorded requests", resp.StatusCode)
+	}
+	var reqs []HTTPRequest
+	if err := json.NewDecoder(resp.Body).Decode(&reqs); err != nil {
+		return nil, fmt.Errorf("could not decode recorded requests: %v", err)
+	}
+	return reqs, nil
+}
+
+// writeHTTPResponse sends resp, including its cookies and trailers.
+func writeHTTPResponse(w http.ResponseWriter, resp HTTPResponse) {
+	for name, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(name, v)
+		}
+	}
+	for _, c := range resp.Cookies {
+		http.SetCookie(w, c)
+	}
+	for name := range resp.Trailer {
+		w.Header().Add("Trailer", name)
+	}
+	status := resp.StatusCode
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(resp.Body))
+	for name, values := range resp.Trailer {
+		for _, v := range values {
+			w.Header().Add(name, v)
+		}
+	}
+}
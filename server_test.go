@@ -0,0 +1,343 @@
+package testutil_test
+
+import (
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestRecordingServer tests that the server records requests and
+// answers with the configured response.
+func TestRecordingServer(t *testing.T) {
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{
+		StatusCode: 201,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Body:       "created",
+	})
+	defer server.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := server.Client().Post(server.URL+"/users", "text/plain", strings.NewReader("bob"))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 201, Header: http.Header{"Content-Type": {"text/plain"}}, Body: "created"}); diff != "" {
+				t.Error(diff)
+			}
+		}()
+	}
+	wg.Wait()
+	reqs := server.Requests()
+	if got, want := len(reqs), 5; got != want {
+		t.Fatalf("got %d requests, want %d", got, want)
+	}
+	if diff := testutil.CheckHTTPRequest(testutil.MustNewHTTPRequest(reqs[0].Method, reqs[0].URL, strings.NewReader(reqs[0].Body)), testutil.HTTPRequest{Method: "POST", URL: "/users", Body: "bob"}); diff != "" {
+		t.Error(diff)
+	}
+
+	server.Reset()
+	server.SetResponse(testutil.HTTPResponse{StatusCode: 204})
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got, want := resp.StatusCode, 204; got != want {
+		t.Errorf("got status code %d, want %d", got, want)
+	}
+	if got, want := len(server.Requests()), 1; got != want {
+		t.Errorf("got %d requests after a reset, want %d", got, want)
+	}
+}
+
+// TestRecordingServerManagement tests that the server can be driven
+// through its control endpoints.
+func TestRecordingServerManagement(t *testing.T) {
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	defer server.Close()
+	post := func(path string, body string) *http.Response {
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	if got, want := post("/_testutil/stubs", `{"status_code": 418, "body": "teapot"}`).StatusCode, 204; got != want {
+		t.Fatalf("got status code %d setting a stub, want %d", got, want)
+	}
+	resp := post("/orders?id=1", `{"id": 1}`)
+	if got, want := resp.StatusCode, 418; got != want {
+		t.Errorf("got status code %d, want %d", got, want)
+	}
+	reqs, err := testutil.FetchRecordedRequests(nil, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(reqs), 1; got != want {
+		t.Fatalf("got %d recorded requests, want %d", got, want)
+	}
+	if got, want := reqs[0].Method+" "+reqs[0].URL+" "+reqs[0].Body, `POST /orders?id=1 {"id": 1}`; got != want {
+		t.Errorf("got recorded request %q, want %q", got, want)
+	}
+	post("/_testutil/reset", "")
+	if reqs, err := testutil.FetchRecordedRequests(nil, server.URL); err != nil || len(reqs) != 0 {
+		t.Errorf("got %d recorded requests and error %v after a reset, want none", len(reqs), err)
+	}
+	if got, want := post("/_testutil/unknown", "").StatusCode, 404; got != want {
+		t.Errorf("got status code %d for an unknown control endpoint, want %d", got, want)
+	}
+}
+
+// TestRecordingServerStubs tests that requests are answered by the
+// stub matching them.
+func TestRecordingServerStubs(t *testing.T) {
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	defer server.Close()
+	server.Stub("POST", "/orders", testutil.HTTPResponse{StatusCode: 201, Body: "created"})
+	server.Stub("GET", "/orders/*", testutil.HTTPResponse{StatusCode: 200, Body: "an order"})
+	server.Stub("", "/health", testutil.HTTPResponse{StatusCode: 204})
+	resp, err := http.Post(server.URL+"/_testutil/stubs", "application/json", strings.NewReader(`{"method": "DELETE", "path": "/orders/*", "response": {"status_code": 202}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	tests := []struct {
+		method   string
+		path     string
+		wantResp testutil.HTTPResponse
+	}{
+		{method: "POST", path: "/orders", wantResp: testutil.HTTPResponse{StatusCode: 201, Body: "created"}},
+		{method: "GET", path: "/orders/7", wantResp: testutil.HTTPResponse{StatusCode: 200, Body: "an order"}},
+		{method: "HEAD", path: "/health", wantResp: testutil.HTTPResponse{StatusCode: 204}},
+		{method: "DELETE", path: "/orders/7", wantResp: testutil.HTTPResponse{StatusCode: 202}},
+		{method: "GET", path: "/orders/7/items", wantResp: testutil.HTTPResponse{StatusCode: 200, Body: "an order"}},
+		{method: "GET", path: "/customers/7", wantResp: testutil.HTTPResponse{StatusCode: 404, Body: "no stub matches GET /customers/7"}},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			resp, err := http.DefaultClient.Do(testutil.MustNewHTTPRequest(test.method, server.URL+test.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if diff := testutil.CheckHTTPResponse(resp, test.wantResp, testutil.Partial()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+// TestRecordingServerStubSequence tests that a stub with several
+// responses sends them in turn and reports requests made after they
+// ran out.
+func TestRecordingServerStubSequence(t *testing.T) {
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	defer server.Close()
+	server.Stub("GET", "/flaky", testutil.HTTPResponse{StatusCode: 503}, testutil.HTTPResponse{StatusCode: 200, Body: "ok"})
+	wantResps := []testutil.HTTPResponse{
+		{StatusCode: 503},
+		{StatusCode: 200, Body: "ok"},
+		{StatusCode: 500, Body: "stub GET /flaky ran out of responses: got request 3, want at most 2"},
+	}
+	for i, wantResp := range wantResps {
+		if err := server.Err(); err != nil {
+			t.Fatalf("before request %d: got error %v, want none", i+1, err)
+		}
+		resp, err := http.Get(server.URL + "/flaky")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := testutil.CheckHTTPResponse(resp, wantResp, testutil.Partial()); diff != "" {
+			t.Errorf("request %d: %s", i+1, diff)
+		}
+		resp.Body.Close()
+	}
+	wantErr := "stub GET /flaky ran out of responses: got request 3, want at most 2"
+	if err := server.Err(); err == nil || err.Error() != wantErr {
+		t.Errorf("got error %v, want %q", err, wantErr)
+	}
+}
+
+// TestRecordingServerStubWithoutResponses tests that a stub without
+// responses answers every request with an empty 200.
+func TestRecordingServerStubWithoutResponses(t *testing.T) {
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	defer server.Close()
+	stub := server.Stub("GET", "/ping")
+	for i := 1; i <= 2; i++ {
+		resp, err := http.Get(server.URL + "/ping")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200}, testutil.Partial()); diff != "" {
+			t.Errorf("request %d: %s", i, diff)
+		}
+		resp.Body.Close()
+	}
+	if err := server.Err(); err != nil {
+		t.Errorf("got error %v, want none", err)
+	}
+	if got, want := len(stub.Requests()), 2; got != want {
+		t.Errorf("got %d requests, want %d", got, want)
+	}
+}
+
+// TestRecordingServerStubRequest tests that the most specific stub
+// matching a request answers it and records it.
+func TestRecordingServerStubRequest(t *testing.T) {
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	defer server.Close()
+	anyOrder := server.Stub("POST", "/orders/*", testutil.HTTPResponse{StatusCode: 201})
+	bigOrder := server.StubRequest(testutil.StubMatch{
+		Method: "POST",
+		Path:   "/orders/*",
+		Body:   testutil.JSONField("quantity", 1000.0),
+	}, testutil.HTTPResponse{StatusCode: 422})
+	premium := server.StubRequest(testutil.StubMatch{
+		Method: "POST",
+		Path:   "/orders/*",
+		Header: http.Header{"X-Plan": {"premium"}},
+		Query:  url.Values{"express": {"true"}},
+	}, testutil.HTTPResponse{StatusCode: 202})
+	tests := []struct {
+		name           string
+		url            string
+		header         http.Header
+		body           string
+		wantStatusCode int
+	}{
+		{name: "only method and path match", url: "/orders/1", body: `{"quantity": 1}`, wantStatusCode: 201},
+		{name: "body matches", url: "/orders/1", body: `{"quantity": 1000}`, wantStatusCode: 422},
+		{name: "header and query match", url: "/orders/1?express=true&debug=1", header: http.Header{"X-Plan": {"premium"}}, body: `{"quantity": 1000}`, wantStatusCode: 202},
+		{name: "header matches but query does not", url: "/orders/1", header: http.Header{"X-Plan": {"premium"}}, body: `{"quantity": 1}`, wantStatusCode: 201},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := testutil.MustNewHTTPRequest("POST", server.URL+test.url, strings.NewReader(test.body))
+			for name, values := range test.header {
+				req.Header[name] = values
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if got, want := resp.StatusCode, test.wantStatusCode; got != want {
+				t.Errorf("got status code %d, want %d", got, want)
+			}
+		})
+	}
+	for _, test := range []struct {
+		name      string
+		stub      *testutil.Stub
+		wantCalls int
+	}{
+		{name: "any order", stub: anyOrder, wantCalls: 2},
+		{name: "big order", stub: bigOrder, wantCalls: 1},
+		{name: "premium", stub: premium, wantCalls: 1},
+	} {
+		if got, want := len(test.stub.Requests()), test.wantCalls; got != want {
+			t.Errorf("%s stub: got %d requests, want %d", test.name, got, want)
+		}
+	}
+}
+
+// TestRecordingServerRecordedRequests tests that requests received
+// concurrently are numbered and timestamped in the order they arrived.
+func TestRecordingServerRecordedRequests(t *testing.T) {
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	defer server.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(server.URL + "/fan-out")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	recorded := server.RecordedRequests()
+	if got, want := len(recorded), 10; got != want {
+		t.Fatalf("got %d recorded requests, want %d", got, want)
+	}
+	for i, r := range recorded {
+		if got, want := r.Seq, i+1; got != want {
+			t.Errorf("request %d: got sequence number %d, want %d", i+1, got, want)
+		}
+		if i > 0 && r.Received.Before(recorded[i-1].Received) {
+			t.Errorf("request %d: received at %v, before request %d at %v", i+1, r.Received, i, recorded[i-1].Received)
+		}
+		if r.InFlight < 1 {
+			t.Errorf("request %d: got %d requests in flight, want at least 1", i+1, r.InFlight)
+		}
+		if diff := testutil.MatchRegexp(r.Request.RemoteAddr, `^127\.0\.0\.1:\d+$`); diff != "" {
+			t.Errorf("request %d: %s", i+1, diff)
+		}
+	}
+}
+
+// TestStartTLSRecordingServer tests that the server serves HTTPS which
+// only the client it returns trusts.
+func TestStartTLSRecordingServer(t *testing.T) {
+	server, client, err := testutil.StartTLSRecordingServer(testutil.HTTPResponse{StatusCode: 200, Body: "secure"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	if !strings.HasPrefix(server.URL, "https://") {
+		t.Errorf("got URL %q, want an https URL", server.URL)
+	}
+	resp, err := client.Get(server.URL + "/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: "secure"}, testutil.Partial()); diff != "" {
+		t.Error(diff)
+	}
+	untrusting := &http.Client{Transport: &http.Transport{}}
+	if _, err := untrusting.Get(server.URL); err == nil || !strings.Contains(err.Error(), "certificate signed by unknown authority") {
+		t.Errorf("got error %v from a client which doesn't trust the CA, want an unknown authority error", err)
+	}
+	if got, want := len(server.Requests()), 1; got != want {
+		t.Errorf("got %d recorded requests, want %d", got, want)
+	}
+}
+
+// TestStartUnixRecordingServer tests that the server can be reached
+// over a unix socket with the client it returns.
+func TestStartUnixRecordingServer(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "sidecar.sock")
+	server, client, err := testutil.StartUnixRecordingServer(testutil.HTTPResponse{StatusCode: 200, Body: "local"}, socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	resp, err := client.Get(server.URL + "/status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: "local"}, testutil.Partial()); diff != "" {
+		t.Error(diff)
+	}
+	if diff := testutil.CompareValues(server.Requests()[0].URL, "/status"); diff != "" {
+		t.Error(diff)
+	}
+}
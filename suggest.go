@@ -0,0 +1,58 @@
+package testutil
+
+import "fmt"
+
+// editDistance returns the Levenshtein distance between a and b
+// counted in runes.
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// closestMatch returns the index of the candidate closest to target
+// by edit distance or -1 if none is close enough to be a plausible
+// typo, that is within a third of the length of target.
+func closestMatch(target string, candidates []string) int {
+	best, bestDist := -1, 0
+	for i, c := range candidates {
+		if d := editDistance(target, c); best == -1 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	if best == -1 || bestDist > len([]rune(target))/3 {
+		return -1
+	}
+	return best
+}
+
+// suggestions returns a "did you mean" line for every missing element
+// which has a close match among the unexpected ones.
+func suggestions[T any](missing []T, unexpected []T) []string {
+	candidates := make([]string, len(unexpected))
+	for i, u := range unexpected {
+		candidates[i] = fmt.Sprint(u)
+	}
+	lines := []string{}
+	for _, m := range missing {
+		if i := closestMatch(fmt.Sprint(m), candidates); i >= 0 {
+			lines = append(lines, fmt.Sprintf("missing %v, did you mean %v?", m, unexpected[i]))
+		}
+	}
+	return lines
+}
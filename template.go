@@ -0,0 +1,95 @@
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template is implemented by both text/template and html/template
+// templates.
+type Template interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// ReportTemplateAction makes CheckTemplate report which part of the
+// template produced the output where it starts to differ from what
+// is expected. It only works with text/template templates.
+func ReportTemplateAction() Option {
+	return func(o *options) {
+		o.reportTemplateAction = true
+	}
+}
+
+// CheckTemplate executes tmpl with data and compares the output to
+// want with CompareStrings, which is passed opts.
+func CheckTemplate(tmpl Template, data interface{}, want string, opts ...Option) string {
+	o := newOptions(opts)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Sprintf("could not execute template: %v", err)
+	}
+	got := buf.String()
+	diff := CompareStrings(got, want, opts...)
+	if diff == "" {
+		return ""
+	}
+	diff = "template output is not expected, " + diff
+	if t, ok := tmpl.(*texttemplate.Template); ok && o.reportTemplateAction {
+		diff += "\n" + templateActionAt(t, data, firstDifference(got, want))
+	}
+	return diff
+}
+
+// templateActionAt finds the top level node of the template whose
+// output covers index by executing ever longer prefixes of the
+// template.
+func templateActionAt(t *texttemplate.Template, data interface{}, index int) string {
+	if t.Tree == nil {
+		return "could not find the template action which produced the difference, template has no parse tree"
+	}
+	nodes := t.Tree.Root.Nodes
+	for i, node := range nodes {
+		clone, err := t.Clone()
+		if err != nil {
+			return fmt.Sprintf("could not find the template action which produced the difference: %v", err)
+		}
+		tree := t.Tree.Copy()
+		tree.Root.Nodes = tree.Root.Nodes[:i+1]
+		if _, err := clone.AddParseTree(t.Name(), tree); err != nil {
+			return fmt.Sprintf("could not find the template action which produced the difference: %v", err)
+		}
+		var buf bytes.Buffer
+		// An error here will also have happened when executing the
+		// whole template so it can't happen.
+		clone.Execute(&buf, data)
+		if buf.Len() > index || i == len(nodes)-1 {
+			location, _ := t.Tree.ErrorContext(node)
+			src := node.String()
+			if len(src) > 60 {
+				src = src[:60] + "..."
+			}
+			return fmt.Sprintf("the difference starts in the output of %s at %s", strings.TrimSpace(src), location)
+		}
+	}
+	return "the difference starts after the end of the template output"
+}
+
+// firstDifference returns the index of the first byte where got and
+// want differ or -1 if they are equal.
+func firstDifference(got string, want string) int {
+	for i := 0; i < len(got) && i < len(want); i++ {
+		if got[i] != want[i] {
+			return i
+		}
+	}
+	if len(got) == len(want) {
+		return -1
+	}
+	if len(got) < len(want) {
+		return len(got)
+	}
+	return len(want)
+}
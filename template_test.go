@@ -0,0 +1,93 @@
+package testutil_test
+
+import (
+	htmltemplate "html/template"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCheckTemplate tests that the expected diff is generated when
+// checking the output of a template.
+func TestCheckTemplate(t *testing.T) {
+	textTmpl := texttemplate.Must(texttemplate.New("greeting").Parse("Hello {{.Name}}!\n{{range .Items}}- {{.}}\n{{end}}Bye"))
+	htmlTmpl := htmltemplate.Must(htmltemplate.New("page").Parse("<p>{{.Name}}</p>"))
+	data := map[string]interface{}{
+		"Name":  "Bob",
+		"Items": []string{"one", "two"},
+	}
+	tests := []struct {
+		name     string
+		tmpl     testutil.Template
+		want     string
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "output matches",
+			tmpl:     textTmpl,
+			want:     "Hello Bob!\n- one\n- two\nBye",
+			wantDiff: "",
+		},
+		{
+			name:     "html output matches",
+			tmpl:     htmlTmpl,
+			want:     "<p>Bob</p>",
+			wantDiff: "",
+		},
+		{
+			name:     "options are passed on",
+			tmpl:     textTmpl,
+			want:     "HELLO BOB!\n- ONE\n- TWO\nBYE",
+			opts:     []testutil.Option{testutil.IgnoreCase()},
+			wantDiff: "",
+		},
+		{
+			name: "output differs",
+			tmpl: textTmpl,
+			want: "Hello Bob!\n- one\n- three\nBye",
+			wantDiff: `template output is not expected, strings differ at index 20 (line 3, column 4), from that index on:
+##### got string #####
+wo
+Bye
+##### want string #####
+hree
+Bye`,
+		},
+		{
+			name: "report action",
+			tmpl: textTmpl,
+			want: "Hello Bob!\n- one\n- three\nBye",
+			opts: []testutil.Option{testutil.ReportTemplateAction()},
+			wantDiff: `template output is not expected, strings differ at index 20 (line 3, column 4), from that index on:
+##### got string #####
+wo
+Bye
+##### want string #####
+hree
+Bye
+the difference starts in the output of {{range .Items}}- {{.}}
+{{end}} at greeting:2:8`,
+		},
+		{
+			name: "report action for text",
+			tmpl: textTmpl,
+			want: "Hello Bob!\n- one\n- two\nGoodbye",
+			opts: []testutil.Option{testutil.ReportTemplateAction()},
+			wantDiff: `template output is not expected, strings differ at index 23 (line 4, column 1), from that index on:
+##### got string #####
+Bye
+##### want string #####
+Goodbye
+the difference starts in the output of Bye at greeting:3:7`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.CheckTemplate(test.tmpl, data, test.want, test.opts...), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
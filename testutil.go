@@ -6,7 +6,10 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"strings"
+	"testing"
+	"unicode/utf8"
 )
 
 // CheckErrHasMsg checks that the received error contains the message
@@ -54,22 +57,95 @@ func MustReadAll(r io.Reader) string {
 
 // CompareStrings compares two strings and returns a string detailing
 // where they differ or "" if they don't. Useful for when two large
-// strings need to be compared.
-func CompareStrings(got string, want string) string {
-	for i := range want {
+// strings need to be compared. Strings are compared rune by rune so
+// the reported index counts runes and a multi-byte character is never
+// split in the output. For multi-line strings the line and column are
+// reported as well. If the strings only differ in whitespace or
+// other invisible characters those characters are made visible in the
+// diff. Options like ByLine change how the difference is reported and
+// options like IgnoreCase normalize both strings before they are
+// compared. Large diffs can be capped with MaxDiffBytes and colored
+// with Color.
+func CompareStrings(got string, want string, opts ...Option) string {
+	o := newOptions(opts)
+	return truncateDiff(compareStrings(normalizeString(got, o), normalizeString(want, o), o), o)
+}
+
+func compareStrings(got string, want string, o options) string {
+	p := newPalette(o)
+	if o.placeholders {
+		return CompareWithPlaceholders(got, want)
+	} else if o.unifiedDiff {
+		return unifiedDiff(got, want, p)
+	} else if o.byLine {
+		return compareByLine(got, want, p)
+	}
+	show, note := func(s string) string { return s }, ""
+	if got != want && onlyInvisibleDiffers(got, want) {
+		show, note = visualizeInvisible, "\n(the strings only differ in invisible characters which are shown as: "+invisibleLegend+")"
+	}
+	rest := show
+	if o.contextWindow > 0 {
+		rest = func(s string) string { return show(firstRunes(s, o.contextWindow)) }
+	}
+	i := 0
+	for i < len(want) {
 		if i > len(got)-1 {
-			return fmt.Sprintf("got a shorter string than what we wanted (characters match otherwise) and the missing characters are: %s", want[i:])
+			return fmt.Sprintf("got a shorter string than what we wanted (characters match otherwise) and the missing characters are: %s", p.added(rest(want[i:]))) + note
 		}
-		if got[i] != want[i] {
-			return fmt.Sprintf("strings differ at index %d, from that index on:\n##### got string #####\n%s\n##### want string #####\n%s", i, got[i:], want[i:])
+		_, gotSize := utf8.DecodeRuneInString(got[i:])
+		_, wantSize := utf8.DecodeRuneInString(want[i:])
+		if got[i:i+gotSize] != want[i:i+wantSize] {
+			if o.contextWindow > 0 {
+				return fmt.Sprintf("strings differ at %s, showing up to %d characters either side:\n##### got string #####\n%s\n##### want string #####\n%s", runeIndex(want, i), o.contextWindow, p.removed(show(around(got, i, o.contextWindow))), p.added(show(around(want, i, o.contextWindow)))) + note
+			}
+			return fmt.Sprintf("strings differ at %s, from that index on:\n##### got string #####\n%s\n##### want string #####\n%s", runeIndex(want, i), p.removed(show(got[i:])), p.added(show(want[i:]))) + note
 		}
+		i += wantSize
 	}
 	if len(want) < len(got) {
-		return fmt.Sprintf("got a longer string than what we wanted (characters match otherwise) and the extra characters are: %s", got[len(want):])
+		return fmt.Sprintf("got a longer string than what we wanted (characters match otherwise) and the extra characters are: %s", p.removed(rest(got[len(want):]))) + note
 	}
 	return ""
 }
 
+// normalizeString applies the normalizations requested by options
+// to s.
+func normalizeString(s string, o options) string {
+	for _, n := range o.normalizers {
+		s = n(s)
+	}
+	if o.normalizeLineEndings {
+		s = strings.Replace(s, "\r\n", "\n", -1)
+	}
+	if o.trimSpace {
+		s = strings.TrimSpace(s)
+	}
+	if o.ignoreCase {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// runeIndex describes the position of byte offset i in s by its rune
+// index, mentioning the byte offset too when the two differ and the
+// line and column when s has several lines.
+func runeIndex(s string, i int) string {
+	n := utf8.RuneCountInString(s[:i])
+	extra := []string{}
+	if n != i {
+		extra = append(extra, fmt.Sprintf("byte offset %d", i))
+	}
+	if line := strings.Count(s[:i], "\n") + 1; line > 1 {
+		column := utf8.RuneCountInString(s[strings.LastIndex(s[:i], "\n")+1:i]) + 1
+		extra = append(extra, fmt.Sprintf("line %d, column %d", line, column))
+	}
+	if len(extra) > 0 {
+		return fmt.Sprintf("index %d (%s)", n, strings.Join(extra, ", "))
+	}
+	return fmt.Sprintf("index %d", n)
+}
+
 // HTTPRequest represents the fields of a HTTP request which I think
 // are most important for checking in a unit test. It also can be
 // marshalled to JSON the intent being that you can use it to check if
@@ -88,38 +164,193 @@ type HTTPRequest struct {
 	URL    string      `json:"url"`
 	Header http.Header `json:"header"`
 	Body   string      `json:"body"`
+
+	// Host, ServerName and RemoteAddr are connection level fields
+	// which are only checked when set. Host is checked against the
+	// Host header, which the URL of a request received by a server
+	// doesn't include, and ServerName against the name the client
+	// asked for with TLS SNI. RemoteAddr is a regular expression
+	// since clients connect from random ports.
+	Host       string `json:"host,omitempty"`
+	ServerName string `json:"server_name,omitempty"`
+	RemoteAddr string `json:"remote_addr,omitempty"`
+
+	// Path is checked against the path of the request URL when
+	// set. Path, Host and ServerName are glob patterns, see
+	// MatchGlob, so "/users/*/orders" matches any user's orders and
+	// "127.0.0.1:*" matches a httptest server on any port. Like in
+	// MatchGlob "*" also matches "/", so "/files/*" matches
+	// "/files/a/b.txt". When URL
+	// is empty and either is set the URL itself isn't checked.
+	Path string `json:"path,omitempty"`
+
+	// Cookies maps the names of cookies which should be sent in
+	// the Cookie header to their values. Other cookies are
+	// ignored.
+	Cookies map[string]string `json:"cookies,omitempty"`
+
+	// BasicAuth, when set, is checked against the credentials in
+	// the Authorization header.
+	BasicAuth *BasicAuth `json:"basic_auth,omitempty"`
+
+	// BearerClaims, when set, requires the Authorization header to
+	// have a bearer token which is a JWT with these claims. Other
+	// claims are ignored. The signature is only verified if
+	// BearerKey is set to a []byte secret for HS256, HS384 and
+	// HS512, an *rsa.PublicKey for RS256, RS384 and RS512 or an
+	// *ecdsa.PublicKey for ES256, ES384 and ES512.
+	BearerClaims map[string]interface{} `json:"bearer_claims,omitempty"`
+	BearerKey    interface{}            `json:"-"`
+
+	// ClientCert, when set, is checked against the certificate the
+	// client presented to a server using mutual TLS.
+	ClientCert *ClientCert `json:"client_cert,omitempty"`
+
+	// ContentLength and TransferEncoding are only checked when
+	// set. A ContentLength of -1 means the length was unknown.
+	ContentLength    int64    `json:"content_length,omitempty"`
+	TransferEncoding []string `json:"transfer_encoding,omitempty"`
+
+	// When set these matchers are used instead of the literal
+	// fields above. HasPrefix and Regexp make good URLMatchers for
+	// URLs with random ports or volatile query parameters.
+	MethodMatcher  Matcher            `json:"-"`
+	URLMatcher     Matcher            `json:"-"`
+	HeaderMatchers map[string]Matcher `json:"-"`
+	BodyMatcher    Matcher            `json:"-"`
 }
 
 // CheckHTTPRequest checks to make sure that a http.Request has the
-// fields we're looking for.
-func CheckHTTPRequest(got *http.Request, want HTTPRequest) string {
-	diffs := []string{}
-	for headerName := range want.Header {
-		if got, want := got.Header.Get(headerName), want.Header.Get(headerName); got != want {
-			diffs = append(diffs, fmt.Sprintf("header %q got value %q, want %q", headerName, got, want))
+// fields we're looking for. URLs are compared like CompareURLs does
+// so the order of query parameters doesn't matter. Options like
+// NormalizeURLs can be passed to loosen the comparison. JSON bodies
+// are compared like CompareJSON does. The body of got is restored
+// afterwards so it can still be read.
+func CheckHTTPRequest(got *http.Request, want HTTPRequest, opts ...Option) string {
+	return DiffHTTPRequest(got, want, opts...).report("request does not match what is expected:\n")
+}
+
+// DiffHTTPRequest is like CheckHTTPRequest but returns the
+// differences as Diffs.
+func DiffHTTPRequest(got *http.Request, want HTTPRequest, opts ...Option) Diffs {
+	o := newOptions(opts)
+	diffs := Diffs{}
+	headerDiffs(&diffs, "header", got.Header, want.Header, o)
+	for headerName, m := range want.HeaderMatchers {
+		if o.ignoredHeaders[http.CanonicalHeaderKey(headerName)] {
+			continue
+		}
+		path, got := fmt.Sprintf("header %q", headerName), got.Header.Get(headerName)
+		if diff := matchField(path, m, got); diff != "" {
+			diffs.add(path, DiffChanged, got, nil, diff)
 		}
 	}
-	if got, want := got.Method, want.Method; got != want {
-		diffs = append(diffs, fmt.Sprintf("got method %q, want %q", got, want))
+	if o.strictHeaders {
+		unexpectedHeaderDiffs(&diffs, "header", got.Header, want.Header, want.HeaderMatchers, o.allowedHeaders, o.ignoredHeaders)
 	}
-	if got, want := got.URL.String(), want.URL; got != want {
-		diffs = append(diffs, fmt.Sprintf("got url:\n  %q\nwant:\n  %q", got, want))
+	requestCookieDiffs(&diffs, got, want.Cookies)
+	basicAuthDiffs(&diffs, got, want.BasicAuth)
+	bearerTokenDiffs(&diffs, got, want.BearerClaims, want.BearerKey)
+	clientCertDiffs(&diffs, got, want.ClientCert)
+	if want.MethodMatcher != nil {
+		if diff := matchField("method", want.MethodMatcher, got.Method); diff != "" {
+			diffs.add("method", DiffChanged, got.Method, nil, diff)
+		}
+	} else if got, want := got.Method, want.Method; got != want && !(o.partial && want == "") {
+		diffs.add("method", DiffChanged, got, want, fmt.Sprintf("got method %q, want %q", got, want))
 	}
-	if diff := CompareStrings(MustReadAll(got.Body), want.Body); diff != "" {
-		diffs = append(diffs, "body is not expected, "+diff)
+	checkURL := want.URL != "" || want.Path == "" && want.Host == "" && !o.partial
+	if want.URLMatcher != nil {
+		got := normalizeURL(got.URL.String(), o)
+		if diff := matchField("url", want.URLMatcher, got); diff != "" {
+			diffs.add("url", DiffChanged, got, nil, diff)
+		}
+	} else if got, want := normalizeURL(got.URL.String(), o), normalizeURL(want.URL, o); checkURL && got != want {
+		if parts, err := urlDiffs(got, want); err != nil || len(parts) > 0 {
+			msg := fmt.Sprintf("got url:\n  %q\nwant:\n  %q", got, want)
+			if len(parts) > 0 {
+				msg += "\nwhich differ in:\n" + indent(strings.Join(parts, "\n"), "  ")
+			}
+			diffs.add("url", DiffChanged, got, want, msg)
+		}
 	}
-	if len(diffs) > 0 {
-		return "request does not match what is expected:\n" + strings.Join(diffs, "\n")
+	if !o.ignoreBody && (!o.partial || want.Body != "" || want.BodyMatcher != nil) {
+		bodyDiffs(&diffs, got.Header, &got.Body, want.Body, want.BodyMatcher, opts)
 	}
-	return ""
+	if want.Host != "" {
+		host := got.Host
+		if host == "" {
+			host = got.URL.Host
+		}
+		if !matchPattern(want.Host, host) {
+			diffs.add("host", DiffChanged, host, want.Host, fmt.Sprintf("got host %q, want %q", host, want.Host))
+		}
+	}
+	if want.ServerName != "" {
+		if got.TLS == nil {
+			diffs.add("server name", DiffMissing, nil, want.ServerName, fmt.Sprintf("request was not made over TLS, want server name %q", want.ServerName))
+		} else if !matchPattern(want.ServerName, got.TLS.ServerName) {
+			diffs.add("server name", DiffChanged, got.TLS.ServerName, want.ServerName, fmt.Sprintf("got TLS server name %q, want %q", got.TLS.ServerName, want.ServerName))
+		}
+	}
+	if want.Path != "" {
+		if got := got.URL.Path; !matchPattern(want.Path, got) {
+			diffs.add("path", DiffChanged, got, want.Path, fmt.Sprintf("got path %q, want %q", got, want.Path))
+		}
+	}
+	framingDiffs(&diffs, got.ContentLength, got.TransferEncoding, want.ContentLength, want.TransferEncoding)
+	if want.RemoteAddr != "" {
+		if diff := matchField("remote address", Regexp(want.RemoteAddr), got.RemoteAddr); diff != "" {
+			diffs.add("remote address", DiffChanged, got.RemoteAddr, want.RemoteAddr, diff)
+		}
+	}
+	if o.checkForwardedHeaders {
+		for _, diff := range forwardedHeaderDiffs(got.Header) {
+			diffs.add("forwarded headers", DiffChanged, nil, nil, diff)
+		}
+	}
+	return diffs
 }
 
 // HTTPResponse contains the fields on a http.Response we are
 // interested in checking.
 type HTTPResponse struct {
-	StatusCode int
-	Header     http.Header
-	Body       string
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+
+	// BodyLength and BodySHA256, a hex encoded digest, check a body
+	// without holding it in memory which suits large downloads.
+	// When either is set Body is not checked and the body is
+	// consumed rather than restored.
+	BodyLength int64  `json:"body_length,omitempty"`
+	BodySHA256 string `json:"body_sha256,omitempty"`
+
+	// Status, like "418 I'm a teapot", and Proto, like "HTTP/2.0",
+	// are only checked when set.
+	Status string `json:"status,omitempty"`
+	Proto  string `json:"proto,omitempty"`
+
+	// ContentLength and TransferEncoding are only checked when
+	// set. A ContentLength of -1 means the length was unknown.
+	ContentLength    int64    `json:"content_length,omitempty"`
+	TransferEncoding []string `json:"transfer_encoding,omitempty"`
+
+	// Trailer is checked against the trailers sent after a chunked
+	// body, like Header is against the headers.
+	Trailer http.Header `json:"trailer,omitempty"`
+
+	// Cookies are checked against the Set-Cookie headers. Name and
+	// Value are always checked but Path, Domain, MaxAge and
+	// SameSite are only checked when set and Secure and HttpOnly
+	// only when true. Other cookies are ignored.
+	Cookies []*http.Cookie `json:"cookies,omitempty"`
+
+	// When set these matchers are used instead of the literal
+	// fields above.
+	StatusCodeMatcher Matcher            `json:"-"`
+	HeaderMatchers    map[string]Matcher `json:"-"`
+	BodyMatcher       Matcher            `json:"-"`
 }
 
 // CheckHTTPResponse compares two *http.Responses for equailty. It
@@ -130,22 +361,128 @@ type HTTPResponse struct {
 // 	- Body
 //
 // It will probably get used in end-to-end tests to make sure that a
-// response received from an API is expected.
-func CheckHTTPResponse(gotResp *http.Response, wantResp HTTPResponse) string {
-	diffs := []string{}
-	if got, want := gotResp.StatusCode, wantResp.StatusCode; got != want {
-		diffs = append(diffs, fmt.Sprintf("got status code %d, want %d", got, want))
+// response received from an API is expected. JSON bodies are compared
+// like CompareJSON does and options like UnifiedDiff change how other
+// differing bodies are reported. The body of gotResp is restored
+// afterwards so it can still be read.
+func CheckHTTPResponse(gotResp *http.Response, wantResp HTTPResponse, opts ...Option) string {
+	return DiffHTTPResponse(gotResp, wantResp, opts...).report("response does not match what is expected:\n")
+}
+
+// DiffHTTPResponse is like CheckHTTPResponse but returns the
+// differences as Diffs.
+func DiffHTTPResponse(gotResp *http.Response, wantResp HTTPResponse, opts ...Option) Diffs {
+	o := newOptions(opts)
+	diffs := Diffs{}
+	if wantResp.StatusCodeMatcher != nil {
+		if diff := matchField("status code", wantResp.StatusCodeMatcher, gotResp.StatusCode); diff != "" {
+			diffs.add("status code", DiffChanged, gotResp.StatusCode, nil, diff)
+		}
+	} else if got, want := gotResp.StatusCode, wantResp.StatusCode; got != want && !(o.partial && want == 0) {
+		diffs.add("status code", DiffChanged, got, want, fmt.Sprintf("got status code %d, want %d", got, want))
+	}
+	if wantResp.Status != "" && gotResp.Status != wantResp.Status {
+		diffs.add("status", DiffChanged, gotResp.Status, wantResp.Status, fmt.Sprintf("got status %q, want %q", gotResp.Status, wantResp.Status))
 	}
-	for headerName := range wantResp.Header {
-		if got, want := gotResp.Header.Get(headerName), wantResp.Header.Get(headerName); got != want {
-			diffs = append(diffs, fmt.Sprintf("header %q got value %q, want %q", headerName, got, want))
+	if wantResp.Proto != "" && gotResp.Proto != wantResp.Proto {
+		diffs.add("protocol", DiffChanged, gotResp.Proto, wantResp.Proto, fmt.Sprintf("got protocol %q, want %q", gotResp.Proto, wantResp.Proto))
+	}
+	headerDiffs(&diffs, "header", gotResp.Header, wantResp.Header, o)
+	for headerName, m := range wantResp.HeaderMatchers {
+		if o.ignoredHeaders[http.CanonicalHeaderKey(headerName)] {
+			continue
+		}
+		path, got := fmt.Sprintf("header %q", headerName), gotResp.Header.Get(headerName)
+		if diff := matchField(path, m, got); diff != "" {
+			diffs.add(path, DiffChanged, got, nil, diff)
 		}
 	}
-	if diff := CompareStrings(MustReadAll(gotResp.Body), wantResp.Body); diff != "" {
-		diffs = append(diffs, "body is not expected, "+diff)
+	if o.strictHeaders {
+		unexpectedHeaderDiffs(&diffs, "header", gotResp.Header, wantResp.Header, wantResp.HeaderMatchers, o.allowedHeaders, o.ignoredHeaders)
 	}
-	if len(diffs) > 0 {
-		return "response does not match what is expected:\n" + strings.Join(diffs, "\n")
+	responseCookieDiffs(&diffs, gotResp, wantResp.Cookies)
+	if wantResp.BodyLength != 0 || wantResp.BodySHA256 != "" {
+		bodyDigestDiffs(&diffs, gotResp.Body, wantResp.BodyLength, wantResp.BodySHA256)
+	} else if !o.ignoreBody && (!o.partial || wantResp.Body != "" || wantResp.BodyMatcher != nil) {
+		bodyDiffs(&diffs, gotResp.Header, &gotResp.Body, wantResp.Body, wantResp.BodyMatcher, opts)
 	}
-	return ""
+	framingDiffs(&diffs, gotResp.ContentLength, gotResp.TransferEncoding, wantResp.ContentLength, wantResp.TransferEncoding)
+	// Trailers are only known once the whole body has been read.
+	headerDiffs(&diffs, "trailer", gotResp.Trailer, wantResp.Trailer, o)
+	if o.strict {
+		unexpectedHeaderDiffs(&diffs, "trailer", gotResp.Trailer, wantResp.Trailer, nil, nil, o.ignoredHeaders)
+	}
+	return diffs
+}
+
+// ServeAndCheck builds a request from req, serves it with handler
+// and checks that the response matches want. It collapses the usual
+// boilerplate of a handler unit test into one call.
+func ServeAndCheck(handler http.Handler, req HTTPRequest, want HTTPResponse) string {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, newServerRequest(req))
+	return CheckRecordedResponse(rec, want)
+}
+
+// CheckRecordedResponse is CheckHTTPResponse for the response a
+// handler wrote to rec, saving the call to rec.Result in every
+// handler unit test.
+func CheckRecordedResponse(rec *httptest.ResponseRecorder, want HTTPResponse, opts ...Option) string {
+	return CheckHTTPResponse(rec.Result(), want, opts...)
+}
+
+// newServerRequest builds a request suitable for passing to a
+// http.Handler. The Host and RemoteAddr of req are kept, if set, so a
+// recorded request looks the same as when it was received.
+func newServerRequest(req HTTPRequest) *http.Request {
+	u := req.URL
+	if u == "" {
+		u = "/"
+	}
+	r := httptest.NewRequest(req.Method, u, strings.NewReader(req.Body))
+	if req.Host != "" {
+		r.Host = req.Host
+	}
+	if req.RemoteAddr != "" {
+		r.RemoteAddr = req.RemoteAddr
+	}
+	for name, values := range req.Header {
+		r.Header[name] = append([]string(nil), values...)
+	}
+	return r
+}
+
+// DoAndCheck builds a request from req, sends it with client (or
+// http.DefaultClient if client is nil) and reports an error on t if
+// the response does not match want. It makes simple end-to-end tests
+// a few lines long.
+func DoAndCheck(t testing.TB, client *http.Client, req HTTPRequest, want HTTPResponse) {
+	t.Helper()
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r, err := newClientRequest(req)
+	if err != nil {
+		t.Errorf("could not build request: %v", err)
+		return
+	}
+	resp, err := client.Do(r)
+	if err != nil {
+		t.Errorf("could not send request: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	Report(t, "CheckHTTPResponse", requestEndpoint(req), CheckHTTPResponse(resp, want))
+}
+
+// newClientRequest builds a request suitable for sending.
+func newClientRequest(req HTTPRequest) (*http.Request, error) {
+	r, err := http.NewRequest(req.Method, req.URL, strings.NewReader(req.Body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range req.Header {
+		r.Header[name] = append([]string(nil), values...)
+	}
+	return r, nil
 }
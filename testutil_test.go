@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
 	"testing"
@@ -104,6 +105,38 @@ er buddy`,
 			wantStr:  "",
 			wantDiff: "got a longer string than what we wanted (characters match otherwise) and the extra characters are: some non-empty string",
 		},
+		{
+			name:    "multi-byte characters",
+			gotStr:  "naïve café",
+			wantStr: "naïve cafè",
+			wantDiff: `strings differ at index 9 (byte offset 10), from that index on:
+##### got string #####
+é
+##### want string #####
+è`,
+		},
+		{
+			name:    "runes sharing a leading byte",
+			gotStr:  "é",
+			wantStr: "è",
+			wantDiff: `strings differ at index 0, from that index on:
+##### got string #####
+é
+##### want string #####
+è`,
+		},
+		{
+			name:    "multi-line strings",
+			gotStr:  "first line\nsecond line\nthird",
+			wantStr: "first line\nsecond lime\nthird",
+			wantDiff: `strings differ at index 20 (line 2, column 10), from that index on:
+##### got string #####
+ne
+third
+##### want string #####
+me
+third`,
+		},
 		{
 			name:     "non-empty strings match",
 			gotStr:   "keep on the sunny side of life",
@@ -159,6 +192,8 @@ got url:
   "http://hello.com"
 want:
   "http://hello-there.com"
+which differ in:
+  host: got "hello.com", want "hello-there.com"
 body is not expected, strings differ at index 0, from that index on:
 ##### got string #####
 hello buddy!
@@ -258,3 +293,188 @@ body is not expected, strings differ at index 11, from that index on:
 		})
 	}
 }
+
+// TestServeAndCheck tests that a request is served by a handler and
+// the response checked.
+func TestServeAndCheck(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Header.Get("X-User") + " " + testutil.MustReadAll(r.Body)))
+	})
+	tests := []struct {
+		name     string
+		req      testutil.HTTPRequest
+		wantResp testutil.HTTPResponse
+		wantDiff string
+	}{
+		{
+			name: "response matches",
+			req: testutil.HTTPRequest{
+				Method: "POST",
+				URL:    "/users",
+				Header: http.Header{"X-User": {"bob"}},
+				Body:   "hi",
+			},
+			wantResp: testutil.HTTPResponse{
+				StatusCode: 201,
+				Header:     http.Header{"Content-Type": {"text/plain"}},
+				Body:       "POST /users bob hi",
+			},
+			wantDiff: "",
+		},
+		{
+			name: "response does not match",
+			req: testutil.HTTPRequest{
+				Method: "GET",
+				URL:    "/users",
+			},
+			wantResp: testutil.HTTPResponse{
+				StatusCode: 200,
+				Body:       "GET /users  ",
+			},
+			wantDiff: "response does not match what is expected:\ngot status code 201, want 200",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.ServeAndCheck(handler, test.req, test.wantResp), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+// TestDoAndCheck tests that a request is built, sent and the response
+// checked in one call.
+func TestDoAndCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method + " " + r.URL.Path + " " + r.Header.Get("X-User") + " " + testutil.MustReadAll(r.Body)))
+	}))
+	defer server.Close()
+	testutil.DoAndCheck(t, nil, testutil.HTTPRequest{
+		Method: "PUT",
+		URL:    server.URL + "/users/1",
+		Header: http.Header{"X-User": {"bob"}},
+		Body:   "hi",
+	}, testutil.HTTPResponse{
+		StatusCode: 200,
+		Body:       "PUT /users/1 bob hi",
+	})
+}
+
+// TestCheckHTTPJSONBody tests that JSON bodies are compared
+// semantically.
+func TestCheckHTTPJSONBody(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"name": "bob", "age": 30.0}`)),
+	}
+	if got, want := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: `{"age":30,"name":"bob"}`}), ""; got != want {
+		t.Errorf("got diff:\n%s", got)
+	}
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Scheme: "http", Host: "hello.com"},
+		Body:   ioutil.NopCloser(strings.NewReader(`{"name": "bob", "tags": ["a"]}`)),
+	}
+	wantDiff := `request does not match what is expected:
+body is not expected, JSON does not match:
+$.tags[0]: got "a", want "b"`
+	if got, want := testutil.CheckHTTPRequest(req, testutil.HTTPRequest{Method: "POST", URL: "http://hello.com", Body: `{"tags":["b"],"name":"bob"}`}, testutil.JSONBody()), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+}
+
+// TestCheckHTTPRequestHostAndPath tests that the host and path of a
+// request can be checked with wildcards instead of the whole URL.
+func TestCheckHTTPRequestHostAndPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		wantReq  testutil.HTTPRequest
+		wantDiff string
+	}{
+		{
+			name:     "wildcards match",
+			wantReq:  testutil.HTTPRequest{Method: "GET", Host: "127.0.0.1:*", Path: "/users/*/orders"},
+			wantDiff: "",
+		},
+		{
+			name:    "wildcards do not match",
+			wantReq: testutil.HTTPRequest{Method: "GET", Host: "localhost:*", Path: "/users/*/invoices"},
+			wantDiff: `request does not match what is expected:
+got host "127.0.0.1:54321", want "localhost:*"
+got path "/users/42/orders", want "/users/*/invoices"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotReq := &http.Request{
+				Method: "GET",
+				URL:    &url.URL{Scheme: "http", Host: "127.0.0.1:54321", Path: "/users/42/orders"},
+				Body:   ioutil.NopCloser(strings.NewReader("")),
+			}
+			if got, want := testutil.CheckHTTPRequest(gotReq, test.wantReq), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCheckHTTPRestoresBody tests that checking a request or response
+// leaves its body to be read again.
+func TestCheckHTTPRestoresBody(t *testing.T) {
+	req := testutil.MustNewHTTPRequest("POST", "http://hello.com", strings.NewReader("hello buddy!"))
+	if diff := testutil.CheckHTTPRequest(req, testutil.HTTPRequest{Method: "POST", URL: "http://hello.com", Body: "hello buddy!"}); diff != "" {
+		t.Error(diff)
+	}
+	if got, want := testutil.MustReadAll(req.Body), "hello buddy!"; got != want {
+		t.Errorf("got request body %q after checking it, want %q", got, want)
+	}
+	resp := &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader("hello buddy!"))}
+	if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: "goodbye"}); diff == "" {
+		t.Error("got no diff for a different body")
+	}
+	if got, want := testutil.MustReadAll(resp.Body), "hello buddy!"; got != want {
+		t.Errorf("got response body %q after checking it, want %q", got, want)
+	}
+}
+
+// TestCheckHTTPResponseStatusAndProto tests that the status line and
+// protocol are only checked when wanted.
+func TestCheckHTTPResponseStatusAndProto(t *testing.T) {
+	newResp := func() *http.Response {
+		return &http.Response{
+			Status:     "200 Everything Is Fine",
+			StatusCode: 200,
+			Proto:      "HTTP/1.1",
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}
+	}
+	if diff := testutil.CheckHTTPResponse(newResp(), testutil.HTTPResponse{StatusCode: 200}); diff != "" {
+		t.Error(diff)
+	}
+	wantDiff := `response does not match what is expected:
+got status "200 Everything Is Fine", want "200 OK"
+got protocol "HTTP/1.1", want "HTTP/2.0"`
+	if got, want := testutil.CheckHTTPResponse(newResp(), testutil.HTTPResponse{StatusCode: 200, Status: "200 OK", Proto: "HTTP/2.0"}), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+}
+
+// TestCheckRecordedResponse tests that a response recorder can be
+// checked directly.
+func TestCheckRecordedResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", "application/json")
+	rec.WriteHeader(http.StatusAccepted)
+	rec.Write([]byte(`{"status": "queued"}`))
+	wantDiff := `response does not match what is expected:
+got status code 202, want 200
+body is not expected, JSON does not match:
+$.status: got "queued", want "done"`
+	if got, want := testutil.CheckRecordedResponse(rec, testutil.HTTPResponse{StatusCode: 200, Body: `{"status":"done"}`}), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+}
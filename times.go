@@ -0,0 +1,40 @@
+package testutil
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// TimeTolerance makes CompareValues treat two time.Time values as
+// equal if they are no more than d apart. Times are always compared
+// as instants so the same moment in different time zones is equal.
+func TimeTolerance(d time.Duration) Option {
+	return func(o *options) {
+		o.timeTolerance = d
+	}
+}
+
+// CompareTimes checks that got is no more than within away from want
+// and returns a string detailing by how much it is off or "" if it
+// isn't. Times are compared as instants, so time zones don't matter,
+// and are shown in UTC. It is useful for fields set with time.Now().
+func CompareTimes(got time.Time, want time.Time, within time.Duration) string {
+	off := got.Sub(want)
+	if off < 0 {
+		off = -off
+	}
+	if off <= within {
+		return ""
+	}
+	if within == 0 {
+		return fmt.Sprintf("got time %s, want %s (off by %v)", formatTime(got), formatTime(want), off)
+	}
+	return fmt.Sprintf("got time %s, want %s within %v (off by %v)", formatTime(got), formatTime(want), within, off)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,74 @@
+package testutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareTimes tests that times are compared as instants within a
+// tolerance.
+func TestCompareTimes(t *testing.T) {
+	want := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	est := time.FixedZone("EST", -5*60*60)
+	tests := []struct {
+		name     string
+		got      time.Time
+		within   time.Duration
+		wantDiff string
+	}{
+		{
+			name:     "same instant in another zone",
+			got:      want.In(est),
+			within:   0,
+			wantDiff: "",
+		},
+		{
+			name:     "within tolerance",
+			got:      want.Add(-500 * time.Millisecond),
+			within:   time.Second,
+			wantDiff: "",
+		},
+		{
+			name:     "outside tolerance",
+			got:      want.Add(3 * time.Second).In(est),
+			within:   time.Second,
+			wantDiff: "got time 2021-03-04T05:06:10Z, want 2021-03-04T05:06:07Z within 1s (off by 3s)",
+		},
+		{
+			name:     "exact",
+			got:      want.Add(time.Millisecond),
+			within:   0,
+			wantDiff: "got time 2021-03-04T05:06:07.001Z, want 2021-03-04T05:06:07Z (off by 1ms)",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareTimes(test.got, want, test.within), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCompareValuesTimes tests that times inside structs are compared
+// as instants within the configured tolerance.
+func TestCompareValuesTimes(t *testing.T) {
+	type order struct {
+		ID        int
+		CreatedAt time.Time
+	}
+	now := time.Now()
+	got := order{ID: 1, CreatedAt: now.Add(20 * time.Millisecond).In(time.FixedZone("X", 3600))}
+	want := order{ID: 1, CreatedAt: now}
+	if diff := testutil.CompareValues(got, want, testutil.TimeTolerance(time.Second)); diff != "" {
+		t.Errorf("got diff %q, want none", diff)
+	}
+	got.CreatedAt = time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	want.CreatedAt = time.Date(2021, 3, 4, 5, 6, 9, 0, time.UTC)
+	wantDiff := "values differ:\norder.CreatedAt: got time 2021-03-04T05:06:07Z, want 2021-03-04T05:06:09Z within 1s (off by 2s)"
+	if diff := testutil.CompareValues(got, want, testutil.TimeTolerance(time.Second)); diff != wantDiff {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", diff, wantDiff)
+	}
+}
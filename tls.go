@@ -0,0 +1,110 @@
+package testutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ClientCert describes the certificate a client should present with
+// mutual TLS. Only the fields which are set are checked.
+type ClientCert struct {
+	CommonName string `json:"common_name,omitempty"`
+	// SANs are the subject alternative names: DNS names, IP
+	// addresses, email addresses and URIs in any order.
+	SANs             []string `json:"sans,omitempty"`
+	IssuerCommonName string   `json:"issuer_common_name,omitempty"`
+}
+
+// clientCertDiffs adds a diff if the leaf certificate got was sent
+// with doesn't look like want.
+func clientCertDiffs(diffs *Diffs, got *http.Request, want *ClientCert) {
+	if want == nil {
+		return
+	}
+	if got.TLS == nil || len(got.TLS.PeerCertificates) == 0 {
+		diffs.add("client certificate", DiffMissing, nil, nil, "no client certificate was presented")
+		return
+	}
+	cert := got.TLS.PeerCertificates[0]
+	if want.CommonName != "" && cert.Subject.CommonName != want.CommonName {
+		diffs.add("client certificate common name", DiffChanged, cert.Subject.CommonName, want.CommonName, fmt.Sprintf("client certificate common name: got %q, want %q", cert.Subject.CommonName, want.CommonName))
+	}
+	if want.SANs != nil {
+		sans := append([]string{}, cert.DNSNames...)
+		for _, ip := range cert.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		sans = append(sans, cert.EmailAddresses...)
+		for _, u := range cert.URIs {
+			sans = append(sans, u.String())
+		}
+		if missing, unexpected := multisetDiff(sans, want.SANs); len(missing) > 0 || len(unexpected) > 0 {
+			diffs.add("client certificate SANs", DiffChanged, sans, want.SANs, fmt.Sprintf("client certificate SANs: got %q, want %q", sans, want.SANs))
+		}
+	}
+	if want.IssuerCommonName != "" && cert.Issuer.CommonName != want.IssuerCommonName {
+		diffs.add("client certificate issuer", DiffChanged, cert.Issuer.CommonName, want.IssuerCommonName, fmt.Sprintf("client certificate issuer common name: got %q, want %q", cert.Issuer.CommonName, want.IssuerCommonName))
+	}
+}
+
+// generateTLSConfig generates a CA and a certificate it signs for
+// localhost, 127.0.0.1 and ::1. It returns a server config using the
+// certificate and a pool holding the CA for clients to trust.
+func generateTLSConfig() (*tls.Config, *x509.CertPool, error) {
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.Add(24 * time.Hour)
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating CA key: %v", err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "testutil CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating CA certificate: %v", err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing CA certificate: %v", err)
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating server key: %v", err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating server certificate: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{leafDER, caDER}, PrivateKey: leafKey}},
+	}
+	return cfg, roots, nil
+}
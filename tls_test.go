@@ -0,0 +1,80 @@
+package testutil_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCheckHTTPRequestClientCert tests that the certificate presented
+// by a client is checked.
+func TestCheckHTTPRequestClientCert(t *testing.T) {
+	cert := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "billing-service"},
+		Issuer:      pkix.Name{CommonName: "Internal CA"},
+		DNSNames:    []string{"billing.internal"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.7")},
+	}
+	tests := []struct {
+		name     string
+		state    *tls.ConnectionState
+		want     *testutil.ClientCert
+		wantDiff string
+	}{
+		{
+			name:     "certificate matches",
+			state:    &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}},
+			want:     &testutil.ClientCert{CommonName: "billing-service", SANs: []string{"10.0.0.7", "billing.internal"}, IssuerCommonName: "Internal CA"},
+			wantDiff: "",
+		},
+		{
+			name:  "certificate differs",
+			state: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}},
+			want:  &testutil.ClientCert{CommonName: "orders-service", SANs: []string{"orders.internal"}, IssuerCommonName: "Public CA"},
+			wantDiff: `request does not match what is expected:
+client certificate common name: got "billing-service", want "orders-service"
+client certificate SANs: got ["billing.internal" "10.0.0.7"], want ["orders.internal"]
+client certificate issuer common name: got "Internal CA", want "Public CA"`,
+		},
+		{
+			name:     "no certificate",
+			want:     &testutil.ClientCert{CommonName: "billing-service"},
+			wantDiff: "request does not match what is expected:\nno client certificate was presented",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := testutil.MustNewHTTPRequest("GET", "https://hello.com", strings.NewReader(""))
+			req.TLS = test.state
+			if got, want := testutil.CheckHTTPRequest(req, testutil.HTTPRequest{Method: "GET", URL: "https://hello.com", ClientCert: test.want}), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCheckHTTPRequestHostAndServerName tests that the Host header and
+// TLS server name of a request received by a server are checked.
+func TestCheckHTTPRequestHostAndServerName(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", strings.NewReader(""))
+	req.Host = "api.hello.com"
+	req.TLS = &tls.ConnectionState{ServerName: "www.hello.com"}
+	wantDiff := `request does not match what is expected:
+got host "api.hello.com", want "admin.hello.com"
+got TLS server name "www.hello.com", want "admin.hello.com"`
+	if got, want := testutil.CheckHTTPRequest(req, testutil.HTTPRequest{Method: "GET", URL: "/", Host: "admin.hello.com", ServerName: "admin.hello.com"}), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+	req.TLS = nil
+	wantDiff = `request does not match what is expected:
+request was not made over TLS, want server name "*.hello.com"`
+	if got, want := testutil.CheckHTTPRequest(req, testutil.HTTPRequest{Method: "GET", URL: "/", Host: "api.hello.com", ServerName: "*.hello.com"}), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+// Package tomltest compares TOML documents. It lives in its own
+// package so that only the users of testutil who need TOML depend on
+// a TOML parser.
+//
+// It requires github.com/BurntSushi/toml v1.6.0 or later.
+package tomltest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/lag13/testutil"
+)
+
+// CompareTOML parses two TOML documents and returns a string detailing
+// the key paths where they differ or "" if they don't. Formatting and
+// key order don't matter but types do, so 1 and 1.0 are different.
+func CompareTOML(got string, want string) string {
+	var gotDoc, wantDoc map[string]interface{}
+	if _, err := toml.Decode(got, &gotDoc); err != nil {
+		return fmt.Sprintf("could not parse got TOML: %v", err)
+	}
+	if _, err := toml.Decode(want, &wantDoc); err != nil {
+		return fmt.Sprintf("could not parse want TOML: %v", err)
+	}
+	if diffs := testutil.CompareTrees(normalize(gotDoc), normalize(wantDoc)); len(diffs) > 0 {
+		return "TOML does not match:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+// normalize converts the concrete slice types the TOML decoder can
+// produce into []interface{} so testutil.CompareTrees can walk them.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = normalize(e)
+		}
+		return m
+	case []map[string]interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = normalize(e)
+		}
+		return s
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = normalize(e)
+		}
+		return s
+	}
+	return v
+}
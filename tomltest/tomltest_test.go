@@ -0,0 +1,68 @@
+package tomltest_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+	"github.com/lag13/testutil/tomltest"
+)
+
+// TestCompareTOML tests that the expected diff is generated when
+// comparing TOML documents.
+func TestCompareTOML(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		want     string
+		wantDiff string
+	}{
+		{
+			name: "equal apart from formatting and order",
+			got: `title = "app"
+[server]
+port = 80
+host = "localhost"
+`,
+			want: `title = "app"
+server = { host = "localhost", port = 80 }
+`,
+			wantDiff: "",
+		},
+		{
+			name: "values and types differ",
+			got: `[server]
+port = "80"
+timeout = 1.0
+debug = true
+
+[[users]]
+name = "bob"
+`,
+			want: `[server]
+port = 80
+timeout = 1.5
+host = "localhost"
+
+[[users]]
+name = "alice"
+
+[[users]]
+name = "bob"
+`,
+			wantDiff: `TOML does not match:
+server: missing key "host"
+server: unexpected key "debug"
+server.port: got string "80", want int64 80
+server.timeout: got 1, want 1.5
+users: got 1 elements, want 2
+users[0].name: got "bob", want "alice"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(tomltest.CompareTOML(test.got, test.want), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
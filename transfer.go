@@ -0,0 +1,41 @@
+package testutil
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// CheckContentLength checks that the Content-Length a response
+// declared matches the size of the body actually received. The body
+// is restored after reading so it can still be checked afterwards.
+func CheckContentLength(resp *http.Response) string {
+	body, err := readAndRestore(&resp.Body)
+	if err != nil {
+		return fmt.Sprintf("could not read body with declared Content-Length %d: %v", resp.ContentLength, err)
+	}
+	if resp.ContentLength < 0 {
+		return fmt.Sprintf("response has no declared Content-Length, body was %d bytes", len(body))
+	}
+	if got, want := int64(len(body)), resp.ContentLength; got != want {
+		return fmt.Sprintf("got body of %d bytes, declared Content-Length is %d", got, want)
+	}
+	return ""
+}
+
+// CheckChunked checks whether or not a response was sent using
+// chunked transfer encoding.
+func CheckChunked(resp *http.Response, want bool) string {
+	got := false
+	for _, te := range resp.TransferEncoding {
+		if te == "chunked" {
+			got = true
+		}
+	}
+	if got != want {
+		if want {
+			return fmt.Sprintf("response was not chunked, got transfer encoding %v and Content-Length %d", resp.TransferEncoding, resp.ContentLength)
+		}
+		return "response was chunked, want it to not be"
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package testutil_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCheckContentLength tests that the expected diff is generated
+// when the declared Content-Length does not match the body.
+func TestCheckContentLength(t *testing.T) {
+	tests := []struct {
+		name          string
+		contentLength int64
+		body          string
+		wantDiff      string
+	}{
+		{
+			name:          "length matches",
+			contentLength: 5,
+			body:          "hello",
+			wantDiff:      "",
+		},
+		{
+			name:          "length differs",
+			contentLength: 10,
+			body:          "hello",
+			wantDiff:      "got body of 5 bytes, declared Content-Length is 10",
+		},
+		{
+			name:          "length not declared",
+			contentLength: -1,
+			body:          "hello",
+			wantDiff:      "response has no declared Content-Length, body was 5 bytes",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				ContentLength: test.contentLength,
+				Body:          ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			if got, want := testutil.CheckContentLength(resp), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+			if got, want := testutil.MustReadAll(resp.Body), test.body; got != want {
+				t.Errorf("body was not restored, got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// TestCheckChunked tests that chunked transfer encoding is detected.
+func TestCheckChunked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("part one"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("part two"))
+	}))
+	defer server.Close()
+	resp := testutil.MustSendHTTPRequest(testutil.MustNewHTTPRequest("GET", server.URL, nil))
+	defer resp.Body.Close()
+	if diff := testutil.CheckChunked(resp, true); diff != "" {
+		t.Error(diff)
+	}
+	if got, want := testutil.CheckChunked(resp, false), "response was chunked, want it to not be"; got != want {
+		t.Errorf("got diff %q, want %q", got, want)
+	}
+}
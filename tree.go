@@ -0,0 +1,106 @@
+package testutil
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// CompareTrees compares two documents decoded into maps with string
+// keys, []interface{} slices and scalars and returns a diff for every
+// key path where they differ, like "server.port", or nil if they
+// don't. Values of different types are never equal. It is for
+// comparing formats testutil doesn't parse itself, see the tomltest
+// package for an example.
+func CompareTrees(got interface{}, want interface{}) []string {
+	return compareTrees("", got, want)
+}
+
+// compareTrees compares two documents decoded into maps, slices and
+// scalars, like those produced by encoding/json, and returns a diff
+// for every path where they differ. Values of different types are
+// never equal.
+func compareTrees(path string, got interface{}, want interface{}) []string {
+	switch want := want.(type) {
+	case map[string]interface{}:
+		gotMap, ok := got.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: got %s, want a table", displayPath(path), describeValue(got))}
+		}
+		diffs := []string{}
+		for _, k := range sortedKeys(want) {
+			if _, ok := gotMap[k]; !ok {
+				diffs = append(diffs, fmt.Sprintf("%s: missing key %q", displayPath(path), k))
+			}
+		}
+		for _, k := range sortedKeys(gotMap) {
+			if _, ok := want[k]; !ok {
+				diffs = append(diffs, fmt.Sprintf("%s: unexpected key %q", displayPath(path), k))
+			}
+		}
+		for _, k := range sortedKeys(want) {
+			if g, ok := gotMap[k]; ok {
+				diffs = append(diffs, compareTrees(joinPath(path, k), g, want[k])...)
+			}
+		}
+		return diffs
+	case []interface{}:
+		gotSlice, ok := got.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: got %s, want an array", displayPath(path), describeValue(got))}
+		}
+		diffs := []string{}
+		if len(gotSlice) != len(want) {
+			diffs = append(diffs, fmt.Sprintf("%s: got %d elements, want %d", displayPath(path), len(gotSlice), len(want)))
+		}
+		for i := 0; i < len(gotSlice) && i < len(want); i++ {
+			diffs = append(diffs, compareTrees(fmt.Sprintf("%s[%d]", path, i), gotSlice[i], want[i])...)
+		}
+		return diffs
+	}
+	if reflect.TypeOf(got) != reflect.TypeOf(want) {
+		return []string{fmt.Sprintf("%s: got %s, want %s", displayPath(path), describeValue(got), describeValue(want))}
+	}
+	if !reflect.DeepEqual(got, want) {
+		return []string{fmt.Sprintf("%s: got %s, want %s", displayPath(path), formatValue(got), formatValue(want))}
+	}
+	return nil
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
+
+// describeValue formats a value along with its type for when the
+// types of two values don't match.
+func describeValue(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "a table"
+	case []interface{}:
+		return "an array"
+	}
+	return fmt.Sprintf("%T %s", v, formatValue(v))
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,71 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxDiffBytes caps the size of every diff returned by
+// CompareStrings, and the body checks which use it, when no
+// MaxDiffBytes option is passed. Zero means no limit. Set it in
+// TestMain to stop multi-megabyte bodies flooding the test log.
+var DefaultMaxDiffBytes = 0
+
+// MaxDiffBytes caps the diff returned by CompareStrings at n bytes,
+// overriding DefaultMaxDiffBytes. Zero means no limit.
+func MaxDiffBytes(n int) Option {
+	return func(o *options) {
+		o.maxDiffBytes = &n
+	}
+}
+
+// MaxDiffLines caps the diff returned by CompareStrings at n lines.
+func MaxDiffLines(n int) Option {
+	return func(o *options) {
+		o.maxDiffLines = n
+	}
+}
+
+// truncateDiff cuts diff down to the limits set in o, never splitting
+// a rune, and says how much was cut.
+func truncateDiff(diff string, o options) string {
+	maxBytes := DefaultMaxDiffBytes
+	if o.maxDiffBytes != nil {
+		maxBytes = *o.maxDiffBytes
+	}
+	cut := len(diff)
+	if o.maxDiffLines > 0 {
+		if i := nthIndex(diff, "\n", o.maxDiffLines); i >= 0 {
+			cut = i
+		}
+	}
+	if maxBytes > 0 && maxBytes < cut {
+		cut = maxBytes
+		for cut > 0 && !utf8.RuneStart(diff[cut]) {
+			cut--
+		}
+	}
+	if cut >= len(diff) {
+		return diff
+	}
+	head := diff[:cut]
+	if strings.Contains(head, "\x1b[") {
+		// Don't leave the rest of the output colored.
+		head += ansiReset
+	}
+	return fmt.Sprintf("%s\n... truncated, %d more bytes differ", head, len(diff)-cut)
+}
+
+// nthIndex returns the index of the nth occurrence of sep in s or -1.
+func nthIndex(s string, sep string, n int) int {
+	i := -1
+	for k := 0; k < n; k++ {
+		j := strings.Index(s[i+1:], sep)
+		if j < 0 {
+			return -1
+		}
+		i += j + 1
+	}
+	return i
+}
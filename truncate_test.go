@@ -0,0 +1,54 @@
+package testutil_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareStringsTruncation tests that large diffs are truncated.
+func TestCompareStringsTruncation(t *testing.T) {
+	got := strings.Repeat("a\n", 1000)
+	tests := []struct {
+		name     string
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "bytes",
+			opts:     []testutil.Option{testutil.MaxDiffBytes(40)},
+			wantDiff: "got a longer string than what we wanted \n... truncated, 2059 more bytes differ",
+		},
+		{
+			name:     "lines",
+			opts:     []testutil.Option{testutil.MaxDiffLines(3)},
+			wantDiff: "got a longer string than what we wanted (characters match otherwise) and the extra characters are: a\na\na\n... truncated, 1995 more bytes differ",
+		},
+		{
+			name:     "no limit",
+			opts:     []testutil.Option{testutil.MaxDiffBytes(0)},
+			wantDiff: "got a longer string than what we wanted (characters match otherwise) and the extra characters are: " + got,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareStrings(got, "", test.opts...), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestDefaultMaxDiffBytes tests that the package level limit applies
+// unless overridden.
+func TestDefaultMaxDiffBytes(t *testing.T) {
+	defer func(n int) { testutil.DefaultMaxDiffBytes = n }(testutil.DefaultMaxDiffBytes)
+	testutil.DefaultMaxDiffBytes = 16
+	if got, want := testutil.CompareStrings("ééééééééééé", ""), "got a longer str\n... truncated, 105 more bytes differ"; got != want {
+		t.Errorf("got diff %q, want %q", got, want)
+	}
+	if got, want := testutil.CompareStrings("x", "", testutil.MaxDiffBytes(0)), "got a longer string than what we wanted (characters match otherwise) and the extra characters are: x"; got != want {
+		t.Errorf("got diff %q, want %q", got, want)
+	}
+}
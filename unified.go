@@ -0,0 +1,214 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unifiedContext is the number of unchanged lines shown around each
+// change in a unified diff.
+const unifiedContext = 3
+
+// UnifiedDiff makes CompareStrings, and the body checks which use it,
+// report differences as a standard unified diff of want against got
+// so the output can be fed to existing diff viewers.
+func UnifiedDiff() Option {
+	return func(o *options) {
+		o.unifiedDiff = true
+	}
+}
+
+// diffOp is a single line of a line based diff. kind is ' ' for a line
+// in both, '-' for a line only in want and '+' for a line only in got.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines computes a minimal line based diff turning want into got.
+// It uses Myers' algorithm, finding the middle of the shortest edit
+// script and recursing on either side of it, so it only needs memory
+// linear in the number of lines even when two large bodies differ.
+func diffLines(want []string, got []string) []diffOp {
+	ops := []diffOp{}
+	var diff func(a []string, b []string)
+	diff = func(a []string, b []string) {
+		for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+			ops = append(ops, diffOp{' ', a[0]})
+			a, b = a[1:], b[1:]
+		}
+		suffix := 0
+		for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+			suffix++
+		}
+		common := a[len(a)-suffix:]
+		a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+		if x, y, ok := middleSnake(a, b); ok {
+			diff(a[:x], b[:y])
+			diff(a[x:], b[y:])
+		} else {
+			for _, line := range a {
+				ops = append(ops, diffOp{'-', line})
+			}
+			for _, line := range b {
+				ops = append(ops, diffOp{'+', line})
+			}
+		}
+		for _, line := range common {
+			ops = append(ops, diffOp{' ', line})
+		}
+	}
+	diff(want, got)
+	return ops
+}
+
+// middleSnake searches for the shortest edit script turning a into b
+// from both ends at once and returns the point (x, y) where the two
+// searches meet, which splits the problem in two. It returns false if
+// there is no such point which makes progress, which is when a or b
+// is empty or every line of a has to be replaced.
+func middleSnake(a []string, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return 0, 0, false
+	}
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	// forward[offset+k] is the furthest x reached on diagonal k = x-y
+	// searching from the start and backward[offset+k] is the same
+	// searching from the end, with a and b reversed.
+	forward := make([]int, 2*maxD+2)
+	backward := make([]int, 2*maxD+2)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+	delta := n - m
+	odd := delta%2 != 0
+	split := func(x int, y int) (int, int, bool) {
+		if (x == 0 && y == 0) || (x == n && y == m) {
+			return 0, 0, false
+		}
+		return x, y, true
+	}
+	for d := 0; d < maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if odd {
+				if i := offset + delta - k; i >= 0 && i < len(backward) && backward[i] != -1 && x >= n-backward[i] {
+					return split(x, y)
+				}
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if !odd {
+				if i := offset + delta - k; i >= 0 && i < len(forward) && forward[i] != -1 && forward[i] >= n-x {
+					fx := forward[i]
+					return split(fx, fx-(delta-k))
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func unifiedDiff(got string, want string, p palette) string {
+	if got == want {
+		return ""
+	}
+	ops := diffLines(strings.Split(want, "\n"), strings.Split(got, "\n"))
+	out := []string{"--- want", "+++ got"}
+	for start := 0; start < len(ops); {
+		// Find the next change and the extent of the hunk around
+		// it, merging changes whose context would overlap.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				if k-last > 2*unifiedContext {
+					break
+				}
+				last = k
+			}
+		}
+		from, to := first-unifiedContext, last+unifiedContext+1
+		if from < start {
+			from = start
+		}
+		if to > len(ops) {
+			to = len(ops)
+		}
+		wantStart, gotStart := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				wantStart++
+			}
+			if op.kind != '-' {
+				gotStart++
+			}
+		}
+		wantLen, gotLen := 0, 0
+		lines := []string{}
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				wantLen++
+			}
+			if op.kind != '-' {
+				gotLen++
+			}
+			line := string(op.kind) + op.line
+			switch op.kind {
+			case '-':
+				line = p.removed(line)
+			case '+':
+				line = p.added(line)
+			}
+			lines = append(lines, line)
+		}
+		out = append(out, p.hunk(fmt.Sprintf("@@ -%s +%s @@", hunkRange(wantStart, wantLen), hunkRange(gotStart, gotLen))))
+		out = append(out, lines...)
+		start = to
+	}
+	return strings.Join(out, "\n")
+}
+
+// hunkRange formats the range of a hunk the way diff(1) does where an
+// empty range starts at the line before it.
+func hunkRange(start int, length int) string {
+	if length == 0 {
+		start--
+	}
+	if length == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
@@ -0,0 +1,126 @@
+package testutil_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareStringsUnifiedDiff tests that the expected unified diff
+// is generated.
+func TestCompareStringsUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		gotStr   string
+		wantStr  string
+		wantDiff string
+	}{
+		{
+			name:     "equal",
+			gotStr:   "a\nb",
+			wantStr:  "a\nb",
+			wantDiff: "",
+		},
+		{
+			name:    "changed line",
+			gotStr:  "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10",
+			wantStr: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10",
+			wantDiff: `--- want
++++ got
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8`,
+		},
+		{
+			name:    "separate hunks",
+			gotStr:  "a\n1\n2\n3\n4\n5\n6\n7\n8\nz",
+			wantStr: "1\n2\n3\n4\n5\n6\n7\n8",
+			wantDiff: `--- want
++++ got
+@@ -1,3 +1,4 @@
++a
+ 1
+ 2
+ 3
+@@ -6,3 +7,4 @@
+ 6
+ 7
+ 8
++z`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareStrings(test.gotStr, test.wantStr, testutil.UnifiedDiff()), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+// TestCompareStringsUnifiedDiffLarge tests that large strings with a
+// few differences can be diffed without running out of memory.
+func TestCompareStringsUnifiedDiffLarge(t *testing.T) {
+	var got, want []string
+	for i := 1; i <= 50000; i++ {
+		line := fmt.Sprintf("line %d", i)
+		want = append(want, line)
+		switch i {
+		case 10000:
+			got = append(got, "changed")
+		case 40000:
+		default:
+			got = append(got, line)
+		}
+	}
+	wantDiff := `--- want
++++ got
+@@ -9997,7 +9997,7 @@
+ line 9997
+ line 9998
+ line 9999
+-line 10000
++changed
+ line 10001
+ line 10002
+ line 10003
+@@ -39997,7 +39997,6 @@
+ line 39997
+ line 39998
+ line 39999
+-line 40000
+ line 40001
+ line 40002
+ line 40003`
+	if got, want := testutil.CompareStrings(strings.Join(got, "\n"), strings.Join(want, "\n"), testutil.UnifiedDiff()), wantDiff; got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+}
+
+// TestCheckHTTPResponseUnifiedDiff tests that body differences in
+// responses can be reported as a unified diff.
+func TestCheckHTTPResponseUnifiedDiff(t *testing.T) {
+	resp := &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader("{\n  \"name\": \"bob\"\n}"))}
+	want := testutil.HTTPResponse{StatusCode: 200, Body: "{\n  \"name\": \"alice\"\n}"}
+	wantDiff := `response does not match what is expected:
+body is not expected, --- want
++++ got
+@@ -1,3 +1,3 @@
+ {
+-  "name": "alice"
++  "name": "bob"
+ }`
+	if got := testutil.CheckHTTPResponse(resp, want, testutil.UnifiedDiff()); got != wantDiff {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, wantDiff)
+	}
+}
@@ -0,0 +1,154 @@
+package testutil
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SortQueryParams makes URL comparisons ignore the order of query
+// parameters.
+func SortQueryParams() Option {
+	return func(o *options) {
+		o.sortQueryParams = true
+	}
+}
+
+// IgnoreDefaultPorts makes URL comparisons treat an explicit default
+// port (80 for http, 443 for https) the same as no port.
+func IgnoreDefaultPorts() Option {
+	return func(o *options) {
+		o.ignoreDefaultPorts = true
+	}
+}
+
+// IgnoreTrailingSlash makes URL comparisons treat paths with and
+// without a trailing slash as equal.
+func IgnoreTrailingSlash() Option {
+	return func(o *options) {
+		o.ignoreTrailingSlash = true
+	}
+}
+
+// LowercaseHost makes URL comparisons ignore the case of the host.
+func LowercaseHost() Option {
+	return func(o *options) {
+		o.lowercaseHost = true
+	}
+}
+
+// NormalizeURLs turns on all of the URL normalization options. These
+// cosmetic differences are behind most URL mismatches which aren't
+// real bugs.
+func NormalizeURLs() Option {
+	return func(o *options) {
+		o.sortQueryParams = true
+		o.ignoreDefaultPorts = true
+		o.ignoreTrailingSlash = true
+		o.lowercaseHost = true
+	}
+}
+
+// normalizeURL applies the URL normalization options to rawURL. If
+// rawURL cannot be parsed it is returned unchanged and the comparison
+// will fail the usual way.
+func normalizeURL(rawURL string, o options) string {
+	if !o.sortQueryParams && !o.ignoreDefaultPorts && !o.ignoreTrailingSlash && !o.lowercaseHost && !o.ignoreURLQuery {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if o.lowercaseHost {
+		u.Host = strings.ToLower(u.Host)
+	}
+	if o.ignoreDefaultPorts {
+		if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+			u.Host = strings.TrimSuffix(u.Host, ":"+port)
+		}
+	}
+	if o.ignoreTrailingSlash {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+		u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	}
+	if o.sortQueryParams {
+		u.RawQuery = u.Query().Encode()
+	}
+	if o.ignoreURLQuery {
+		u.RawQuery, u.ForceQuery = "", false
+	}
+	return u.String()
+}
+
+// CompareURLs parses two URLs and compares them part by part, treating
+// the query as an unordered multimap so neither the order of the
+// parameters nor the order of a parameter's values matters. It returns
+// a string listing the parts which differ or "" if none do. The URL
+// normalization options, like IgnoreTrailingSlash, are applied first.
+func CompareURLs(got string, want string, opts ...Option) string {
+	o := newOptions(opts)
+	diffs, err := urlDiffs(normalizeURL(got, o), normalizeURL(want, o))
+	if err != nil {
+		return err.Error()
+	}
+	if len(diffs) > 0 {
+		return "URLs differ:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+func urlDiffs(rawGot string, rawWant string) ([]string, error) {
+	got, err := url.Parse(rawGot)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse got URL: %v", err)
+	}
+	want, err := url.Parse(rawWant)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse want URL: %v", err)
+	}
+	diffs := []string{}
+	part := func(name string, got string, want string) {
+		if got != want {
+			diffs = append(diffs, fmt.Sprintf("%s: got %q, want %q", name, got, want))
+		}
+	}
+	part("scheme", got.Scheme, want.Scheme)
+	part("user", got.User.String(), want.User.String())
+	part("host", got.Host, want.Host)
+	part("path", got.EscapedPath(), want.EscapedPath())
+	diffs = append(diffs, valuesDiffs("query parameter", got.Query(), want.Query())...)
+	part("fragment", got.Fragment, want.Fragment)
+	return diffs, nil
+}
+
+// valuesDiffs compares two sets of query parameters or form fields,
+// which are called noun in the diffs. The order of the values for a
+// name doesn't matter.
+func valuesDiffs(noun string, got url.Values, want url.Values) []string {
+	names := []string{}
+	for name := range want {
+		names = append(names, name)
+	}
+	for name := range got {
+		if _, ok := want[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	diffs := []string{}
+	for _, name := range names {
+		g, inGot := got[name]
+		w, inWant := want[name]
+		switch {
+		case !inGot:
+			diffs = append(diffs, fmt.Sprintf("missing %s %q, want %q", noun, name, w))
+		case !inWant:
+			diffs = append(diffs, fmt.Sprintf("unexpected %s %q with %q", noun, name, g))
+		case AssertPermutation(g, w) != "":
+			diffs = append(diffs, fmt.Sprintf("%s %q: got %q, want %q", noun, name, g, w))
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,148 @@
+package testutil_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestURLNormalization tests that the URL normalization options make
+// CheckHTTPRequest ignore cosmetic differences between URLs.
+func TestURLNormalization(t *testing.T) {
+	tests := []struct {
+		name     string
+		gotURL   string
+		wantURL  string
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:    "no normalization",
+			gotURL:  "http://Hello.com:80/users/?b=2&a=1",
+			wantURL: "http://hello.com/users?a=1&b=2",
+			wantDiff: `request does not match what is expected:
+got url:
+  "http://Hello.com:80/users/?b=2&a=1"
+want:
+  "http://hello.com/users?a=1&b=2"
+which differ in:
+  host: got "Hello.com:80", want "hello.com"
+  path: got "/users/", want "/users"`,
+		},
+		{
+			name:     "query parameter order never matters",
+			gotURL:   "http://hello.com/users?b=2&a=1&a=3",
+			wantURL:  "http://hello.com/users?a=3&a=1&b=2",
+			wantDiff: "",
+		},
+		{
+			name:     "sort query params",
+			gotURL:   "http://hello.com/users?b=2&a=1",
+			wantURL:  "http://hello.com/users?a=1&b=2",
+			opts:     []testutil.Option{testutil.SortQueryParams()},
+			wantDiff: "",
+		},
+		{
+			name:     "ignore default ports",
+			gotURL:   "https://hello.com:443/users",
+			wantURL:  "https://hello.com/users",
+			opts:     []testutil.Option{testutil.IgnoreDefaultPorts()},
+			wantDiff: "",
+		},
+		{
+			name:    "non-default ports are not ignored",
+			gotURL:  "https://hello.com:80/users",
+			wantURL: "https://hello.com/users",
+			opts:    []testutil.Option{testutil.IgnoreDefaultPorts()},
+			wantDiff: `request does not match what is expected:
+got url:
+  "https://hello.com:80/users"
+want:
+  "https://hello.com/users"
+which differ in:
+  host: got "hello.com:80", want "hello.com"`,
+		},
+		{
+			name:     "ignore trailing slash",
+			gotURL:   "http://hello.com/users/",
+			wantURL:  "http://hello.com/users",
+			opts:     []testutil.Option{testutil.IgnoreTrailingSlash()},
+			wantDiff: "",
+		},
+		{
+			name:     "lowercase host",
+			gotURL:   "http://Hello.COM/users",
+			wantURL:  "http://hello.com/users",
+			opts:     []testutil.Option{testutil.LowercaseHost()},
+			wantDiff: "",
+		},
+		{
+			name:     "all normalizations",
+			gotURL:   "http://Hello.com:80/users/?b=2&a=1",
+			wantURL:  "http://hello.com/users?a=1&b=2",
+			opts:     []testutil.Option{testutil.NormalizeURLs()},
+			wantDiff: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gotReq := testutil.MustNewHTTPRequest("GET", test.gotURL, strings.NewReader(""))
+			wantReq := testutil.HTTPRequest{Method: "GET", URL: test.wantURL}
+			if diff := testutil.CompareStrings(testutil.CheckHTTPRequest(gotReq, wantReq, test.opts...), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+// TestCompareURLs tests that URLs are compared part by part.
+func TestCompareURLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		want     string
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "equal apart from query order",
+			got:      "https://api.com/v1/users?page=2&sort=name&tag=a&tag=b",
+			want:     "https://api.com/v1/users?tag=b&sort=name&tag=a&page=2",
+			wantDiff: "",
+		},
+		{
+			name: "parts differ",
+			got:  "http://bob@api.com/v1/users?page=2&debug=true#top",
+			want: "https://api.com/v2/users?page=3&sort=name",
+			wantDiff: `URLs differ:
+scheme: got "http", want "https"
+user: got "bob", want ""
+path: got "/v1/users", want "/v2/users"
+unexpected query parameter "debug" with ["true"]
+query parameter "page": got ["2"], want ["3"]
+missing query parameter "sort", want ["name"]
+fragment: got "top", want ""`,
+		},
+		{
+			name:     "normalization options",
+			got:      "http://API.com:80/users/",
+			want:     "http://api.com/users",
+			opts:     []testutil.Option{testutil.NormalizeURLs()},
+			wantDiff: "",
+		},
+		{
+			name:     "invalid",
+			got:      "http://a b.com/%zz",
+			want:     "http://ab.com",
+			wantDiff: `could not parse got URL: parse "http://a b.com/%zz": invalid character " " in host name`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareURLs(test.got, test.want, test.opts...), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
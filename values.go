@@ -0,0 +1,236 @@
+package testutil
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IgnoreFields makes CompareValues skip struct fields with the given
+// names. A name can be a plain field name like "ID", which is skipped
+// wherever it appears, or a path from the root value like
+// "Address.Zip".
+func IgnoreFields(names ...string) Option {
+	return func(o *options) {
+		o.ignoreFields = append(o.ignoreFields, names...)
+	}
+}
+
+// IgnoreUnexported makes CompareValues skip unexported struct fields.
+func IgnoreUnexported() Option {
+	return func(o *options) {
+		o.ignoreUnexported = true
+	}
+}
+
+// CompareValues walks two values, following structs, slices, arrays,
+// maps, pointers and interfaces, and returns a string with a diff for
+// every path where they differ, like:
+//
+//	User.Address.Zip: got "12345", want "54321"
+//
+// or "" if they are equal. Nil and empty slices and maps are treated
+// as equal. Exported time.Time values are compared with CompareTimes,
+// see TimeTolerance.
+func CompareValues(got interface{}, want interface{}, opts ...Option) string {
+	return DiffValues(got, want, opts...).report("values differ:\n")
+}
+
+// DiffValues is like CompareValues but returns the differences as
+// Diffs. Got and Want hold the differing values, or their formatted
+// form if they were read from unexported fields.
+func DiffValues(got interface{}, want interface{}, opts ...Option) Diffs {
+	c := valueComparer{o: newOptions(opts), visited: map[visit]bool{}}
+	g, w := reflect.ValueOf(got), reflect.ValueOf(want)
+	root := "(root)"
+	if w.IsValid() {
+		t := w.Type()
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct && t.Name() != "" {
+			root = t.Name()
+		}
+	}
+	c.root = root
+	diffs := Diffs{}
+	c.compare(&diffs, root, g, w)
+	return diffs
+}
+
+type valueComparer struct {
+	o       options
+	root    string
+	visited map[visit]bool
+}
+
+// visit is a pair of pointers, maps or slices being compared. Like
+// reflect.DeepEqual, a pair seen before is not compared again so
+// cyclic values don't recurse forever.
+type visit struct {
+	got  uintptr
+	want uintptr
+	typ  reflect.Type
+}
+
+// seen reports whether got and want have already been compared,
+// remembering them if not.
+func (c valueComparer) seen(got reflect.Value, want reflect.Value) bool {
+	switch want.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if got.IsNil() || want.IsNil() {
+			return false
+		}
+	default:
+		return false
+	}
+	v := visit{got: got.Pointer(), want: want.Pointer(), typ: want.Type()}
+	if c.visited[v] {
+		return true
+	}
+	c.visited[v] = true
+	return false
+}
+
+func (c valueComparer) changed(diffs *Diffs, path string, got reflect.Value, want reflect.Value) {
+	diffs.add(path, DiffChanged, reflectInterface(got), reflectInterface(want), fmt.Sprintf("%s: got %s, want %s", path, formatReflect(got), formatReflect(want)))
+}
+
+func (c valueComparer) compare(diffs *Diffs, path string, got reflect.Value, want reflect.Value) {
+	if !got.IsValid() || !want.IsValid() {
+		if got.IsValid() != want.IsValid() {
+			c.changed(diffs, path, got, want)
+		}
+		return
+	}
+	if got.Type() != want.Type() {
+		diffs.add(path, DiffChanged, reflectInterface(got), reflectInterface(want), fmt.Sprintf("%s: got type %s, want %s", path, got.Type(), want.Type()))
+		return
+	}
+	if want.Type() == timeType && got.CanInterface() && want.CanInterface() {
+		if diff := CompareTimes(got.Interface().(time.Time), want.Interface().(time.Time), c.o.timeTolerance); diff != "" {
+			diffs.add(path, DiffChanged, got.Interface(), want.Interface(), fmt.Sprintf("%s: %s", path, diff))
+		}
+		return
+	}
+	if c.seen(got, want) {
+		return
+	}
+	switch want.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if got.IsNil() || want.IsNil() {
+			if got.IsNil() != want.IsNil() {
+				c.changed(diffs, path, got, want)
+			}
+			return
+		}
+		c.compare(diffs, path, got.Elem(), want.Elem())
+	case reflect.Struct:
+		for i := 0; i < want.NumField(); i++ {
+			f := want.Type().Field(i)
+			fieldPath := path + "." + f.Name
+			if c.ignored(f, fieldPath) {
+				continue
+			}
+			c.compare(diffs, fieldPath, got.Field(i), want.Field(i))
+		}
+	case reflect.Slice, reflect.Array:
+		if got.Len() != want.Len() {
+			diffs.add(path, DiffChanged, got.Len(), want.Len(), fmt.Sprintf("%s: got %d elements, want %d", path, got.Len(), want.Len()))
+		}
+		for i := 0; i < got.Len() && i < want.Len(); i++ {
+			c.compare(diffs, fmt.Sprintf("%s[%d]", path, i), got.Index(i), want.Index(i))
+		}
+	case reflect.Map:
+		keys := want.MapKeys()
+		for _, k := range got.MapKeys() {
+			if !want.MapIndex(k).IsValid() {
+				keys = append(keys, k)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			g, w := got.MapIndex(k), want.MapIndex(k)
+			keyPath := fmt.Sprintf("%s[%s]", path, formatReflect(k))
+			switch {
+			case !g.IsValid():
+				diffs.add(keyPath, DiffMissing, nil, reflectInterface(w), fmt.Sprintf("%s: missing key %s", path, formatReflect(k)))
+			case !w.IsValid():
+				diffs.add(keyPath, DiffUnexpected, reflectInterface(g), nil, fmt.Sprintf("%s: unexpected key %s", path, formatReflect(k)))
+			default:
+				c.compare(diffs, keyPath, g, w)
+			}
+		}
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		if got.Pointer() != want.Pointer() {
+			c.changed(diffs, path, got, want)
+		}
+	default:
+		if !leafEqual(got, want) {
+			c.changed(diffs, path, got, want)
+		}
+	}
+}
+
+// reflectInterface returns the value held by v or, if it was read
+// from an unexported field and can't be, its formatted form.
+func reflectInterface(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return formatReflect(v)
+}
+
+func (c valueComparer) ignored(f reflect.StructField, path string) bool {
+	if c.o.ignoreUnexported && !f.IsExported() {
+		return true
+	}
+	relative := strings.TrimPrefix(path, c.root+".")
+	for _, name := range c.o.ignoreFields {
+		if name == f.Name || name == relative {
+			return true
+		}
+	}
+	return false
+}
+
+// leafEqual compares values of the basic kinds. It works on values
+// read from unexported fields which cannot be converted back to an
+// interface{}.
+func leafEqual(got reflect.Value, want reflect.Value) bool {
+	switch want.Kind() {
+	case reflect.Bool:
+		return got.Bool() == want.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return got.Int() == want.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return got.Uint() == want.Uint()
+	case reflect.Float32, reflect.Float64:
+		return got.Float() == want.Float()
+	case reflect.Complex64, reflect.Complex128:
+		return got.Complex() == want.Complex()
+	case reflect.String:
+		return got.String() == want.String()
+	}
+	return false
+}
+
+func formatReflect(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("%q", v)
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			return "nil"
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
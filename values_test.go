@@ -0,0 +1,123 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+type address struct {
+	Street string
+	Zip    string
+}
+
+type user struct {
+	ID      int
+	Name    string
+	Address *address
+	Tags    []string
+	Attrs   map[string]interface{}
+	secret  string
+}
+
+// TestCompareValues tests that the expected field path diffs are
+// generated when comparing values.
+func TestCompareValues(t *testing.T) {
+	base := func() user {
+		return user{
+			ID:      1,
+			Name:    "bob",
+			Address: &address{Street: "Main St", Zip: "12345"},
+			Tags:    []string{"a", "b"},
+			Attrs:   map[string]interface{}{"age": 30, "admin": false},
+			secret:  "s1",
+		}
+	}
+	changed := base()
+	changed.ID = 2
+	changed.Address = &address{Street: "Main St", Zip: "54321"}
+	changed.Tags = []string{"a", "c", "d"}
+	changed.Attrs = map[string]interface{}{"age": "30", "team": "x"}
+	changed.secret = "s2"
+	noAddress := base()
+	noAddress.Address = nil
+	tests := []struct {
+		name     string
+		got      interface{}
+		want     interface{}
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "equal",
+			got:      base(),
+			want:     base(),
+			wantDiff: "",
+		},
+		{
+			name: "differ",
+			got:  changed,
+			want: base(),
+			wantDiff: `values differ:
+user.ID: got 2, want 1
+user.Address.Zip: got "54321", want "12345"
+user.Tags: got 3 elements, want 2
+user.Tags[1]: got "c", want "b"
+user.Attrs: missing key "admin"
+user.Attrs["age"]: got type string, want int
+user.Attrs: unexpected key "team"
+user.secret: got "s2", want "s1"`,
+		},
+		{
+			name:     "ignore fields and unexported",
+			got:      changed,
+			want:     base(),
+			opts:     []testutil.Option{testutil.IgnoreFields("ID", "Address.Zip", "Tags", "Attrs"), testutil.IgnoreUnexported()},
+			wantDiff: "",
+		},
+		{
+			name: "nil pointer",
+			got:  &noAddress,
+			want: &changed,
+			opts: []testutil.Option{testutil.IgnoreFields("ID", "Tags", "Attrs", "secret")},
+			wantDiff: `values differ:
+user.Address: got nil, want &{Main St 54321}`,
+		},
+		{
+			name: "different types",
+			got:  1,
+			want: "1",
+			wantDiff: `values differ:
+(root): got type int, want string`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareValues(test.got, test.want, test.opts...), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
+
+type node struct {
+	Value int
+	Next  *node
+}
+
+// TestCompareValuesCyclic tests that cyclic values are compared
+// without recursing forever.
+func TestCompareValuesCyclic(t *testing.T) {
+	cycle := func(value int) *node {
+		n := &node{Value: value}
+		n.Next = &node{Value: 2, Next: n}
+		return n
+	}
+	if diff := testutil.CompareValues(cycle(1), cycle(1)); diff != "" {
+		t.Errorf("got diff for equal cyclic values:\n%s", diff)
+	}
+	want := "values differ:\nnode.Value: got 1, want 3"
+	if got := testutil.CompareValues(cycle(1), cycle(3)); got != want {
+		t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+	}
+}
@@ -0,0 +1,187 @@
+package testutil
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Cassette holds the interactions a VCR recorded with an upstream
+// API.
+type Cassette struct {
+	Upstream     string        `json:"upstream"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// VCRConfig configures a VCR.
+type VCRConfig struct {
+	// CassettePath is the file the interactions are recorded to
+	// and replayed from.
+	CassettePath string
+	// Upstream is the base URL of the real API requests are
+	// forwarded to while recording.
+	Upstream string
+	// Record makes the VCR record a new cassette even if one
+	// already exists.
+	Record bool
+	// Transport is used to forward requests while recording. If
+	// nil http.DefaultTransport is used.
+	Transport http.RoundTripper
+	// Scrubbers redact secrets from the interactions before they
+	// are written to the cassette. Incoming requests are scrubbed
+	// the same way before being matched against the recorded ones
+	// when replaying.
+	Scrubbers []Scrubber
+	// MatchOptions are passed to CheckHTTPRequest when matching
+	// requests against the recorded ones, for example
+	// IgnoreHeaders to ignore volatile headers.
+	MatchOptions []Option
+}
+
+// VCR is a server which stands in for an upstream API. The first time
+// it runs, when there is no cassette, it forwards every request to the
+// upstream and records the request and response to the cassette.
+// After that it replays the recorded responses without touching the
+// network so integration tests can run hermetically in CI. It embeds
+// the *httptest.Server it runs on so URL, Client and Close are
+// available as usual.
+type VCR struct {
+	*httptest.Server
+
+	cfg       VCRConfig
+	upstream  *url.URL
+	recording bool
+
+	mu       sync.Mutex
+	cassette Cassette
+	replayed []bool
+	errs     []string
+}
+
+// NewVCR starts a VCR which records if cfg.Record is set or there is
+// no cassette at cfg.CassettePath, and replays otherwise. The cassette
+// is read and written with ReadCassette and WriteCassette. The caller
+// should Close it when finished.
+func NewVCR(cfg VCRConfig) (*VCR, error) {
+	v := &VCR{cfg: cfg}
+	_, err := os.Stat(cfg.CassettePath)
+	switch {
+	case cfg.Record || errors.Is(err, os.ErrNotExist):
+		v.recording = true
+		if v.upstream, err = url.Parse(cfg.Upstream); err != nil || v.upstream.Host == "" {
+			return nil, fmt.Errorf("upstream %q is not an absolute URL", cfg.Upstream)
+		}
+		v.cassette.Upstream = cfg.Upstream
+	default:
+		if v.cassette, err = ReadCassette(cfg.CassettePath); err != nil {
+			return nil, err
+		}
+		v.replayed = make([]bool, len(v.cassette.Interactions))
+	}
+	v.Server = httptest.NewServer(v)
+	return v, nil
+}
+
+// Recording reports whether the VCR is recording rather than
+// replaying.
+func (v *VCR) Recording() bool {
+	return v.recording
+}
+
+// Err returns an error describing the requests the VCR could not
+// handle, like those no recorded interaction matches when replaying or
+// those which could not be forwarded when recording, or nil if there
+// were none.
+func (v *VCR) Err() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(v.errs, "\n"))
+}
+
+// ServeHTTP forwards and records r or replays the recorded response
+// to it.
+func (v *VCR) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := FromHTTPRequest(r)
+	if err != nil {
+		http.Error(w, "could not read request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var resp HTTPResponse
+	if v.recording {
+		resp, err = v.record(r, req)
+	} else {
+		resp, err = v.replay(r, req)
+	}
+	if err != nil {
+		v.mu.Lock()
+		v.errs = append(v.errs, err.Error())
+		v.mu.Unlock()
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeHTTPResponse(w, resp)
+}
+
+// record forwards r, which was read into req, to the upstream and adds
+// the interaction to the cassette.
+func (v *VCR) record(r *http.Request, req HTTPRequest) (HTTPResponse, error) {
+	u := *v.upstream
+	u.Path = strings.TrimSuffix(u.Path, "/") + r.URL.Path
+	u.RawPath = ""
+	u.RawQuery = r.URL.RawQuery
+	out, err := http.NewRequest(r.Method, u.String(), strings.NewReader(req.Body))
+	if err != nil {
+		return HTTPResponse{}, fmt.Errorf("could not forward %s %s: %v", r.Method, r.URL, err)
+	}
+	out.Header = r.Header.Clone()
+	transport := v.cfg.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	upstreamResp, err := transport.RoundTrip(out)
+	if err != nil {
+		return HTTPResponse{}, fmt.Errorf("could not forward %s %s: %v", r.Method, r.URL, err)
+	}
+	defer upstreamResp.Body.Close()
+	resp, err := FromHTTPResponse(upstreamResp)
+	if err != nil {
+		return HTTPResponse{}, fmt.Errorf("could not read the response to %s %s: %v", r.Method, r.URL, err)
+	}
+	// The body is sent whole when replaying so the length is
+	// worked out again.
+	resp.Header.Del("Content-Length")
+	// The VCR's own address changes from run to run.
+	req.Host = ""
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.cassette.Interactions = append(v.cassette.Interactions, Interaction{Request: req, Response: resp})
+	if err := WriteCassette(v.cfg.CassettePath, v.cassette, v.cfg.Scrubbers...); err != nil {
+		return HTTPResponse{}, err
+	}
+	return resp, nil
+}
+
+// replay returns the response of the first interaction which hasn't
+// been replayed yet and whose request matches req, once scrubbed, as
+// checked by CheckHTTPRequest, so repeated requests get their
+// responses in the order they were recorded.
+func (v *VCR) replay(r *http.Request, req HTTPRequest) (HTTPResponse, error) {
+	scrubbed := scrubInteractions([]Interaction{{Request: req}}, v.cfg.Scrubbers)[0].Request
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i, interaction := range v.cassette.Interactions {
+		if !v.replayed[i] && DiffHTTPRequest(newServerRequest(scrubbed), interaction.Request, v.cfg.MatchOptions...).String() == "" {
+			v.replayed[i] = true
+			return interaction.Response, nil
+		}
+	}
+	return HTTPResponse{}, fmt.Errorf("no recorded interaction matches %s %s", r.Method, r.URL)
+}
@@ -0,0 +1,138 @@
+package testutil_test
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestVCR tests that a VCR records interactions with the upstream the
+// first time it runs and replays them afterwards without it.
+func TestVCR(t *testing.T) {
+	upstream := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	upstream.Stub("GET", "/api/users/1", testutil.HTTPResponse{StatusCode: 200, Body: `{"name": "ann"}`})
+	upstream.Stub("POST", "/api/users", testutil.HTTPResponse{StatusCode: 201}, testutil.HTTPResponse{StatusCode: 409})
+	cfg := testutil.VCRConfig{
+		CassettePath: filepath.Join(t.TempDir(), "cassette.json"),
+		Upstream:     upstream.URL + "/api",
+	}
+	send := func(t *testing.T, vcr *testutil.VCR) []testutil.HTTPResponse {
+		t.Helper()
+		resps := []testutil.HTTPResponse{}
+		for _, req := range []*http.Request{
+			testutil.MustNewHTTPRequest("GET", vcr.URL+"/users/1", nil),
+			testutil.MustNewHTTPRequest("POST", vcr.URL+"/users", strings.NewReader(`{"name": "bob"}`)),
+			testutil.MustNewHTTPRequest("POST", vcr.URL+"/users", strings.NewReader(`{"name": "bob"}`)),
+		} {
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := testutil.FromHTTPResponse(resp)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			resps = append(resps, testutil.HTTPResponse{StatusCode: got.StatusCode, Body: got.Body})
+		}
+		return resps
+	}
+	wantResps := []testutil.HTTPResponse{
+		{StatusCode: 200, Body: `{"name": "ann"}`},
+		{StatusCode: 201, Body: ""},
+		{StatusCode: 409, Body: ""},
+	}
+
+	recorder, err := testutil.NewVCR(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recorder.Recording() {
+		t.Error("got a VCR which is replaying, want one which is recording")
+	}
+	if diff := testutil.CompareValues(send(t, recorder), wantResps); diff != "" {
+		t.Errorf("recording: %s", diff)
+	}
+	recorder.Close()
+	upstream.Close()
+	if got, want := len(upstream.Requests()), 3; got != want {
+		t.Errorf("got %d requests to the upstream, want %d", got, want)
+	}
+	if err := recorder.Err(); err != nil {
+		t.Errorf("recording: got error %v, want none", err)
+	}
+
+	player, err := testutil.NewVCR(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer player.Close()
+	if player.Recording() {
+		t.Error("got a VCR which is recording, want one which is replaying")
+	}
+	if diff := testutil.CompareValues(send(t, player), wantResps); diff != "" {
+		t.Errorf("replaying: %s", diff)
+	}
+	if err := player.Err(); err != nil {
+		t.Errorf("replaying: got error %v, want none", err)
+	}
+	resp, err := http.Get(player.URL + "/users/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if diff := testutil.CheckErrHasMsg(player.Err(), "no recorded interaction matches GET /users/2"); diff != "" {
+		t.Error(diff)
+	}
+}
+
+// TestVCRScrubbing tests that secrets are scrubbed from the cassette
+// and that requests still match when replaying.
+func TestVCRScrubbing(t *testing.T) {
+	upstream := testutil.NewRecordingServer(testutil.HTTPResponse{StatusCode: 200, Body: "hi"})
+	cfg := testutil.VCRConfig{
+		CassettePath: filepath.Join(t.TempDir(), "cassette.json"),
+		Upstream:     upstream.URL,
+		Scrubbers:    []testutil.Scrubber{testutil.ScrubHeader("Authorization", "REDACTED")},
+		MatchOptions: []testutil.Option{testutil.IgnoreHeaders("X-Request-Id")},
+	}
+	get := func(vcr *testutil.VCR, token string, requestID string) {
+		req := testutil.MustNewHTTPRequest("GET", vcr.URL+"/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Request-Id", requestID)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if diff := testutil.CheckHTTPResponse(resp, testutil.HTTPResponse{StatusCode: 200, Body: "hi"}, testutil.Partial()); diff != "" {
+			t.Error(diff)
+		}
+	}
+	recorder, err := testutil.NewVCR(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get(recorder, "secret", "1")
+	recorder.Close()
+	upstream.Close()
+	c, err := testutil.ReadCassette(cfg.CassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Interactions[0].Request.Header.Get("Authorization"), "REDACTED"; got != want {
+		t.Errorf("got recorded Authorization header %q, want %q", got, want)
+	}
+	player, err := testutil.NewVCR(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer player.Close()
+	get(player, "another secret", "2")
+	if err := player.Err(); err != nil {
+		t.Errorf("got error %v, want none", err)
+	}
+}
@@ -0,0 +1,132 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// AssertReceived reports an error on t unless the server received a
+// request matching want, as checked by CheckHTTPRequest with opts.
+// Recorded requests only have a path and query in their URL so want
+// should too.
+func (s *RecordingServer) AssertReceived(t testing.TB, want HTTPRequest, opts ...Option) {
+	t.Helper()
+	matched, diff := s.received(want, opts)
+	if len(matched) == 0 {
+		Report(t, "AssertReceived", requestEndpoint(want), diff)
+	}
+}
+
+// AssertReceivedTimes reports an error on t unless the server
+// received exactly n requests matching want, as checked by
+// CheckHTTPRequest with opts.
+func (s *RecordingServer) AssertReceivedTimes(t testing.TB, want HTTPRequest, n int, opts ...Option) {
+	t.Helper()
+	matched, diff := s.received(want, opts)
+	if len(matched) == n {
+		return
+	}
+	msg := fmt.Sprintf("got %d requests matching %s %s, want %d", len(matched), want.Method, want.URL, n)
+	if len(matched) == 0 && n > 0 {
+		msg = diff
+	}
+	Report(t, "AssertReceivedTimes", requestEndpoint(want), msg)
+}
+
+// AssertNothingElseReceived reports an error on t if the server
+// received requests which no earlier call to AssertReceived,
+// AssertReceivedTimes or AssertReceivedInOrder matched.
+func (s *RecordingServer) AssertNothingElseReceived(t testing.TB) {
+	t.Helper()
+	s.mu.Lock()
+	diffs := []string{}
+	for i, r := range s.requests {
+		if !s.verified[i] {
+			diffs = append(diffs, fmt.Sprintf("unexpected request %d: %s %s", i+1, r.Request.Method, r.Request.URL))
+		}
+	}
+	s.mu.Unlock()
+	if len(diffs) > 0 {
+		Report(t, "AssertNothingElseReceived", "", "received requests which were not asserted on:\n"+strings.Join(diffs, "\n"))
+	}
+}
+
+// AssertReceivedInOrder reports an error on t unless the server
+// received requests matching wants, as checked by CheckHTTPRequest,
+// in that order. Other requests can come before, after and between
+// them so unrelated traffic doesn't matter.
+func (s *RecordingServer) AssertReceivedInOrder(t testing.TB, wants ...HTTPRequest) {
+	t.Helper()
+	s.AssertReceivedInOrderWith(t, nil, wants...)
+}
+
+// AssertReceivedInOrderWith is like AssertReceivedInOrder but the
+// requests are checked by CheckHTTPRequest with opts.
+func (s *RecordingServer) AssertReceivedInOrderWith(t testing.TB, opts []Option, wants ...HTTPRequest) {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := 0
+	for j, want := range wants {
+		i := s.indexMatching(want, next, len(s.requests), opts)
+		if i < 0 {
+			diff := fmt.Sprintf("expected request %d was never seen: %s %s", j+1, want.Method, want.URL)
+			if j > 0 {
+				diff = fmt.Sprintf("expected request %d was not seen after request %d, the match for expected request %d: %s %s", j+1, next, j, want.Method, want.URL)
+				if earlier := s.indexMatching(want, 0, next, opts); earlier >= 0 {
+					diff += fmt.Sprintf(", it was seen earlier as request %d", earlier+1)
+				}
+			}
+			Report(t, "AssertReceivedInOrder", requestEndpoint(want), "requests were not received in order:\n"+diff)
+			return
+		}
+		s.markVerified(i)
+		next = i + 1
+	}
+}
+
+// indexMatching returns the index of the first recorded request from
+// start up to end which matches want, as checked by CheckHTTPRequest
+// with opts, or -1 if none do.
+func (s *RecordingServer) indexMatching(want HTTPRequest, start int, end int, opts []Option) int {
+	for i := start; i < end; i++ {
+		if DiffHTTPRequest(newServerRequest(s.requests[i].Request), want, opts...).String() == "" {
+			return i
+		}
+	}
+	return -1
+}
+
+// received returns the indexes of the recorded requests which match
+// want, marking them as verified. If none do it also returns a diff
+// saying so which points at the closest request.
+func (s *RecordingServer) received(want HTTPRequest, opts []Option) ([]int, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	matched := []int{}
+	summaries := []string{}
+	for i, r := range s.requests {
+		if DiffHTTPRequest(newServerRequest(r.Request), want, opts...).String() == "" {
+			matched = append(matched, i)
+			s.markVerified(i)
+		}
+		summaries = append(summaries, requestSummary(r.Request))
+	}
+	if len(matched) > 0 {
+		return matched, ""
+	}
+	diff := fmt.Sprintf("no request matching %s %s was received", want.Method, want.URL)
+	if i := closestMatch(requestSummary(want), summaries); i >= 0 {
+		d := DiffHTTPRequest(newServerRequest(s.requests[i].Request), want, opts...).String()
+		diff += fmt.Sprintf(", did you mean request %d which differed with:\n%s", i+1, indent(d, "  "))
+	}
+	return matched, diff
+}
+
+func (s *RecordingServer) markVerified(i int) {
+	if s.verified == nil {
+		s.verified = map[int]bool{}
+	}
+	s.verified[i] = true
+}
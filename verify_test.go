@@ -0,0 +1,144 @@
+package testutil_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestRecordingServerAssertions tests the assertions which can be
+// made about the requests a RecordingServer received.
+func TestRecordingServerAssertions(t *testing.T) {
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	defer server.Close()
+	for _, path := range []string{"/orders", "/orders", "/users"} {
+		resp, err := http.Post(server.URL+path, "text/plain", strings.NewReader("hi"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	orders := testutil.HTTPRequest{Method: "POST", URL: "/orders", Body: "hi"}
+	tests := []struct {
+		name      string
+		assert    func(t testing.TB)
+		wantError string
+	}{
+		{
+			name:   "received",
+			assert: func(t testing.TB) { server.AssertReceived(t, orders) },
+		},
+		{
+			name: "not received",
+			assert: func(t testing.TB) {
+				server.AssertReceived(t, testutil.HTTPRequest{Method: "POST", URL: "/order", Body: "hi"})
+			},
+			wantError: `no request matching POST /order was received, did you mean request 1 which differed with:
+  got url:
+    "/orders"
+  want:
+    "/order"
+  which differ in:
+    path: got "/orders", want "/order"`,
+		},
+		{
+			name:   "received the right number of times",
+			assert: func(t testing.TB) { server.AssertReceivedTimes(t, orders, 2) },
+		},
+		{
+			name:      "received the wrong number of times",
+			assert:    func(t testing.TB) { server.AssertReceivedTimes(t, orders, 3) },
+			wantError: "got 2 requests matching POST /orders, want 3",
+		},
+		{
+			name:      "something else was received",
+			assert:    func(t testing.TB) { server.AssertNothingElseReceived(t) },
+			wantError: "received requests which were not asserted on:\nunexpected request 3: POST /users",
+		},
+		{
+			name: "received from an address",
+			assert: func(t testing.TB) {
+				server.AssertReceived(t, testutil.HTTPRequest{RemoteAddr: `^127\.0\.0\.1:`}, testutil.Partial())
+			},
+		},
+		{
+			name: "received for a host",
+			assert: func(t testing.TB) {
+				server.AssertReceived(t, testutil.HTTPRequest{Host: "127.0.0.1:*"}, testutil.Partial())
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := &recordingTB{TB: t}
+			test.assert(rec)
+			switch {
+			case test.wantError == "" && len(rec.errors) != 0:
+				t.Errorf("got errors %q, want none", rec.errors)
+			case test.wantError != "" && (len(rec.errors) != 1 || rec.errors[0] != test.wantError):
+				t.Errorf("got errors %q, want:\n%s", rec.errors, test.wantError)
+			}
+		})
+	}
+}
+
+// TestRecordingServerAssertReceivedInOrder tests checking the order
+// requests were received in while ignoring unrelated requests.
+func TestRecordingServerAssertReceivedInOrder(t *testing.T) {
+	server := testutil.NewRecordingServer(testutil.HTTPResponse{})
+	defer server.Close()
+	for _, path := range []string{"/login", "/metrics", "/orders", "/metrics", "/pay"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	get := func(path string) testutil.HTTPRequest {
+		return testutil.HTTPRequest{Method: "GET", URL: path}
+	}
+	tests := []struct {
+		name      string
+		wants     []testutil.HTTPRequest
+		opts      []testutil.Option
+		wantError string
+	}{
+		{
+			name:  "in order with requests in between",
+			wants: []testutil.HTTPRequest{get("/login"), get("/orders"), get("/pay")},
+		},
+		{
+			name:      "out of order",
+			wants:     []testutil.HTTPRequest{get("/orders"), get("/login")},
+			wantError: "requests were not received in order:\nexpected request 2 was not seen after request 3, the match for expected request 1: GET /login, it was seen earlier as request 1",
+		},
+		{
+			name:      "never received",
+			wants:     []testutil.HTTPRequest{get("/logout")},
+			wantError: "requests were not received in order:\nexpected request 1 was never seen: GET /logout",
+		},
+		{
+			name:  "with options",
+			wants: []testutil.HTTPRequest{{Path: "/log*"}, {Path: "/pay"}},
+			opts:  []testutil.Option{testutil.Partial()},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := &recordingTB{TB: t}
+			if test.opts == nil {
+				server.AssertReceivedInOrder(rec, test.wants...)
+			} else {
+				server.AssertReceivedInOrderWith(rec, test.opts, test.wants...)
+			}
+			switch {
+			case test.wantError == "" && len(rec.errors) != 0:
+				t.Errorf("got errors %q, want none", rec.errors)
+			case test.wantError != "" && (len(rec.errors) != 1 || rec.errors[0] != test.wantError):
+				t.Errorf("got errors %q, want:\n%s", rec.errors, test.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package testutil
+
+import "unicode/utf8"
+
+// ContextWindow limits the output of CompareStrings to n characters
+// either side of the point where the strings diverge. Without it
+// everything from that point to the end of both strings is shown which
+// is unreadable when large strings differ near the start.
+func ContextWindow(n int) Option {
+	return func(o *options) {
+		o.contextWindow = n
+	}
+}
+
+// around returns up to n runes of s either side of byte offset i with
+// "..." marking where s was cut.
+func around(s string, i int, n int) string {
+	return lastRunes(s[:i], n) + firstRunes(s[i:], n)
+}
+
+// firstRunes returns the first n runes of s followed by "..." if
+// anything was cut.
+func firstRunes(s string, n int) string {
+	end := 0
+	for k := 0; k < n && end < len(s); k++ {
+		_, size := utf8.DecodeRuneInString(s[end:])
+		end += size
+	}
+	if end < len(s) {
+		return s[:end] + "..."
+	}
+	return s
+}
+
+// lastRunes returns the last n runes of s preceded by "..." if
+// anything was cut.
+func lastRunes(s string, n int) string {
+	start := len(s)
+	for k := 0; k < n && start > 0; k++ {
+		_, size := utf8.DecodeLastRuneInString(s[:start])
+		start -= size
+	}
+	if start > 0 {
+		return "..." + s[start:]
+	}
+	return s
+}
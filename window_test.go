@@ -0,0 +1,60 @@
+package testutil_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareStringsContextWindow tests that only the characters
+// around the point of divergence are shown.
+func TestCompareStringsContextWindow(t *testing.T) {
+	long := strings.Repeat("a", 100)
+	tests := []struct {
+		name     string
+		gotStr   string
+		wantStr  string
+		wantDiff string
+	}{
+		{
+			name:    "differ in the middle",
+			gotStr:  long + "X" + long,
+			wantStr: long + "Y" + long,
+			wantDiff: `strings differ at index 100, showing up to 5 characters either side:
+##### got string #####
+...aaaaaXaaaa...
+##### want string #####
+...aaaaaYaaaa...`,
+		},
+		{
+			name:    "differ near the start",
+			gotStr:  "abX" + long,
+			wantStr: "abY",
+			wantDiff: `strings differ at index 2, showing up to 5 characters either side:
+##### got string #####
+abXaaaa...
+##### want string #####
+abY`,
+		},
+		{
+			name:     "multi-byte characters are not split",
+			gotStr:   "ab",
+			wantStr:  "ab" + strings.Repeat("é", 10),
+			wantDiff: "got a shorter string than what we wanted (characters match otherwise) and the missing characters are: ééééé...",
+		},
+		{
+			name:     "got is longer",
+			gotStr:   "ab" + long,
+			wantStr:  "ab",
+			wantDiff: "got a longer string than what we wanted (characters match otherwise) and the extra characters are: aaaaa...",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareStrings(test.gotStr, test.wantStr, testutil.ContextWindow(5)), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
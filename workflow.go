@@ -0,0 +1,87 @@
+package testutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultStepTimeout is how long a Step may take if it doesn't set its
+// own timeout.
+var DefaultStepTimeout = 10 * time.Second
+
+// Step is a single step of a state machine or multi-step workflow
+// under test.
+type Step struct {
+	Name string
+	// Action moves the workflow on, for example by sending a
+	// request or firing an event. It may be nil for steps which
+	// only check state.
+	Action func() error
+	// Check returns a diff if the observable state after the action
+	// is not what is expected, the package's Check and Compare
+	// functions are a good fit. It may be nil.
+	Check func() string
+	// Timeout limits how long the action and check may take
+	// together. DefaultStepTimeout is used if it is zero.
+	Timeout time.Duration
+}
+
+// RunSteps runs steps in order, stopping at the first one whose action
+// fails, check returns a diff or which takes too long. If a step
+// fails it returns a transcript of the whole workflow showing exactly
+// where it diverged, otherwise it returns "". A step which times out
+// is abandoned, its goroutine is left running.
+func RunSteps(steps ...Step) string {
+	lines := []string{}
+	failed := -1
+	for i, step := range steps {
+		label := fmt.Sprintf("%d %q", i+1, step.Name)
+		if failed != -1 {
+			lines = append(lines, "  skipped "+label)
+			continue
+		}
+		if diff := runStep(step); diff != "" {
+			failed = i
+			lines = append(lines, fmt.Sprintf("  FAILED  %s:\n%s", label, indent(diff, "          ")))
+			continue
+		}
+		lines = append(lines, "  ok      "+label)
+	}
+	if failed == -1 {
+		return ""
+	}
+	return fmt.Sprintf("workflow diverged at step %d %q:\n", failed+1, steps[failed].Name) + strings.Join(lines, "\n")
+}
+
+func runStep(step Step) string {
+	timeout := step.Timeout
+	if timeout == 0 {
+		timeout = DefaultStepTimeout
+	}
+	done := make(chan string, 1)
+	go func() {
+		if step.Action != nil {
+			if err := step.Action(); err != nil {
+				done <- fmt.Sprintf("action failed: %v", err)
+				return
+			}
+		}
+		if step.Check != nil {
+			done <- step.Check()
+			return
+		}
+		done <- ""
+	}()
+	select {
+	case diff := <-done:
+		return diff
+	case <-time.After(timeout):
+		return fmt.Sprintf("timed out after %v", timeout)
+	}
+}
+
+// indent prefixes every line of s.
+func indent(s string, prefix string) string {
+	return prefix + strings.Replace(s, "\n", "\n"+prefix, -1)
+}
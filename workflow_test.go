@@ -0,0 +1,84 @@
+package testutil_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lag13/testutil"
+)
+
+// TestRunSteps tests that a transcript showing where a workflow
+// diverged is generated.
+func TestRunSteps(t *testing.T) {
+	state := ""
+	transition := func(to string) func() error {
+		return func() error {
+			state = to
+			return nil
+		}
+	}
+	checkState := func(want string) func() string {
+		return func() string {
+			if state != want {
+				return fmt.Sprintf("got state %q\nwant %q", state, want)
+			}
+			return ""
+		}
+	}
+	tests := []struct {
+		name     string
+		steps    []testutil.Step
+		wantDiff string
+	}{
+		{
+			name: "all steps pass",
+			steps: []testutil.Step{
+				{Name: "create", Action: transition("created"), Check: checkState("created")},
+				{Name: "pay", Action: transition("paid"), Check: checkState("paid")},
+			},
+			wantDiff: "",
+		},
+		{
+			name: "check fails",
+			steps: []testutil.Step{
+				{Name: "create", Action: transition("created"), Check: checkState("created")},
+				{Name: "pay", Action: transition("cancelled"), Check: checkState("paid")},
+				{Name: "ship", Action: transition("shipped"), Check: checkState("shipped")},
+			},
+			wantDiff: `workflow diverged at step 2 "pay":
+  ok      1 "create"
+  FAILED  2 "pay":
+          got state "cancelled"
+          want "paid"
+  skipped 3 "ship"`,
+		},
+		{
+			name: "action fails",
+			steps: []testutil.Step{
+				{Name: "create", Action: func() error { return errors.New("boom") }},
+			},
+			wantDiff: `workflow diverged at step 1 "create":
+  FAILED  1 "create":
+          action failed: boom`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := testutil.CompareStrings(testutil.RunSteps(test.steps...), test.wantDiff); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+
+	diff := testutil.RunSteps(testutil.Step{
+		Name:    "hang",
+		Action:  func() error { time.Sleep(time.Second); return nil },
+		Timeout: 10 * time.Millisecond,
+	})
+	if !strings.HasSuffix(diff, "timed out after 10ms") {
+		t.Errorf("got diff %q, want the step to time out", diff)
+	}
+}
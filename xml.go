@@ -0,0 +1,249 @@
+package testutil
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// IgnoreAttributeOrder makes CompareXML ignore the order attributes
+// appear in on an element.
+func IgnoreAttributeOrder() Option {
+	return func(o *options) {
+		o.ignoreAttributeOrder = true
+	}
+}
+
+// IgnoreNamespacePrefixes makes CompareXML compare element and
+// attribute names by the namespace they belong to rather than the
+// prefix used for that namespace, so <a:x xmlns:a="urn:n"/> and
+// <b:x xmlns:b="urn:n"/> are equal.
+func IgnoreNamespacePrefixes() Option {
+	return func(o *options) {
+		o.ignoreNamespacePrefixes = true
+	}
+}
+
+// IgnoreInsignificantWhitespace makes CompareXML ignore text between
+// elements which is only whitespace, like indentation.
+func IgnoreInsignificantWhitespace() Option {
+	return func(o *options) {
+		o.ignoreInsignificantWhitespace = true
+	}
+}
+
+// CompareXML parses two XML documents and returns a string detailing
+// where they differ or "" if they don't. Differences are reported by
+// an XPath like location such as /order/item[2]/@sku. Comments and
+// processing instructions are ignored.
+func CompareXML(got string, want string, opts ...Option) string {
+	o := newOptions(opts)
+	gotRoot, err := parseXML(got, o)
+	if err != nil {
+		return fmt.Sprintf("could not parse got XML: %v", err)
+	}
+	wantRoot, err := parseXML(want, o)
+	if err != nil {
+		return fmt.Sprintf("could not parse want XML: %v", err)
+	}
+	if diffs := compareXMLNodes("/"+wantRoot.name, gotRoot, wantRoot, o); len(diffs) > 0 {
+		return "XML does not match:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+// xmlNode is an element or, when text is true, a text node.
+type xmlNode struct {
+	text     bool
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+}
+
+// parseXML parses an XML document into a tree. Names are kept as
+// written, prefix included, unless namespace prefixes are ignored in
+// which case they are resolved to "{namespace}local".
+func parseXML(s string, o options) (*xmlNode, error) {
+	dec := xml.NewDecoder(strings.NewReader(s))
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	// RawToken keeps the prefixes as written but doesn't check that
+	// end elements match start elements so that's done here.
+	open := []xml.Name{}
+	scopes := []map[string]string{{"xml": "http://www.w3.org/XML/1998/namespace"}}
+	for {
+		tok, err := dec.RawToken()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			scope := map[string]string{}
+			for k, v := range scopes[len(scopes)-1] {
+				scope[k] = v
+			}
+			attrs := []xml.Attr{}
+			for _, a := range tok.Attr {
+				switch {
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					scope[""] = a.Value
+				case a.Name.Space == "xmlns":
+					scope[a.Name.Local] = a.Value
+				default:
+					attrs = append(attrs, a)
+					continue
+				}
+				if !o.ignoreNamespacePrefixes {
+					attrs = append(attrs, a)
+				}
+			}
+			scopes = append(scopes, scope)
+			n := &xmlNode{name: xmlName(tok.Name, scope, true, o)}
+			for _, a := range attrs {
+				a.Name = xml.Name{Local: xmlName(a.Name, scope, false, o)}
+				n.attrs = append(n.attrs, a)
+			}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+			open = append(open, tok.Name)
+		case xml.EndElement:
+			if len(open) == 0 {
+				return nil, fmt.Errorf("unexpected end element </%s>", rawXMLName(tok.Name))
+			}
+			if start := open[len(open)-1]; start != tok.Name {
+				return nil, fmt.Errorf("element <%s> closed by </%s>", rawXMLName(start), rawXMLName(tok.Name))
+			}
+			open = open[:len(open)-1]
+			stack = stack[:len(stack)-1]
+			scopes = scopes[:len(scopes)-1]
+		case xml.CharData:
+			text := string(tok)
+			if o.ignoreInsignificantWhitespace && strings.TrimSpace(text) == "" {
+				continue
+			}
+			if k := len(parent.children); k > 0 && parent.children[k-1].text {
+				parent.children[k-1].name += text
+			} else if parent != root {
+				parent.children = append(parent.children, &xmlNode{text: true, name: text})
+			}
+		}
+	}
+	if len(open) > 0 {
+		return nil, fmt.Errorf("element <%s> is never closed", rawXMLName(open[len(open)-1]))
+	}
+	for _, n := range root.children {
+		if !n.text {
+			return n, nil
+		}
+	}
+	return nil, errors.New("no root element")
+}
+
+// rawXMLName writes a name returned by RawToken the way it appeared in
+// the document.
+func rawXMLName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+func xmlName(name xml.Name, scope map[string]string, isElement bool, o options) string {
+	if !o.ignoreNamespacePrefixes {
+		if name.Space == "" {
+			return name.Local
+		}
+		return name.Space + ":" + name.Local
+	}
+	// Unprefixed attributes are not in the default namespace.
+	if name.Space == "" && !isElement {
+		return name.Local
+	}
+	if ns := scope[name.Space]; ns != "" {
+		return "{" + ns + "}" + name.Local
+	}
+	return name.Local
+}
+
+func compareXMLNodes(path string, got *xmlNode, want *xmlNode, o options) []string {
+	if got.name != want.name {
+		return []string{fmt.Sprintf("%s: got element <%s>, want <%s>", path, got.name, want.name)}
+	}
+	diffs := compareXMLAttrs(path, got.attrs, want.attrs, o)
+	if len(got.children) != len(want.children) {
+		diffs = append(diffs, fmt.Sprintf("%s: got %d child nodes, want %d", path, len(got.children), len(want.children)))
+	}
+	seen := map[string]int{}
+	for i := 0; i < len(got.children) && i < len(want.children); i++ {
+		g, w := got.children[i], want.children[i]
+		step := "text()"
+		if !w.text {
+			step = w.name
+		}
+		seen[step]++
+		if count := xmlStepCount(want.children, step); count > 1 {
+			step = fmt.Sprintf("%s[%d]", step, seen[step])
+		}
+		childPath := path + "/" + step
+		switch {
+		case g.text && w.text:
+			if g.name != w.name {
+				diffs = append(diffs, fmt.Sprintf("%s: got %q, want %q", childPath, g.name, w.name))
+			}
+		case g.text:
+			diffs = append(diffs, fmt.Sprintf("%s: got text %q, want element <%s>", childPath, g.name, w.name))
+		case w.text:
+			diffs = append(diffs, fmt.Sprintf("%s: got element <%s>, want text %q", childPath, g.name, w.name))
+		default:
+			diffs = append(diffs, compareXMLNodes(childPath, g, w, o)...)
+		}
+	}
+	return diffs
+}
+
+func xmlStepCount(nodes []*xmlNode, step string) int {
+	count := 0
+	for _, n := range nodes {
+		if (n.text && step == "text()") || (!n.text && n.name == step) {
+			count++
+		}
+	}
+	return count
+}
+
+func compareXMLAttrs(path string, got []xml.Attr, want []xml.Attr, o options) []string {
+	gotValues, wantValues := map[string]string{}, map[string]string{}
+	gotNames, wantNames := []string{}, []string{}
+	for _, a := range got {
+		gotValues[a.Name.Local] = a.Value
+		gotNames = append(gotNames, a.Name.Local)
+	}
+	for _, a := range want {
+		wantValues[a.Name.Local] = a.Value
+		wantNames = append(wantNames, a.Name.Local)
+	}
+	diffs := []string{}
+	for _, name := range wantNames {
+		if g, ok := gotValues[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s/@%s: missing attribute", path, name))
+		} else if g != wantValues[name] {
+			diffs = append(diffs, fmt.Sprintf("%s/@%s: got %q, want %q", path, name, g, wantValues[name]))
+		}
+	}
+	for _, name := range gotNames {
+		if _, ok := wantValues[name]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s/@%s: unexpected attribute", path, name))
+		}
+	}
+	if len(diffs) == 0 && !o.ignoreAttributeOrder && strings.Join(gotNames, " ") != strings.Join(wantNames, " ") {
+		diffs = append(diffs, fmt.Sprintf("%s: got attributes in order %v, want %v", path, gotNames, wantNames))
+	}
+	sort.Strings(diffs)
+	return diffs
+}
@@ -0,0 +1,113 @@
+package testutil_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil"
+)
+
+// TestCompareXML tests that the expected diff is generated when
+// comparing XML documents.
+func TestCompareXML(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		want     string
+		opts     []testutil.Option
+		wantDiff string
+	}{
+		{
+			name:     "equal",
+			got:      `<order id="1"><item sku="a"/></order>`,
+			want:     `<order id="1"><item sku="a"></item></order>`,
+			wantDiff: "",
+		},
+		{
+			name: "values differ",
+			got: `<order id="1" status="new">
+  <item sku="a">2</item>
+  <item sku="c">1</item>
+  <note>fragile</note>
+</order>`,
+			want: `<order id="2">
+  <item sku="a">2</item>
+  <item sku="b">1</item>
+</order>`,
+			opts: []testutil.Option{testutil.IgnoreInsignificantWhitespace()},
+			wantDiff: `XML does not match:
+/order/@id: got "1", want "2"
+/order/@status: unexpected attribute
+/order: got 3 child nodes, want 2
+/order/item[2]/@sku: got "c", want "b"`,
+		},
+		{
+			name: "whitespace is significant by default",
+			got:  "<a>\n  <b/>\n</a>",
+			want: "<a><b/></a>",
+			wantDiff: `XML does not match:
+/a: got 3 child nodes, want 1
+/a/b: got text "\n  ", want element <b>`,
+		},
+		{
+			name: "attribute order",
+			got:  `<a x="1" y="2"/>`,
+			want: `<a y="2" x="1"/>`,
+			wantDiff: `XML does not match:
+/a: got attributes in order [x y], want [y x]`,
+		},
+		{
+			name:     "ignore attribute order",
+			got:      `<a x="1" y="2"/>`,
+			want:     `<a y="2" x="1"/>`,
+			opts:     []testutil.Option{testutil.IgnoreAttributeOrder()},
+			wantDiff: "",
+		},
+		{
+			name: "namespace prefixes",
+			got:  `<p:a xmlns:p="urn:x"><p:b>1</p:b></p:a>`,
+			want: `<q:a xmlns:q="urn:x"><q:b>1</q:b></q:a>`,
+			wantDiff: `XML does not match:
+/q:a: got element <p:a>, want <q:a>`,
+		},
+		{
+			name:     "ignore namespace prefixes",
+			got:      `<p:a xmlns:p="urn:x"><p:b>1</p:b></p:a>`,
+			want:     `<a xmlns="urn:x"><b>1</b></a>`,
+			opts:     []testutil.Option{testutil.IgnoreNamespacePrefixes()},
+			wantDiff: "",
+		},
+		{
+			name: "different namespaces",
+			got:  `<p:a xmlns:p="urn:x"><p:b>1</p:b></p:a>`,
+			want: `<p:a xmlns:p="urn:x"><p:b xmlns:p="urn:y">2</p:b></p:a>`,
+			opts: []testutil.Option{testutil.IgnoreNamespacePrefixes()},
+			wantDiff: `XML does not match:
+/{urn:x}a/{urn:y}b: got element <{urn:x}b>, want <{urn:y}b>`,
+		},
+		{
+			name:     "stray end element",
+			got:      `<a></a></a><b/>`,
+			want:     `<a/>`,
+			wantDiff: "could not parse got XML: unexpected end element </a>",
+		},
+		{
+			name:     "mismatched end element",
+			got:      `<a/>`,
+			want:     `<p:a><b></p:b></p:a>`,
+			wantDiff: "could not parse want XML: element <b> closed by </p:b>",
+		},
+		{
+			name:     "unclosed element",
+			got:      `<a><b></b>`,
+			want:     `<a/>`,
+			wantDiff: "could not parse got XML: element <a> is never closed",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := testutil.CompareXML(test.got, test.want, test.opts...), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package yamltest
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/lag13/testutil"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	for _, ext := range []string{".yaml", ".yml"} {
+		testutil.RegisterCassetteFormat(ext, jsonToYAML, yamlToJSON)
+	}
+}
+
+// jsonToYAML converts JSON to block style YAML keeping the order of
+// the keys. JSON is YAML already so it only has to be restyled.
+func jsonToYAML(b []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return nil, err
+	}
+	restyleYAML(&node)
+	return yaml.Marshal(&node)
+}
+
+// restyleYAML drops the flow style and quotes of the nodes parsed from
+// JSON, the encoder adds quotes back where they're needed, and writes
+// multi-line strings literally so cassettes are easy to read and edit
+// by hand.
+func restyleYAML(n *yaml.Node) {
+	n.Style = 0
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!str" && strings.Contains(n.Value, "\n") {
+		n.Style = yaml.LiteralStyle
+	}
+	for _, c := range n.Content {
+		restyleYAML(c)
+	}
+}
+
+func yamlToJSON(b []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalize(doc))
+}
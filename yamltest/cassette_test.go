@@ -0,0 +1,68 @@
+package yamltest_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/lag13/testutil"
+	_ "github.com/lag13/testutil/yamltest"
+)
+
+// TestWriteCassetteYAML tests that cassettes are written as readable
+// YAML, with secrets scrubbed, and read back.
+func TestWriteCassetteYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	c := testutil.Cassette{
+		Upstream: "https://api.example.com",
+		Interactions: []testutil.Interaction{{
+			Request: testutil.HTTPRequest{
+				Method: "GET",
+				URL:    "/users/1",
+				Header: http.Header{"Authorization": {"Bearer secret"}},
+			},
+			Response: testutil.HTTPResponse{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {"text/plain"}},
+				Body:       "line one\nline two\n",
+			},
+		}},
+	}
+	if err := testutil.WriteCassette(path, c, testutil.ScrubHeader("Authorization", "REDACTED")); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `upstream: https://api.example.com
+interactions:
+    - request:
+        method: GET
+        url: /users/1
+        header:
+            Authorization:
+                - REDACTED
+        body: ""
+      response:
+        status_code: 200
+        header:
+            Content-Type:
+                - text/plain
+        body: |
+            line one
+            line two
+`
+	if diff := testutil.CompareStrings(string(b), want, testutil.ByLine()); diff != "" {
+		t.Error(diff)
+	}
+	got, err := testutil.ReadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Interactions[0].Request.Header.Set("Authorization", "REDACTED")
+	if diff := testutil.CompareValues(got, c); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,90 @@
+// Package yamltest compares YAML streams. Importing it also registers
+// YAML as the cassette format for ".yaml" and ".yml" files with
+// testutil.RegisterCassetteFormat. It lives in its own package so that
+// only the users of testutil who need YAML depend on a YAML parser.
+//
+// It requires gopkg.in/yaml.v3 v3.0.1 or later.
+package yamltest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lag13/testutil"
+	"gopkg.in/yaml.v3"
+)
+
+// CompareYAML parses two YAML streams and returns a string detailing
+// the key paths where they differ or "" if they don't. Streams can
+// contain several documents separated by "---", like Kubernetes
+// manifests, in which case each diff says which document it is in.
+// Formatting and key order don't matter.
+func CompareYAML(got string, want string) string {
+	gotDocs, err := decodeYAML(got)
+	if err != nil {
+		return fmt.Sprintf("could not parse got YAML: %v", err)
+	}
+	wantDocs, err := decodeYAML(want)
+	if err != nil {
+		return fmt.Sprintf("could not parse want YAML: %v", err)
+	}
+	diffs := []string{}
+	if len(gotDocs) != len(wantDocs) {
+		diffs = append(diffs, fmt.Sprintf("got %d documents, want %d", len(gotDocs), len(wantDocs)))
+	}
+	for i := 0; i < len(gotDocs) && i < len(wantDocs); i++ {
+		for _, diff := range testutil.CompareTrees(gotDocs[i], wantDocs[i]) {
+			if len(gotDocs) > 1 || len(wantDocs) > 1 {
+				diff = fmt.Sprintf("document %d: %s", i+1, diff)
+			}
+			diffs = append(diffs, diff)
+		}
+	}
+	if len(diffs) > 0 {
+		return "YAML does not match:\n" + strings.Join(diffs, "\n")
+	}
+	return ""
+}
+
+func decodeYAML(s string) ([]interface{}, error) {
+	docs := []interface{}{}
+	dec := yaml.NewDecoder(strings.NewReader(s))
+	for {
+		var doc interface{}
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			return docs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		docs = append(docs, normalize(doc))
+	}
+}
+
+// normalize converts maps with non-string keys, which YAML allows,
+// into maps with string keys so testutil.CompareTrees can walk them
+// and they can be marshalled as JSON.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = normalize(e)
+		}
+		return m
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = normalize(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = normalize(e)
+		}
+		return s
+	}
+	return v
+}
@@ -0,0 +1,77 @@
+package yamltest_test
+
+import (
+	"testing"
+
+	"github.com/lag13/testutil/yamltest"
+)
+
+// TestCompareYAML tests that the expected diff is generated when
+// comparing YAML streams.
+func TestCompareYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		got      string
+		want     string
+		wantDiff string
+	}{
+		{
+			name: "equal apart from formatting and order",
+			got: `name: app
+ports: [80, 443]
+`,
+			want: `ports:
+  - 80
+  - 443
+name: app
+`,
+			wantDiff: "",
+		},
+		{
+			name: "single document differs",
+			got: `spec:
+  replicas: "3"
+  labels: {app: web}
+`,
+			want: `spec:
+  replicas: 3
+  labels: {app: api, tier: backend}
+`,
+			wantDiff: `YAML does not match:
+spec.labels: missing key "tier"
+spec.labels.app: got "web", want "api"
+spec.replicas: got string "3", want int 3`,
+		},
+		{
+			name: "multi-document streams",
+			got: `kind: Deployment
+---
+kind: Service
+port: 80
+`,
+			want: `kind: Deployment
+---
+kind: Service
+port: 8080
+---
+kind: Ingress
+`,
+			wantDiff: `YAML does not match:
+got 2 documents, want 3
+document 2: port: got 80, want 8080`,
+		},
+		{
+			name:     "invalid",
+			got:      "a: [",
+			want:     "a: 1",
+			wantDiff: "could not parse got YAML: yaml: line 1: did not find expected node content",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := yamltest.CompareYAML(test.got, test.want), test.wantDiff; got != want {
+				t.Errorf("got wrong diff:\n### GOT ###\n%s\n### WANT ###\n%s", got, want)
+			}
+		})
+	}
+}